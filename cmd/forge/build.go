@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aellingwood/forge/internal/build"
 	"github.com/aellingwood/forge/internal/config"
+	"github.com/aellingwood/forge/internal/content"
 	"github.com/spf13/cobra"
 )
 
@@ -38,6 +41,13 @@ var buildCmd = &cobra.Command{
 		destination, _ := cmd.Flags().GetString("destination")
 		verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
 		minify, _ := cmd.Flags().GetBool("minify")
+		keepGoing, _ := cmd.Flags().GetBool("keep-going")
+		failOnWarnings, _ := cmd.Flags().GetBool("fail-on-warnings")
+		lockTimeout, _ := cmd.Flags().GetDuration("lock-timeout")
+		asOf, err := asOfFlag(cmd)
+		if err != nil {
+			return err
+		}
 
 		projectRoot, err := os.Getwd()
 		if err != nil {
@@ -53,11 +63,22 @@ var buildCmd = &cobra.Command{
 			Minify:         minify,
 			BaseURL:        cfg.BaseURL,
 			ProjectRoot:    projectRoot,
+			KeepGoing:      keepGoing,
+			LockTimeout:    lockTimeout,
+			BuildDate:      asOf,
 		}
 
 		// 4. Create builder and run the build.
 		builder := build.NewBuilder(cfg, opts)
 		result, err := builder.Build()
+		var buildErrs *build.BuildErrors
+		if errors.As(err, &buildErrs) {
+			for _, pe := range buildErrs.Errors {
+				fmt.Fprintf(cmd.ErrOrStderr(), "error: %v\n", pe)
+			}
+			return fmt.Errorf("build failed: %d pages had errors (%d rendered)",
+				len(buildErrs.Errors), result.PagesRendered)
+		}
 		if err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
@@ -70,11 +91,34 @@ var buildCmd = &cobra.Command{
 			result.FilesCopied,
 			result.Duration.Round(1_000_000), // round to milliseconds
 		)
+		if result.BytesSaved > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Minification saved %d bytes\n", result.BytesSaved)
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", w)
+		}
+		if failOnWarnings && len(result.Warnings) > 0 {
+			return fmt.Errorf("build produced %d warnings", len(result.Warnings))
+		}
 
 		return nil
 	},
 }
 
+// asOfFlag returns the date given with --as-of, or the zero time if the
+// flag is not set.
+func asOfFlag(cmd *cobra.Command) (time.Time, error) {
+	s, _ := cmd.Flags().GetString("as-of")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := content.ParseDate(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --as-of: %w", err)
+	}
+	return t, nil
+}
+
 func init() {
 	buildCmd.Flags().Bool("drafts", false, "include draft content")
 	buildCmd.Flags().Bool("future", false, "include future-dated content")
@@ -82,6 +126,10 @@ func init() {
 	buildCmd.Flags().String("baseURL", "", "override base URL")
 	buildCmd.Flags().StringP("destination", "d", "public", "output directory")
 	buildCmd.Flags().Bool("minify", false, "minify output")
+	buildCmd.Flags().Bool("keep-going", false, "build every page possible and report all page errors")
+	buildCmd.Flags().Bool("fail-on-warnings", false, "exit with an error if the build reports any warnings")
+	buildCmd.Flags().String("as-of", "", "build the site as it will be on this date (YYYY-MM-DD or RFC 3339)")
+	buildCmd.Flags().Duration("lock-timeout", 0, "how long to wait for another running build of this site (0 fails immediately)")
 
 	rootCmd.AddCommand(buildCmd)
 }
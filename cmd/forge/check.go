@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aellingwood/forge/internal/build"
+	"github.com/aellingwood/forge/internal/config"
+	"github.com/aellingwood/forge/internal/content"
+	"github.com/spf13/cobra"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify the built site",
+	Long:  "Check the output of a previous build for problems.",
+}
+
+var checkLinksCmd = &cobra.Command{
+	Use:   "links",
+	Short: "Find broken internal links",
+	Long: "Parse every HTML file in the output directory and resolve each internal\n" +
+		"href, src, and srcset URL, including #fragments, against the output tree.\n" +
+		"Run it after forge build.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, _ := cmd.Root().PersistentFlags().GetString("config")
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		baseURL, _ := cmd.Flags().GetString("baseURL")
+		if baseURL == "" {
+			baseURL = cfg.BaseURL
+		}
+		destination, _ := cmd.Flags().GetString("destination")
+
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("determining project root: %w", err)
+		}
+		outputDir := destination
+		if !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(projectRoot, outputDir)
+		}
+		if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("output directory %s not found; run forge build first", destination)
+		}
+
+		// Map pages back to their source files. Files that fail to parse
+		// simply go unattributed.
+		pages, _, err := content.DiscoverAll(filepath.Join(projectRoot, "content"), cfg)
+		if err != nil {
+			return fmt.Errorf("discovering content: %w", err)
+		}
+
+		broken, err := build.CheckLinks(outputDir, content.BasePath(baseURL), build.LinkSources(pages))
+		if err != nil {
+			return err
+		}
+		if len(broken) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No broken links found.")
+			return nil
+		}
+		for _, l := range broken {
+			fmt.Fprintln(cmd.OutOrStdout(), l)
+		}
+		return fmt.Errorf("%d broken links", len(broken))
+	},
+}
+
+func init() {
+	checkLinksCmd.Flags().StringP("destination", "d", "public", "output directory to check")
+	checkLinksCmd.Flags().String("baseURL", "", "override base URL")
+
+	checkCmd.AddCommand(checkLinksCmd)
+	rootCmd.AddCommand(checkCmd)
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"path/filepath"
 	"testing"
+
+	"github.com/aellingwood/forge/internal/buildmanifest"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -10,7 +13,7 @@ func TestRootCommand(t *testing.T) {
 		t.Errorf("expected root command Use to be 'forge', got %q", rootCmd.Use)
 	}
 
-	expectedSubcommands := []string{"build", "serve", "new", "deploy", "version", "list", "config"}
+	expectedSubcommands := []string{"build", "serve", "new", "deploy", "version", "list", "config", "check", "diff"}
 	commands := rootCmd.Commands()
 
 	nameSet := make(map[string]bool)
@@ -26,7 +29,7 @@ func TestRootCommand(t *testing.T) {
 }
 
 func TestBuildFlags(t *testing.T) {
-	expectedFlags := []string{"drafts", "future", "expired", "baseURL", "destination", "minify"}
+	expectedFlags := []string{"drafts", "future", "expired", "baseURL", "destination", "minify", "keep-going", "fail-on-warnings", "lock-timeout", "as-of"}
 	for _, name := range expectedFlags {
 		flag := buildCmd.Flags().Lookup(name)
 		if flag == nil {
@@ -43,8 +46,31 @@ func TestBuildFlags(t *testing.T) {
 	}
 }
 
+func TestListFlags(t *testing.T) {
+	if listCmd.PersistentFlags().Lookup("as-of") == nil {
+		t.Fatal("expected list command to have persistent flag \"as-of\"")
+	}
+	for _, sub := range listCmd.Commands() {
+		if sub.InheritedFlags().Lookup("as-of") == nil {
+			t.Errorf("expected list %s to inherit flag \"as-of\"", sub.Name())
+		}
+	}
+}
+
+func TestDiffFlags(t *testing.T) {
+	expectedFlags := []string{"against", "ref", "json", "name-only", "ignore-build-date", "ignore"}
+	for _, name := range expectedFlags {
+		if diffCmd.Flags().Lookup(name) == nil {
+			t.Errorf("expected diff command to have flag %q", name)
+		}
+	}
+	if flag := diffCmd.Flags().Lookup("ignore-build-date"); flag != nil && flag.DefValue != "true" {
+		t.Errorf("expected ignore-build-date default to be 'true', got %q", flag.DefValue)
+	}
+}
+
 func TestServeFlags(t *testing.T) {
-	expectedFlags := []string{"port", "bind", "no-live-reload", "drafts", "future"}
+	expectedFlags := []string{"port", "bind", "no-live-reload", "drafts", "future", "as-of"}
 	for _, name := range expectedFlags {
 		flag := serveCmd.Flags().Lookup(name)
 		if flag == nil {
@@ -108,3 +134,30 @@ func TestNewSubcommands(t *testing.T) {
 		}
 	}
 }
+
+func TestManifestDescribes(t *testing.T) {
+	root := t.TempDir()
+	m := &buildmanifest.Manifest{OutputDir: "public"}
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{filepath.Join(root, "public"), true},
+		{filepath.Join(root, ".", "public") + string(filepath.Separator), true},
+		{filepath.Join(root, "dist"), false},
+	}
+	for _, tt := range tests {
+		if got := manifestDescribes(m, root, tt.dir); got != tt.want {
+			t.Errorf("manifestDescribes(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+
+	// Relative paths resolve against the working directory.
+	t.Chdir(root)
+	if !manifestDescribes(m, ".", "./public") {
+		t.Error(`manifestDescribes("./public") = false, want true`)
+	}
+	if !manifestDescribes(&buildmanifest.Manifest{OutputDir: "./public/"}, ".", "public") {
+		t.Error(`manifest for "./public/" does not describe public`)
+	}
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 
+	"github.com/aellingwood/forge/internal/buildmanifest"
 	siteconfig "github.com/aellingwood/forge/internal/config"
 	"github.com/aellingwood/forge/internal/deploy"
 	"github.com/aellingwood/forge/internal/security"
@@ -72,6 +76,16 @@ var deployCmd = &cobra.Command{
 			return fmt.Errorf("output directory %q not found; run 'forge build' first", publicDir)
 		}
 
+		// Reuse the hashes of the last build when its manifest describes
+		// this directory.
+		manifest, err := buildmanifest.Read(".")
+		switch {
+		case err == nil && manifestDescribes(manifest, ".", publicDir):
+			deployCfg.Manifest = manifest
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: %v; hashing every file\n", err)
+		}
+
 		// 4. Build AWS SDK clients.
 		ctx := context.Background()
 		awsOpts := []func(*awsconfig.LoadOptions) error{
@@ -134,6 +148,19 @@ var deployCmd = &cobra.Command{
 	},
 }
 
+// manifestDescribes reports whether m was written for the output directory
+// dir. The manifest records its directory relative to projectRoot, so both
+// are compared as absolute paths.
+func manifestDescribes(m *buildmanifest.Manifest, projectRoot, dir string) bool {
+	recorded := filepath.FromSlash(m.OutputDir)
+	if !filepath.IsAbs(recorded) {
+		recorded = filepath.Join(projectRoot, recorded)
+	}
+	a, errA := filepath.Abs(recorded)
+	b, errB := filepath.Abs(dir)
+	return errA == nil && errB == nil && a == b
+}
+
 func init() {
 	deployCmd.Flags().Bool("dry-run", false, "show what would be deployed without deploying")
 
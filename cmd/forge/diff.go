@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aellingwood/forge/internal/build"
+	"github.com/aellingwood/forge/internal/config"
+	"github.com/aellingwood/forge/internal/sitediff"
+	"github.com/spf13/cobra"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show which pages a change to the site alters",
+	Long: "Build the current tree into a temporary directory and compare its HTML\n" +
+		"with a previous build (public/ by default) or with a build of a git ref\n" +
+		"checked out into a temporary worktree. Added, removed, and changed URLs\n" +
+		"are listed with unified diffs of the normalized HTML.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath, _ := cmd.Root().PersistentFlags().GetString("config")
+		against, _ := cmd.Flags().GetString("against")
+		ref, _ := cmd.Flags().GetString("ref")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		nameOnly, _ := cmd.Flags().GetBool("name-only")
+		ignoreBuildDate, _ := cmd.Flags().GetBool("ignore-build-date")
+		ignore, _ := cmd.Flags().GetStringArray("ignore")
+
+		var opts sitediff.Options
+		if ignoreBuildDate {
+			opts.Ignore = append(opts.Ignore, sitediff.TimestampPatterns...)
+		}
+		for _, expr := range ignore {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("invalid --ignore pattern %q: %w", expr, err)
+			}
+			opts.Ignore = append(opts.Ignore, re)
+		}
+
+		projectRoot, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("determining project root: %w", err)
+		}
+		tmpDir, err := os.MkdirTemp("", "forge-diff-")
+		if err != nil {
+			return fmt.Errorf("creating temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		// Both builds see the same build date, so it cannot differ between
+		// them.
+		buildDate := time.Now()
+
+		// Determine the build to compare against.
+		base := against
+		baseDir := against
+		if ref != "" {
+			base = ref
+			baseDir = filepath.Join(tmpDir, "base")
+			if err := buildRef(cmd, ref, projectRoot, configPath, filepath.Join(tmpDir, "worktree"), baseDir, buildDate); err != nil {
+				return err
+			}
+		} else {
+			if !filepath.IsAbs(baseDir) {
+				baseDir = filepath.Join(projectRoot, baseDir)
+			}
+			if info, err := os.Stat(baseDir); err != nil || !info.IsDir() {
+				return fmt.Errorf("previous build %s not found; run forge build first or use --ref", against)
+			}
+		}
+
+		// Build the current tree.
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		currentDir := filepath.Join(tmpDir, "current")
+		if err := diffBuild(cfg, projectRoot, currentDir, buildDate); err != nil {
+			return fmt.Errorf("building current tree: %w", err)
+		}
+
+		diffs, err := sitediff.Compare(baseDir, currentDir, opts)
+		if err != nil {
+			return fmt.Errorf("comparing builds: %w", err)
+		}
+		counts := make(map[string]int)
+		for _, d := range diffs {
+			counts[d.Status]++
+		}
+
+		out := cmd.OutOrStdout()
+		if asJSON {
+			if diffs == nil {
+				diffs = []sitediff.PageDiff{}
+			}
+			if nameOnly {
+				for i := range diffs {
+					diffs[i].Diff = ""
+				}
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Base    string              `json:"base"`
+				Added   int                 `json:"added"`
+				Removed int                 `json:"removed"`
+				Changed int                 `json:"changed"`
+				Pages   []sitediff.PageDiff `json:"pages"`
+			}{
+				Base:    base,
+				Added:   counts[sitediff.Added],
+				Removed: counts[sitediff.Removed],
+				Changed: counts[sitediff.Changed],
+				Pages:   diffs,
+			})
+		}
+
+		if len(diffs) == 0 {
+			fmt.Fprintf(out, "No pages differ from %s.\n", base)
+			return nil
+		}
+		for _, d := range diffs {
+			fmt.Fprintf(out, "%-8s %s\n", d.Status, d.URL)
+			if !nameOnly && d.Diff != "" {
+				fmt.Fprint(out, d.Diff)
+			}
+		}
+		fmt.Fprintf(out, "%d pages differ from %s: %d added, %d removed, %d changed\n",
+			len(diffs), base, counts[sitediff.Added], counts[sitediff.Removed], counts[sitediff.Changed])
+		return nil
+	},
+}
+
+// diffBuild builds the project at projectRoot into outputDir without
+// touching its output directory or build manifest.
+func diffBuild(cfg *config.SiteConfig, projectRoot, outputDir string, buildDate time.Time) error {
+	builder := build.NewBuilder(cfg, build.BuildOptions{
+		OutputDir:    outputDir,
+		BaseURL:      cfg.BaseURL,
+		ProjectRoot:  projectRoot,
+		BuildDate:    buildDate,
+		SkipManifest: true,
+		LockTimeout:  30 * time.Second,
+	})
+	_, err := builder.Build()
+	return err
+}
+
+// buildRef checks out ref into a temporary git worktree at worktree, builds
+// the project there into outputDir, and removes the worktree again.
+func buildRef(cmd *cobra.Command, ref, projectRoot, configPath, worktree, outputDir string, buildDate time.Time) error {
+	top, err := git(projectRoot, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("--ref requires a git repository: %w", err)
+	}
+	// The project may live in a subdirectory of the repository.
+	realRoot, err := filepath.EvalSymlinks(projectRoot)
+	if err != nil {
+		return fmt.Errorf("resolving project root: %w", err)
+	}
+	prefix, err := filepath.Rel(top, realRoot)
+	if err != nil {
+		return fmt.Errorf("locating project in repository: %w", err)
+	}
+
+	if _, err := git(projectRoot, "worktree", "add", "--detach", "--quiet", worktree, ref); err != nil {
+		return fmt.Errorf("checking out %s: %w", ref, err)
+	}
+	defer func() {
+		if _, err := git(projectRoot, "worktree", "remove", "--force", worktree); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "warning: removing worktree %s: %v\n", worktree, err)
+		}
+	}()
+
+	refRoot := filepath.Join(worktree, prefix)
+	refConfig := configPath
+	if !filepath.IsAbs(refConfig) {
+		refConfig = filepath.Join(refRoot, refConfig)
+	}
+	cfg, err := config.Load(refConfig)
+	if err != nil {
+		return fmt.Errorf("loading config at %s: %w", ref, err)
+	}
+	if err := diffBuild(cfg, refRoot, outputDir, buildDate); err != nil {
+		return fmt.Errorf("building %s: %w", ref, err)
+	}
+	return nil
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(dir string, args ...string) (string, error) {
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func init() {
+	diffCmd.Flags().String("against", "public", "previous build directory to compare with")
+	diffCmd.Flags().String("ref", "", "compare with a build of this git ref instead of a previous build")
+	diffCmd.Flags().Bool("json", false, "print the result as JSON")
+	diffCmd.Flags().Bool("name-only", false, "list changed URLs without diffs")
+	diffCmd.Flags().Bool("ignore-build-date", true, "ignore timestamps such as .Site.BuildDate")
+	diffCmd.Flags().StringArray("ignore", nil, "ignore text matching this regular expression (repeatable)")
+
+	rootCmd.AddCommand(diffCmd)
+}
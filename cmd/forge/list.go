@@ -39,25 +39,28 @@ var listDraftsCmd = &cobra.Command{
 			return nil
 		}
 
-		printPageList(cmd, drafts)
+		printPageList(cmd, drafts, pageDate)
 		return nil
 	},
 }
 
 var listFutureCmd = &cobra.Command{
 	Use:   "future",
-	Short: "List future-dated content",
+	Short: "List content scheduled to publish later",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pages, err := discoverAllContent(cmd)
 		if err != nil {
 			return err
 		}
 
-		// Filter to only future-dated pages.
-		now := time.Now()
+		// Filter to only pages not yet published.
+		now, err := listDate(cmd)
+		if err != nil {
+			return err
+		}
 		var future []*content.Page
 		for _, p := range pages {
-			if p.Date.After(now) {
+			if p.IsFuture(now) {
 				future = append(future, p)
 			}
 		}
@@ -67,7 +70,7 @@ var listFutureCmd = &cobra.Command{
 			return nil
 		}
 
-		printPageList(cmd, future)
+		printPageList(cmd, future, func(p *content.Page) time.Time { return p.PublishDate })
 		return nil
 	},
 }
@@ -82,7 +85,10 @@ var listExpiredCmd = &cobra.Command{
 		}
 
 		// Filter to only expired pages.
-		now := time.Now()
+		now, err := listDate(cmd)
+		if err != nil {
+			return err
+		}
 		var expired []*content.Page
 		for _, p := range pages {
 			if !p.ExpiryDate.IsZero() && p.ExpiryDate.Before(now) {
@@ -95,7 +101,7 @@ var listExpiredCmd = &cobra.Command{
 			return nil
 		}
 
-		printPageList(cmd, expired)
+		printPageList(cmd, expired, pageDate)
 		return nil
 	},
 }
@@ -122,13 +128,29 @@ func discoverAllContent(cmd *cobra.Command) ([]*content.Page, error) {
 	return pages, nil
 }
 
-// printPageList prints a formatted table of pages with title, date, and URL.
-func printPageList(cmd *cobra.Command, pages []*content.Page) {
+// listDate returns the date content is judged against: --as-of if set,
+// else now.
+func listDate(cmd *cobra.Command) (time.Time, error) {
+	asOf, err := asOfFlag(cmd)
+	if err != nil || !asOf.IsZero() {
+		return asOf, err
+	}
+	return time.Now(), nil
+}
+
+// pageDate returns the date a page is listed under.
+func pageDate(p *content.Page) time.Time {
+	return p.Date
+}
+
+// printPageList prints a formatted table of pages with title, the date
+// returned by date, and URL.
+func printPageList(cmd *cobra.Command, pages []*content.Page, date func(*content.Page) time.Time) {
 	out := cmd.OutOrStdout()
 	for _, p := range pages {
 		dateStr := ""
-		if !p.Date.IsZero() {
-			dateStr = p.Date.Format("2006-01-02")
+		if d := date(p); !d.IsZero() {
+			dateStr = d.Format("2006-01-02")
 		}
 		title := p.Title
 		if title == "" {
@@ -139,6 +161,8 @@ func printPageList(cmd *cobra.Command, pages []*content.Page) {
 }
 
 func init() {
+	listCmd.PersistentFlags().String("as-of", "", "judge future and expired content as of this date (YYYY-MM-DD or RFC 3339)")
+
 	listCmd.AddCommand(listDraftsCmd)
 	listCmd.AddCommand(listFutureCmd)
 	listCmd.AddCommand(listExpiredCmd)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -37,6 +38,10 @@ var serveCmd = &cobra.Command{
 		drafts, _ := cmd.Flags().GetBool("drafts")
 		future, _ := cmd.Flags().GetBool("future")
 		verbose, _ := cmd.Root().PersistentFlags().GetBool("verbose")
+		asOf, err := asOfFlag(cmd)
+		if err != nil {
+			return err
+		}
 
 		projectRoot, err := os.Getwd()
 		if err != nil {
@@ -45,19 +50,25 @@ var serveCmd = &cobra.Command{
 
 		outputDir := filepath.Join(projectRoot, "public")
 
-		// 3. Run initial build.
+		// 3. Run initial build. Assets keep stable names so the Tailwind
+		// watcher below can rewrite css/style.css in place. Broken pages are
+		// reported without stopping the server so they can be fixed live.
 		buildOpts := build.BuildOptions{
-			IncludeDrafts: drafts,
-			IncludeFuture: future,
-			OutputDir:     outputDir,
-			Verbose:       verbose,
-			BaseURL:       cfg.BaseURL,
-			ProjectRoot:   projectRoot,
+			IncludeDrafts:   drafts,
+			IncludeFuture:   future,
+			OutputDir:       outputDir,
+			Verbose:         verbose,
+			BaseURL:         cfg.BaseURL,
+			ProjectRoot:     projectRoot,
+			SkipFingerprint: true,
+			KeepGoing:       true,
+			LockTimeout:     30 * time.Second,
+			BuildDate:       asOf,
 		}
 
 		builder := build.NewBuilder(cfg, buildOpts)
 		result, err := builder.Build()
-		if err != nil {
+		if err != nil && !logBuildErrors(err) {
 			return fmt.Errorf("initial build failed: %w", err)
 		}
 		fmt.Fprintf(cmd.OutOrStdout(),
@@ -66,6 +77,7 @@ var serveCmd = &cobra.Command{
 			result.Duration.Round(time.Millisecond),
 			renderTree(result.Pages),
 		)
+		logWarnings(result.Warnings)
 
 		// 3b. Start Tailwind CSS watch mode (if globals.css exists).
 		themeName := cfg.Theme
@@ -108,23 +120,34 @@ var serveCmd = &cobra.Command{
 		watchPaths := []string{
 			filepath.Join(projectRoot, "content"),
 			filepath.Join(projectRoot, "layouts"),
+			filepath.Join(projectRoot, "data"),
+			filepath.Join(themePath, "layouts"),
 			filepath.Join(projectRoot, "static"),
 			filepath.Join(projectRoot, "assets"),
 			filepath.Join(projectRoot, "forge.yaml"),
 		}
 
-		watcher := server.NewWatcher(watchPaths, 100*time.Millisecond, func() {
+		watcher := server.NewWatcher(watchPaths, 100*time.Millisecond, func(changed []string) {
 			log.Println("Change detected, rebuilding...")
-			rebuildResult, err := builder.Build()
-			if err != nil {
+			rebuildResult, err := builder.Rebuild(changed)
+			if err != nil && !logBuildErrors(err) {
 				log.Printf("Rebuild failed: %v", err)
 				return
 			}
-			log.Printf("Rebuild complete: %d pages in %s\n\n%s",
+			kind := "full"
+			if rebuildResult.Incremental {
+				kind = "incremental"
+			}
+			log.Printf("Rebuild complete (%s): %d pages rendered, %d changed in %s",
+				kind,
 				rebuildResult.PagesRendered,
+				len(rebuildResult.Changed),
 				rebuildResult.Duration.Round(time.Millisecond),
-				renderTree(rebuildResult.Pages),
 			)
+			if len(rebuildResult.Changed) > 0 {
+				log.Printf("Changed:\n\n%s", renderTree(rebuildResult.Changed))
+			}
+			logWarnings(rebuildResult.Warnings)
 			srv.NotifyReload()
 		})
 		srv.SetWatcher(watcher)
@@ -153,6 +176,27 @@ var serveCmd = &cobra.Command{
 	},
 }
 
+// logBuildErrors logs every page error in err and reports whether err was
+// a *build.BuildErrors, i.e. whether the rest of the site still built.
+func logBuildErrors(err error) bool {
+	var buildErrs *build.BuildErrors
+	if !errors.As(err, &buildErrs) {
+		return false
+	}
+	log.Printf("%d pages failed to build:", len(buildErrs.Errors))
+	for _, pe := range buildErrs.Errors {
+		log.Printf("  %v", pe)
+	}
+	return true
+}
+
+// logWarnings logs each build warning.
+func logWarnings(warnings []build.Warning) {
+	for _, w := range warnings {
+		log.Printf("warning: %s", w)
+	}
+}
+
 // treeNode is a node in an ordered URL tree.
 type treeNode struct {
 	children   map[string]*treeNode
@@ -230,6 +274,7 @@ func init() {
 	serveCmd.Flags().Bool("no-live-reload", false, "disable live reload")
 	serveCmd.Flags().Bool("drafts", false, "include draft content")
 	serveCmd.Flags().Bool("future", false, "include future-dated content")
+	serveCmd.Flags().String("as-of", "", "preview the site as it will be on this date (YYYY-MM-DD or RFC 3339)")
 
 	rootCmd.AddCommand(serveCmd)
 }
@@ -48,16 +48,21 @@ func GenerateAliasPages(aliases []AliasPage) map[string][]byte {
 }
 
 // aliasURLToFilePath converts an alias URL to an output file path.
-// The leading slash is stripped and the path is normalized to end with /index.html.
+// The leading slash is stripped and the path is normalized to end with
+// /index.html, unless it already names an .html file.
 //
 // Examples:
 //
-//	"/old-post/"  -> "old-post/index.html"
-//	"/old-post"   -> "old-post/index.html"
-//	"/"           -> "index.html"
+//	"/old-post/"     -> "old-post/index.html"
+//	"/old-post"      -> "old-post/index.html"
+//	"/old-post.html" -> "old-post.html"
+//	"/"              -> "index.html"
 func aliasURLToFilePath(url string) string {
 	// Strip leading slash.
 	path := strings.TrimPrefix(url, "/")
+	if strings.HasSuffix(path, ".html") {
+		return path
+	}
 
 	// Strip trailing slash.
 	path = strings.TrimSuffix(path, "/")
@@ -4,17 +4,21 @@
 package build
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"html/template"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/aellingwood/forge/internal/buildmanifest"
 	"github.com/aellingwood/forge/internal/config"
 	"github.com/aellingwood/forge/internal/content"
 	"github.com/aellingwood/forge/internal/feed"
@@ -35,23 +39,57 @@ type BuildOptions struct {
 	Minify         bool
 	BaseURL        string
 	ProjectRoot    string
+
+	// SkipFingerprint leaves assets under their original names, e.g. for the
+	// dev server, where Tailwind's watch mode rewrites css/style.css in place.
+	SkipFingerprint bool
+
+	// LockTimeout is how long to wait for another process's build of the
+	// same project to finish (see LockFile). Zero fails immediately with a
+	// *LockError.
+	LockTimeout time.Duration
+
+	// BuildDate is the build's clock: the time templates see as
+	// .Site.BuildDate and the now function, and against which future and
+	// expired pages are judged. Setting it previews the site as of another
+	// date. Zero means the time in SOURCE_DATE_EPOCH if set, else the time
+	// the build runs.
+	BuildDate time.Time
+
+	// SkipManifest leaves buildmanifest.Path untouched, for throwaway builds
+	// that do not replace the project's output, e.g. forge diff.
+	SkipManifest bool
+
+	// KeepGoing builds every page it can instead of stopping at the first
+	// broken one. Content, markdown, and template errors are collected and
+	// returned together as a *BuildErrors.
+	KeepGoing bool
 }
 
 // BuildResult contains statistics about the completed build.
 type BuildResult struct {
-	PagesRendered  int
-	FilesWritten   int
-	FilesCopied    int
-	StaticFiles    int
-	Duration       time.Duration
-	OutputSize     int64
-	Pages          []string // URL paths of all rendered pages
+	PagesRendered int
+	FilesWritten  int
+	FilesCopied   int
+	StaticFiles   int
+	Duration      time.Duration
+	OutputSize    int64
+	Pages         []string  // URL paths of all rendered pages
+	Changed       []string  // URL paths whose output differs from the previous build
+	Incremental   bool      // only pages affected by a change were re-rendered
+	BytesSaved    int64     // bytes removed by minification
+	Warnings      []Warning // non-fatal problems, sorted by file and line
 }
 
-// Builder coordinates the full static site generation pipeline.
+// Builder coordinates the full static site generation pipeline. A Builder
+// remembers the dependency graph and output hashes of its last successful
+// build, which Rebuild uses to re-render only what a change affects.
 type Builder struct {
 	config  *config.SiteConfig
 	options BuildOptions
+
+	mu    sync.Mutex
+	state *buildState
 }
 
 // NewBuilder creates a new Builder with the given site configuration and options.
@@ -64,30 +102,74 @@ func NewBuilder(cfg *config.SiteConfig, opts BuildOptions) *Builder {
 
 // Build executes the full build pipeline and returns a BuildResult summarizing
 // what was generated. The pipeline steps are:
-//  1. Clean or create the output directory
+//  1. Clean or create a staging directory, normally next to the output directory
 //  2. Discover content files
 //  3. Filter pages (drafts, future, expired)
 //  4. Render markdown in parallel
 //  5. Build taxonomy maps
 //  6. Sort pages and set navigation links
-//  7. Create template engine
-//  8. Render pages to HTML in parallel
-//  9. Write HTML files
-//  10. Copy static files
-//  11. Build Tailwind CSS
-//  12. Copy page bundle assets
+//  7. Copy static files, build Tailwind CSS, and fingerprint assets
+//  8. Create template engine and paginate list pages
+//  9. Render pages to HTML in parallel
+//  10. Write HTML files
+//  11. Copy page bundle assets and generate sitemap, robots.txt, feeds,
+//     search index, and alias redirects
+//  12. Replace the output directory with the staging directory
+//  13. Record every output file in the build manifest (buildmanifest.Path)
+//
+// A build that fails leaves the previous output directory untouched.
+//
+// With BuildOptions.KeepGoing, pages that fail are skipped and Build returns
+// both the BuildResult and a *BuildErrors listing every failure.
 func (b *Builder) Build() (*BuildResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.build(nil)
+}
+
+// projectRoot returns the configured project root, or the working directory.
+func (b *Builder) projectRoot() (string, error) {
+	if b.options.ProjectRoot != "" {
+		return b.options.ProjectRoot, nil
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("determining project root: %w", err)
+	}
+	return root, nil
+}
+
+// themePath returns the directory of the configured theme.
+func (b *Builder) themePath(projectRoot string) string {
+	themeName := b.config.Theme
+	if themeName == "" {
+		themeName = "default"
+	}
+	return filepath.Join(projectRoot, "themes", themeName)
+}
+
+// build runs the pipeline. With a nil changeSet it is a full build; otherwise
+// the output directory is updated in place, re-rendering only the pages the
+// change affects and skipping static files, images, and CSS.
+func (b *Builder) build(cs *changeSet) (*BuildResult, error) {
 	start := time.Now()
-	result := &BuildResult{}
+	result := &BuildResult{Incremental: cs != nil}
 
-	projectRoot := b.options.ProjectRoot
-	if projectRoot == "" {
-		var err error
-		projectRoot, err = os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("determining project root: %w", err)
-		}
+	// Until this build succeeds there is no trustworthy previous state.
+	prev := b.state
+	b.state = nil
+
+	projectRoot, err := b.projectRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only one process at a time may build into the project's output.
+	lock, err := acquireBuildLock(projectRoot, b.options.LockTimeout)
+	if err != nil {
+		return nil, err
 	}
+	defer lock.release()
 
 	// Determine output directory.
 	outputDir := b.options.OutputDir
@@ -98,6 +180,11 @@ func (b *Builder) Build() (*BuildResult, error) {
 		outputDir = filepath.Join(projectRoot, outputDir)
 	}
 
+	// A full build is written to a staging directory (see makeStagingDir)
+	// and swapped into place only when it completes, so a failed build
+	// leaves the last good output for the dev server and deploys.
+	finalDir := outputDir
+
 	// Determine content directory.
 	contentDir := filepath.Join(projectRoot, "content")
 
@@ -106,14 +193,53 @@ func (b *Builder) Build() (*BuildResult, error) {
 	if baseURL == "" {
 		baseURL = b.config.BaseURL
 	}
+	// Links are prefixed with the base URL's path when the site is hosted
+	// under a subdirectory; output paths are not.
+	basePath := content.BasePath(baseURL)
+
+	// Outputs are minified when enabled by flag or by build.minify.
+	var mn *minifier
+	if b.options.Minify || b.config.Build.Minify {
+		mn = newMinifier()
+	}
 
-	// Step 1: Clean output directory.
-	if err := CleanDir(outputDir); err != nil {
-		return nil, fmt.Errorf("cleaning output directory: %w", err)
+	// Everything that depends on the time of the build sees the same date,
+	// which SOURCE_DATE_EPOCH can pin for reproducible builds.
+	buildDate, err := b.buildDate()
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 1: Create an empty staging directory (full builds only).
+	if cs == nil {
+		outputDir, err = makeStagingDir(finalDir)
+		if err != nil {
+			return nil, fmt.Errorf("cleaning output directory: %w", err)
+		}
+		defer os.RemoveAll(outputDir)
+	}
+
+	// Page failures collected with KeepGoing.
+	var pageErrs []*PageError
+
+	// What wrote each output file, for the build manifest. Incremental
+	// builds keep the producers of the files they do not rewrite.
+	producers := producerTable{}
+	if cs != nil {
+		producers = prev.producers.clone()
 	}
 
 	// Step 2: Discover content.
-	pages, err := content.Discover(contentDir, b.config)
+	var pages []*content.Page
+	if b.options.KeepGoing {
+		var fileErrs []*content.FileError
+		pages, fileErrs, err = content.DiscoverAll(contentDir, b.config)
+		for _, fe := range fileErrs {
+			pageErrs = append(pageErrs, &PageError{Path: fe.Path, Err: fe.Err})
+		}
+	} else {
+		pages, err = content.Discover(contentDir, b.config)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("discovering content: %w", err)
 	}
@@ -135,33 +261,36 @@ func (b *Builder) Build() (*BuildResult, error) {
 		pages = content.FilterDrafts(pages)
 	}
 	if !b.options.IncludeFuture {
-		pages = content.FilterFuture(pages)
+		pages = content.FilterFuture(pages, buildDate)
 	}
 	if !b.options.IncludeExpired {
-		pages = content.FilterExpired(pages)
+		pages = content.FilterExpired(pages, buildDate)
 	}
 
 	// Inject a virtual home page if none was discovered (i.e., no content/_index.md).
 	// This ensures public/index.html is always generated.
 	if !hasHomePage(pages) {
-		pages = append(pages, &content.Page{
+		home := &content.Page{
 			Type: content.PageTypeHome,
 			URL:  "/",
-		})
+		}
+		if !b.config.Build.CleanURLs {
+			home.URL = content.UglyURL(home.URL, true)
+		}
+		pages = append(pages, home)
 	}
 
 	// Determine theme path early — needed for image processing and template engine.
-	themeName := b.config.Theme
-	if themeName == "" {
-		themeName = "default"
-	}
-	themePath := filepath.Join(projectRoot, "themes", themeName)
+	themePath := b.themePath(projectRoot)
 	themeStaticDir := filepath.Join(themePath, "static")
 	siteStaticDir := filepath.Join(projectRoot, "static")
 
-	// Step 3b: Process images (responsive variants) if enabled.
+	// Step 3b: Process images (responsive variants) if enabled. Incremental
+	// builds reuse the previous processor since images have not changed.
 	var imgProcessor *image.Processor
-	if b.config.Images.Enabled {
+	if cs != nil {
+		imgProcessor = prev.imgProcessor
+	} else if b.config.Images.Enabled {
 		imgProcessor = image.NewProcessor(b.config.Images, projectRoot)
 
 		// Process images from theme static directory.
@@ -180,32 +309,204 @@ func (b *Builder) Build() (*BuildResult, error) {
 
 		// Process images from page bundle directories.
 		for _, p := range pages {
-			if !p.IsBundle || p.BundleDir == "" {
+			if !p.IsBundle || p.BundleDir == "" || p.Build.NoPublish {
 				continue
 			}
-			bundleOutputDir := filepath.Join(outputDir, strings.TrimPrefix(p.URL, "/"))
-			urlPrefix := strings.TrimSuffix(p.URL, "/")
+			bundleURL := content.DirURL(p.URL)
+			bundleOutputDir := filepath.Join(outputDir, strings.TrimPrefix(bundleURL, "/"))
+			urlPrefix := strings.TrimSuffix(bundleURL, "/")
 			if err := imgProcessor.ProcessDir(p.BundleDir, bundleOutputDir, urlPrefix); err != nil {
 				return nil, fmt.Errorf("processing bundle images for %s: %w", p.URL, err)
 			}
 		}
+
+		for _, pi := range imgProcessor.Images() {
+			for _, v := range pi.Variants {
+				if rel, err := filepath.Rel(outputDir, v.Path); err == nil {
+					producers.record(rel, buildmanifest.Producer{Kind: buildmanifest.ProducerImage, Source: projectPath(projectRoot, pi.Source)})
+				}
+			}
+		}
+	}
+
+	// Step 3c: Copy static files and build CSS ahead of rendering, so that
+	// fingerprinted asset URLs are known to templates. Incremental builds do
+	// not handle static files or the theme stylesheet and reuse the previous
+	// asset manifest.
+	var assetManifest map[string]string
+	if cs != nil {
+		assetManifest = prev.assets
+	} else {
+		// Copy static files from theme and site static directories.
+		if info, err := os.Stat(themeStaticDir); err == nil && info.IsDir() {
+			copied, err := copyDirCounting(mn, themeStaticDir, outputDir, producers, projectPath(projectRoot, themeStaticDir))
+			if err != nil {
+				return nil, fmt.Errorf("copying theme static files: %w", err)
+			}
+			result.FilesCopied += copied
+		}
+
+		if info, err := os.Stat(siteStaticDir); err == nil && info.IsDir() {
+			copied, err := copyDirCounting(mn, siteStaticDir, outputDir, producers, projectPath(projectRoot, siteStaticDir))
+			if err != nil {
+				return nil, fmt.Errorf("copying site static files: %w", err)
+			}
+			result.FilesCopied += copied
+		}
+
+		// Build Tailwind CSS.
+		cssInput := filepath.Join(themePath, "static", "css", "globals.css")
+		if _, err := os.Stat(cssInput); err == nil {
+			cssOutput := filepath.Join(outputDir, "css", "style.css")
+			tb := &TailwindBuilder{}
+			if _, binErr := tb.EnsureBinary(TailwindVersion); binErr != nil {
+				relInput, _ := filepath.Rel(projectRoot, cssInput)
+				result.Warnings = append(result.Warnings, Warning{
+					Code:    WarnTailwindUnavailable,
+					Message: fmt.Sprintf("could not download Tailwind CSS binary: %v (skipping CSS compilation)", binErr),
+					File:    filepath.ToSlash(relInput),
+				})
+			} else {
+				if err := os.MkdirAll(filepath.Dir(cssOutput), 0o755); err != nil {
+					return nil, fmt.Errorf("creating CSS output directory: %w", err)
+				}
+				if err := tb.Build(cssInput, cssOutput, projectRoot); err != nil {
+					return nil, fmt.Errorf("building Tailwind CSS: %w", err)
+				}
+				result.StaticFiles++
+			}
+			producers.record(filepath.Join("css", "style.css"), buildmanifest.Producer{
+				Kind:      buildmanifest.ProducerGenerator,
+				Generator: "tailwind",
+				Source:    projectPath(projectRoot, cssInput),
+			})
+
+			// Append syntax highlighting CSS (Chroma) to the compiled stylesheet.
+			lightStyle := b.config.Highlight.Style
+			darkStyle := b.config.Highlight.DarkStyle
+			if lightStyle == "" {
+				lightStyle = "github"
+			}
+			if darkStyle == "" {
+				darkStyle = "github-dark"
+			}
+			lightCSS, darkCSS, chromaErr := content.GenerateChromaCSS(lightStyle, darkStyle)
+			if chromaErr != nil {
+				return nil, fmt.Errorf("generating syntax highlight CSS: %w", chromaErr)
+			}
+			cssFile, err := os.OpenFile(cssOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+			if err != nil {
+				return nil, fmt.Errorf("opening CSS for chroma append: %w", err)
+			}
+			if _, err := cssFile.WriteString(lightCSS + "\n" + darkCSS); err != nil {
+				cssFile.Close()
+				return nil, fmt.Errorf("writing syntax highlight CSS: %w", err)
+			}
+			cssFile.Close()
+
+			// Minify the compiled stylesheet together with the appended
+			// syntax highlighting rules.
+			if mn != nil {
+				if err := mn.copyFile(cssOutput, cssOutput); err != nil {
+					return nil, fmt.Errorf("minifying CSS: %w", err)
+				}
+			}
+		}
+
+		// Rename configured assets to name.<hash>.ext and record the mapping
+		// for the asset template function.
+		if !b.options.SkipFingerprint && len(b.config.Build.Fingerprint) > 0 {
+			assetManifest, err = fingerprintAssets(outputDir, b.config.Build.Fingerprint)
+			if err != nil {
+				return nil, fmt.Errorf("fingerprinting assets: %w", err)
+			}
+			for orig, hashed := range assetManifest {
+				producers.rename(orig[1:], hashed[1:])
+			}
+			if err := writeAssetManifest(mn, outputDir, assetManifest); err != nil {
+				return nil, fmt.Errorf("writing asset manifest: %w", err)
+			}
+			producers.record(AssetManifestFile, buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "asset-manifest"})
+			result.StaticFiles++
+		}
+	}
+
+	// Step 3d: Create the template engine.
+	userLayoutPath := filepath.Join(projectRoot, "layouts")
+
+	engine, err := tmpl.NewEngine(themePath, userLayoutPath)
+	if err != nil {
+		return nil, fmt.Errorf("creating template engine: %w", err)
 	}
+	engine.SetAssets(assetManifest)
+	engine.SetUglyURLs(!b.config.Build.CleanURLs)
+	engine.SetPermalinks(b.config.Permalinks)
+	engine.SetBasePath(basePath)
+	engine.SetBuildDate(buildDate)
 
 	// Step 4: Render markdown in parallel.
 	var mdExtensions []goldmark.Extender
 	if imgProcessor != nil {
-		mdExtensions = append(mdExtensions, image.NewResponsiveImageExtension(imgProcessor))
+		mdExtensions = append(mdExtensions, image.NewResponsiveImageExtension(imgProcessor, basePath))
 	}
 	mdRenderer := content.NewMarkdownRenderer(mdExtensions...)
+	renderHooks := engine.RenderHooks()
+	mdRenderer.SetRenderHooks(renderHooks...)
 	numWorkers := runtime.NumCPU()
 
-	err = renderParallel(pages, numWorkers, func(p *content.Page) error {
-		htmlContent, tocHTML, err := mdRenderer.RenderWithTOC([]byte(p.RawContent))
-		if err != nil {
-			return fmt.Errorf("rendering markdown for %s: %w", p.SourcePath, err)
+	// Shortcodes and render hooks run with the markdown, before any page
+	// has content, so their .Page and .Site hold page metadata only. Their
+	// output can change with templates and other pages, so pages that use
+	// them never reuse the markdown of the previous build.
+	var markupSite *tmpl.SiteContext
+	if engine.HasShortcodes() || len(renderHooks) > 0 {
+		listed := slices.DeleteFunc(slices.Clone(pages), func(p *content.Page) bool { return !p.ListedInSite() })
+		tags, categories := buildTaxonomyMaps(listed)
+		markupSite = b.buildSiteContext(pages, tags, categories, baseURL, dataFiles, imgProcessor, buildDate)
+	}
+
+	// Pages whose source is unchanged since the previous build reuse its
+	// rendered markdown.
+	var prevMarkdown map[string]renderedMarkdown
+	if cs != nil {
+		prevMarkdown = prev.markdown
+	}
+	var mdMu sync.Mutex
+	markdownCache := make(map[string]renderedMarkdown, len(pages))
+
+	pages, err = b.forEachPage(pages, numWorkers, &pageErrs, func(p *content.Page) error {
+		if cached, ok := prevMarkdown[p.SourcePath]; ok && p.SourcePath != "" && cached.raw == p.RawContent && !cached.templated {
+			p.Content = cached.content
+			p.TableOfContents = cached.toc
+		} else {
+			var render content.ShortcodeFunc
+			var hook content.RenderHookFunc
+			if markupSite != nil {
+				pageCtx := pageToContext(p, markupSite, imgProcessor, basePath)
+				render = func(sc *content.Shortcode) (string, error) {
+					return engine.RenderShortcode(sc, pageCtx, markupSite)
+				}
+				hook = func(h *content.RenderHook) (string, error) {
+					return engine.RenderHook(h, pageCtx)
+				}
+			}
+			htmlContent, tocHTML, err := mdRenderer.RenderPage(p, render, hook)
+			if err != nil {
+				return fmt.Errorf("rendering markdown: %w", err)
+			}
+			p.Content = string(htmlContent)
+			p.TableOfContents = string(tocHTML)
+		}
+		if p.SourcePath != "" {
+			mdMu.Lock()
+			markdownCache[p.SourcePath] = renderedMarkdown{
+				raw:       p.RawContent,
+				content:   p.Content,
+				toc:       p.TableOfContents,
+				templated: len(p.Shortcodes) > 0 || len(p.RenderHooks) > 0,
+			}
+			mdMu.Unlock()
 		}
-		p.Content = string(htmlContent)
-		p.TableOfContents = string(tocHTML)
 		return nil
 	})
 	if err != nil {
@@ -225,13 +526,25 @@ func (b *Builder) Build() (*BuildResult, error) {
 		}
 	}
 
-	// Step 5: Build taxonomy maps.
-	tags, categories := buildTaxonomyMaps(pages)
+	// Step 5: Build taxonomy maps from the pages listed site-wide.
+	listed := slices.DeleteFunc(slices.Clone(pages), func(p *content.Page) bool { return !p.ListedInSite() })
+	tags, categories := buildTaxonomyMaps(listed)
 
 	// Step 5b: Generate taxonomy virtual pages.
+	var taxonomies map[string]*content.Taxonomy
 	if b.config.Taxonomies != nil {
-		taxonomies := content.BuildTaxonomies(pages, b.config.Taxonomies)
+		taxonomies = content.BuildTaxonomies(listed, taxonomyNames(b.config.Taxonomies))
 		taxPages := content.GenerateTaxonomyPages(taxonomies)
+		for _, tp := range taxPages {
+			if term, ok := tp.Params["term"].(string); ok {
+				tp.URL = content.TermPermalink(b.config.Permalinks, tp.Section, term)
+				tp.Aliases = slices.DeleteFunc(tp.Aliases, func(alias string) bool { return alias == tp.URL })
+			}
+		}
+		result.Warnings = append(result.Warnings, mergedTermWarnings(taxonomies, b.config.Permalinks)...)
+		if !b.config.Build.CleanURLs {
+			content.UseUglyURLs(taxPages)
+		}
 		// Set permalinks on taxonomy pages.
 		for _, tp := range taxPages {
 			tp.Permalink = strings.TrimRight(baseURL, "/") + tp.URL
@@ -239,59 +552,101 @@ func (b *Builder) Build() (*BuildResult, error) {
 		pages = append(pages, taxPages...)
 	}
 
-	// Step 6: Sort pages by date (newest first) and set prev/next links.
+	// Step 6: Sort pages by date (newest first) and set prev/next and parent
+	// links.
 	content.SortByDate(pages, false)
 	setSectionNavigation(pages)
+	setSectionTree(pages)
 
-	// Step 7: Create template engine.
-	userLayoutPath := filepath.Join(projectRoot, "layouts")
+	// Step 7: Build the site context for templates.
+	siteCtx := b.buildSiteContext(pages, tags, categories, baseURL, dataFiles, imgProcessor, buildDate)
 
-	engine, err := tmpl.NewEngine(themePath, userLayoutPath)
-	if err != nil {
-		return nil, fmt.Errorf("creating template engine: %w", err)
+	// Build page contexts for all pages.
+	pageContextMap := b.buildPageContexts(pages, siteCtx, imgProcessor)
+
+	// Step 7b: Paginate section lists, the home page, and taxonomy terms.
+	// Pages 2..N of each list are rendered as additional virtual pages.
+	pagerPages := b.paginateLists(pages, taxonomies, pageContextMap, baseURL)
+	renderPages := slices.DeleteFunc(slices.Concat(pages, pagerPages), func(p *content.Page) bool {
+		return !p.Rendered()
+	})
+
+	// Every output file must have exactly one owner; otherwise pages and
+	// aliases would silently overwrite each other.
+	if err := checkURLOwnership(renderPages, b.config.Build.CleanURLs); err != nil {
+		return nil, err
 	}
 
-	// Build site context for templates.
-	siteCtx := b.buildSiteContext(pages, tags, categories, baseURL, dataFiles, imgProcessor)
+	// Step 7c: Record what each page depends on. An incremental build then
+	// narrows the render set to the pages affected by the change.
+	dataNames := make([]string, 0, len(dataFiles))
+	for name := range dataFiles {
+		dataNames = append(dataNames, name)
+	}
+	graph, listSignatures := buildDepGraph(renderPages, pageContextMap, engine, dataNames)
 
-	// Build page contexts for all pages.
-	pageContextMap := b.buildPageContexts(pages, siteCtx, imgProcessor)
+	taxonomyPlurals := taxonomyNames(b.config.Taxonomies)
+	if b.config.Taxonomies == nil {
+		taxonomyPlurals = map[string]string{"tags": "tag", "categories": "category"}
+	}
+	result.Warnings = append(result.Warnings, pageWarnings(renderPages, engine, projectRoot,
+		[]string{siteStaticDir, themeStaticDir}, taxonomyPlurals)...)
+
+	allURLs := make(map[string]struct{}, len(renderPages))
+	for _, p := range renderPages {
+		allURLs[p.URL] = struct{}{}
+	}
+	if cs != nil && !cs.renderAll {
+		renderPages = affectedPages(renderPages, cs, prev, graph, listSignatures)
+	}
 
 	// Step 8 & 9: Render pages to HTML in parallel and collect results.
 	type renderResult struct {
-		url  string
-		data []byte
+		url      string
+		data     []byte
+		producer buildmanifest.Producer
 	}
 	var mu sync.Mutex
 	var results []renderResult
 
-	err = renderParallel(pages, numWorkers, func(p *content.Page) error {
+	_, err = b.forEachPage(renderPages, numWorkers, &pageErrs, func(p *content.Page) error {
 		ctx := pageContextMap[p]
 		if ctx == nil {
 			return fmt.Errorf("no context for page %s", p.SourcePath)
 		}
 
-		// Resolve template.
-		templateName := engine.Resolve(p.Type.String(), p.Section, p.Layout)
+		// Resolve template, falling back to the default single template.
+		templateName := resolveTemplate(engine, p)
 		if templateName == "" {
-			// Use a fallback: wrap content in baseof if available, or output raw content.
-			templateName = engine.Resolve("single", "_default", "")
-			if templateName == "" {
-				// No template found at all, use raw rendered content.
-				mu.Lock()
-				results = append(results, renderResult{url: p.URL, data: []byte(p.Content)})
-				mu.Unlock()
-				return nil
+			// No template found at all, use raw rendered content.
+			raw := []byte(p.Content)
+			if b.config.Build.RelativeURLs {
+				raw = relativizeURLs(raw, p.URL, basePath)
+			}
+			raw, err := mn.bytes(".html", raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", p.URL, err)
 			}
+			mu.Lock()
+			results = append(results, renderResult{url: p.URL, data: raw, producer: pageProducer(p, "")})
+			mu.Unlock()
+			return nil
 		}
 
 		rendered, err := engine.ExecutePage(templateName, ctx)
 		if err != nil {
-			return fmt.Errorf("executing template %s for %s: %w", templateName, p.SourcePath, err)
+			return fmt.Errorf("executing template %s: %w", templateName, err)
+		}
+		if b.config.Build.RelativeURLs {
+			rendered = relativizeURLs(rendered, p.URL, basePath)
+		}
+		rendered, err = mn.bytes(".html", rendered)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.URL, err)
 		}
 
 		mu.Lock()
-		results = append(results, renderResult{url: p.URL, data: rendered})
+		results = append(results, renderResult{url: p.URL, data: rendered, producer: pageProducer(p, templateName)})
 		mu.Unlock()
 		return nil
 	})
@@ -299,15 +654,53 @@ func (b *Builder) Build() (*BuildResult, error) {
 		return nil, fmt.Errorf("rendering pages: %w", err)
 	}
 
-	// Step 10: Write HTML files.
+	// Step 10: Write HTML files. Outputs identical to the previous build are
+	// left untouched on incremental builds.
+	outputs := make(map[string][sha256.Size]byte, len(allURLs))
+	if prev != nil {
+		for url, sum := range prev.outputs {
+			if _, ok := allURLs[url]; ok {
+				outputs[url] = sum
+			}
+		}
+	}
 	for _, r := range results {
+		producers.record(urlToFilePath(r.url), r.producer)
+		sum := sha256.Sum256(r.data)
+		old, existed := outputs[r.url]
+		outputs[r.url] = sum
+		if !existed || old != sum {
+			result.Changed = append(result.Changed, r.url)
+		} else if cs != nil {
+			continue // already on disk
+		}
 		if err := WriteFile(outputDir, r.url, r.data); err != nil {
 			return nil, fmt.Errorf("writing %s: %w", r.url, err)
 		}
 		result.FilesWritten++
-		result.Pages = append(result.Pages, r.url)
 	}
 	result.PagesRendered = len(results)
+	for url := range allURLs {
+		result.Pages = append(result.Pages, url)
+	}
+	sort.Strings(result.Pages)
+
+	// Remove pages that existed in the previous build but no longer do.
+	if prev != nil {
+		for url := range prev.outputs {
+			if _, ok := allURLs[url]; ok {
+				continue
+			}
+			if cs != nil {
+				if err := removeOutput(outputDir, url); err != nil {
+					return nil, err
+				}
+				delete(producers, urlToFilePath(url))
+			}
+			result.Changed = append(result.Changed, url)
+		}
+	}
+	sort.Strings(result.Changed)
 
 	// Step 10b: Generate 404.html using theme template if available.
 	notFoundTemplate := engine.Resolve("404", "", "")
@@ -320,93 +713,50 @@ func (b *Builder) Build() (*BuildResult, error) {
 		if err != nil {
 			return nil, fmt.Errorf("rendering 404 page: %w", err)
 		}
-		if err := WriteFile(outputDir, "/404.html", rendered404); err != nil {
-			return nil, fmt.Errorf("writing 404.html: %w", err)
+		if b.config.Build.RelativeURLs {
+			rendered404 = relativizeURLs(rendered404, "/404.html", basePath)
 		}
-		result.FilesWritten++
-	}
-
-	// Step 11: Copy static files from theme and site static directories.
-	if info, err := os.Stat(themeStaticDir); err == nil && info.IsDir() {
-		copied, err := copyDirCounting(themeStaticDir, outputDir)
+		rendered404, err = mn.bytes(".html", rendered404)
 		if err != nil {
-			return nil, fmt.Errorf("copying theme static files: %w", err)
+			return nil, fmt.Errorf("rendering 404 page: %w", err)
 		}
-		result.FilesCopied += copied
-	}
-
-	if info, err := os.Stat(siteStaticDir); err == nil && info.IsDir() {
-		copied, err := copyDirCounting(siteStaticDir, outputDir)
-		if err != nil {
-			return nil, fmt.Errorf("copying site static files: %w", err)
+		if err := WriteFile(outputDir, "/404.html", rendered404); err != nil {
+			return nil, fmt.Errorf("writing 404.html: %w", err)
 		}
-		result.FilesCopied += copied
+		producers.record("404.html", buildmanifest.Producer{Kind: buildmanifest.ProducerPage, Template: notFoundTemplate})
+		result.FilesWritten++
 	}
 
-	// Step 11: Build Tailwind CSS.
-	cssInput := filepath.Join(themePath, "static", "css", "globals.css")
-	if _, err := os.Stat(cssInput); err == nil {
-		cssOutput := filepath.Join(outputDir, "css", "style.css")
-		tb := &TailwindBuilder{}
-		if _, binErr := tb.EnsureBinary(TailwindVersion); binErr != nil {
-			fmt.Fprintf(os.Stderr, "warning: could not download Tailwind CSS binary: %v (skipping CSS compilation)\n", binErr)
-		} else {
-			if err := os.MkdirAll(filepath.Dir(cssOutput), 0o755); err != nil {
-				return nil, fmt.Errorf("creating CSS output directory: %w", err)
-			}
-			if err := tb.Build(cssInput, cssOutput, projectRoot); err != nil {
-				return nil, fmt.Errorf("building Tailwind CSS: %w", err)
+	// Step 11: Copy page bundle assets. Bundle resources are not handled by
+	// incremental builds.
+	if cs == nil {
+		for _, p := range pages {
+			if !p.IsBundle || len(p.BundleFiles) == 0 || p.Build.NoPublish {
+				continue
 			}
-			result.StaticFiles++
-		}
-
-		// Append syntax highlighting CSS (Chroma) to the compiled stylesheet.
-		lightStyle := b.config.Highlight.Style
-		darkStyle := b.config.Highlight.DarkStyle
-		if lightStyle == "" {
-			lightStyle = "github"
-		}
-		if darkStyle == "" {
-			darkStyle = "github-dark"
-		}
-		lightCSS, darkCSS, chromaErr := content.GenerateChromaCSS(lightStyle, darkStyle)
-		if chromaErr != nil {
-			return nil, fmt.Errorf("generating syntax highlight CSS: %w", chromaErr)
-		}
-		cssFile, err := os.OpenFile(cssOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-		if err != nil {
-			return nil, fmt.Errorf("opening CSS for chroma append: %w", err)
-		}
-		if _, err := cssFile.WriteString(lightCSS + "\n" + darkCSS); err != nil {
-			cssFile.Close()
-			return nil, fmt.Errorf("writing syntax highlight CSS: %w", err)
-		}
-		cssFile.Close()
-	}
-
-	// Step 12: Copy page bundle assets.
-	for _, p := range pages {
-		if !p.IsBundle || len(p.BundleFiles) == 0 {
-			continue
-		}
-		// Determine output directory for this page's assets.
-		pageOutputDir := filepath.Join(outputDir, strings.TrimPrefix(p.URL, "/"))
-		for _, assetName := range p.BundleFiles {
-			src := filepath.Join(p.BundleDir, assetName)
-			dst := filepath.Join(pageOutputDir, assetName)
-			if err := CopyFile(src, dst); err != nil {
-				return nil, fmt.Errorf("copying bundle asset %s: %w", src, err)
+			// Determine output directory for this page's assets.
+			pageOutputDir := filepath.Join(outputDir, strings.TrimPrefix(content.DirURL(p.URL), "/"))
+			for _, assetName := range p.BundleFiles {
+				src := filepath.Join(p.BundleDir, assetName)
+				dst := filepath.Join(pageOutputDir, assetName)
+				if err := mn.copyFile(src, dst); err != nil {
+					return nil, fmt.Errorf("copying bundle asset %s: %w", src, err)
+				}
+				if rel, err := filepath.Rel(outputDir, dst); err == nil {
+					producers.record(rel, buildmanifest.Producer{Kind: buildmanifest.ProducerBundle, Source: projectPath(projectRoot, src)})
+				}
+				result.FilesCopied++
 			}
-			result.FilesCopied++
 		}
 	}
 
-	// Step 13: Generate ancillary files (sitemap, robots, feeds, search index, aliases).
+	// Step 11b: Generate ancillary files (sitemap, robots, feeds, search index, aliases).
 
-	// Collect non-draft pages for sitemap and search.
+	// Collect the non-draft pages with their own output that are listed
+	// site-wide, for the sitemap, feeds, and search.
 	var nonDraftPages []*content.Page
 	for _, p := range pages {
-		if !p.Draft {
+		if !p.Draft && p.Rendered() && p.ListedInSite() {
 			nonDraftPages = append(nonDraftPages, p)
 		}
 	}
@@ -416,24 +766,26 @@ func (b *Builder) Build() (*BuildResult, error) {
 	for _, p := range nonDraftPages {
 		sitemapEntries = append(sitemapEntries, seo.SitemapEntry{
 			URL:     p.Permalink,
-			Lastmod: p.Lastmod,
+			Lastmod: sitemapLastmod(p),
 		})
 	}
 	sitemapData, err := seo.GenerateSitemap(sitemapEntries)
 	if err != nil {
 		return nil, fmt.Errorf("generating sitemap: %w", err)
 	}
-	if err := writeDirectFile(outputDir, "sitemap.xml", sitemapData); err != nil {
+	if err := writeDirectFile(mn, outputDir, "sitemap.xml", sitemapData); err != nil {
 		return nil, fmt.Errorf("writing sitemap.xml: %w", err)
 	}
+	producers.record("sitemap.xml", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "sitemap"})
 	result.StaticFiles++
 
 	// Generate robots.txt.
 	sitemapURL := strings.TrimRight(baseURL, "/") + "/sitemap.xml"
 	robotsData := seo.GenerateRobotsTxt(sitemapURL)
-	if err := writeDirectFile(outputDir, "robots.txt", robotsData); err != nil {
+	if err := writeDirectFile(mn, outputDir, "robots.txt", robotsData); err != nil {
 		return nil, fmt.Errorf("writing robots.txt: %w", err)
 	}
+	producers.record("robots.txt", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "robots"})
 	result.StaticFiles++
 
 	// Generate manifest.json (Web App Manifest).
@@ -445,21 +797,19 @@ func (b *Builder) Build() (*BuildResult, error) {
 		Name:        b.config.Title,
 		ShortName:   shortName,
 		Description: b.config.Description,
-		StartURL:    "/",
+		StartURL:    content.WithBasePath(basePath, "/"),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("generating manifest.json: %w", err)
 	}
-	if err := writeDirectFile(outputDir, "manifest.json", manifestData); err != nil {
+	if err := writeDirectFile(mn, outputDir, "manifest.json", manifestData); err != nil {
 		return nil, fmt.Errorf("writing manifest.json: %w", err)
 	}
+	producers.record("manifest.json", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "web-manifest"})
 	result.StaticFiles++
 
 	// Collect blog posts for feeds (non-draft, section == "blog" or configured sections, sorted by date desc).
-	feedSections := b.config.Feeds.Sections
-	if len(feedSections) == 0 {
-		feedSections = []string{"blog"}
-	}
+	feedSections := b.feedSections()
 	var feedPages []*content.Page
 	for _, p := range nonDraftPages {
 		if slices.Contains(feedSections, p.Section) {
@@ -493,6 +843,7 @@ func (b *Builder) Build() (*BuildResult, error) {
 		Author:      b.config.Author.Name,
 		MaxItems:    b.config.Feeds.Limit,
 		FullContent: b.config.Feeds.FullContent,
+		Updated:     buildDate,
 	}
 
 	// Generate RSS feed (index.xml).
@@ -502,9 +853,10 @@ func (b *Builder) Build() (*BuildResult, error) {
 		if err != nil {
 			return nil, fmt.Errorf("generating RSS feed: %w", err)
 		}
-		if err := writeDirectFile(outputDir, "index.xml", rssData); err != nil {
+		if err := writeDirectFile(mn, outputDir, "index.xml", rssData); err != nil {
 			return nil, fmt.Errorf("writing index.xml: %w", err)
 		}
+		producers.record("index.xml", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "rss"})
 		result.StaticFiles++
 	}
 
@@ -515,9 +867,10 @@ func (b *Builder) Build() (*BuildResult, error) {
 		if err != nil {
 			return nil, fmt.Errorf("generating Atom feed: %w", err)
 		}
-		if err := writeDirectFile(outputDir, "atom.xml", atomData); err != nil {
+		if err := writeDirectFile(mn, outputDir, "atom.xml", atomData); err != nil {
 			return nil, fmt.Errorf("writing atom.xml: %w", err)
 		}
+		producers.record("atom.xml", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "atom"})
 		result.StaticFiles++
 	}
 
@@ -532,7 +885,7 @@ func (b *Builder) Build() (*BuildResult, error) {
 			strippedContent := search.StripHTML(p.Content)
 			indexEntries = append(indexEntries, search.IndexEntry{
 				Title:      p.Title,
-				URL:        p.URL,
+				URL:        content.WithBasePath(basePath, p.URL),
 				Tags:       p.Tags,
 				Categories: p.Categories,
 				Summary:    content.StripHTMLTags(p.Summary),
@@ -543,25 +896,42 @@ func (b *Builder) Build() (*BuildResult, error) {
 		if err != nil {
 			return nil, fmt.Errorf("generating search index: %w", err)
 		}
-		if err := writeDirectFile(outputDir, "search-index.json", searchData); err != nil {
+		if err := writeDirectFile(mn, outputDir, "search-index.json", searchData); err != nil {
 			return nil, fmt.Errorf("writing search-index.json: %w", err)
 		}
+		producers.record("search-index.json", buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "search-index"})
 		result.StaticFiles++
 	}
 
 	// Generate alias redirect pages.
 	var aliases []AliasPage
+	aliasOwners := make(map[string]*content.Page)
 	for _, p := range pages {
+		if !p.Rendered() {
+			continue // nothing to redirect to
+		}
 		for _, alias := range p.Aliases {
+			if !b.config.Build.CleanURLs {
+				alias = content.UglyURL(alias, false)
+			}
+			aliasOwners[aliasURLToFilePath(alias)] = p
 			aliases = append(aliases, AliasPage{
 				AliasURL:     alias,
-				CanonicalURL: p.URL,
+				CanonicalURL: content.WithBasePath(basePath, p.URL),
 			})
 		}
 	}
 	if len(aliases) > 0 {
 		aliasFiles := GenerateAliasPages(aliases)
-		for filePath, htmlData := range aliasFiles {
+		for _, filePath := range slices.Sorted(maps.Keys(aliasFiles)) {
+			htmlData := aliasFiles[filePath]
+			if b.config.Build.RelativeURLs {
+				htmlData = relativizeURLs(htmlData, "/"+filepath.ToSlash(filePath), basePath)
+			}
+			htmlData, err := mn.bytes(".html", htmlData)
+			if err != nil {
+				return nil, fmt.Errorf("alias %s: %w", filePath, err)
+			}
 			fullPath := filepath.Join(outputDir, filePath)
 			dir := filepath.Dir(fullPath)
 			if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -570,9 +940,36 @@ func (b *Builder) Build() (*BuildResult, error) {
 			if err := os.WriteFile(fullPath, htmlData, 0o644); err != nil {
 				return nil, fmt.Errorf("writing alias file %s: %w", fullPath, err)
 			}
+			aliasProducer := buildmanifest.Producer{Kind: buildmanifest.ProducerAlias}
+			if owner := aliasOwners[filePath]; owner != nil && owner.SourcePath != "" {
+				aliasProducer.Source = "content/" + owner.SourcePath
+			}
+			producers.record(filePath, aliasProducer)
 			result.StaticFiles++
 		}
 	}
+	// An incremental build keeps the producers of the previous one, so
+	// redirects for aliases that were dropped, or whose page was removed,
+	// are still listed; delete them.
+	for filePath, prod := range producers {
+		if _, ok := aliasOwners[filePath]; ok || prod.Kind != buildmanifest.ProducerAlias {
+			continue
+		}
+		if err := removeOutputFile(outputDir, filePath); err != nil {
+			return nil, err
+		}
+		delete(producers, filePath)
+	}
+
+	// Verify internal links in the finished output.
+	if b.config.Build.CheckLinks {
+		broken, err := CheckLinks(outputDir, basePath, LinkSources(slices.Concat(pages, pagerPages)))
+		if err != nil {
+			return nil, fmt.Errorf("checking links: %w", err)
+		}
+		result.Warnings = append(result.Warnings, brokenLinkWarnings(broken, projectRoot, outputDir)...)
+	}
+	sortWarnings(result.Warnings)
 
 	// Calculate output size.
 	size, err := DirSize(outputDir)
@@ -580,13 +977,88 @@ func (b *Builder) Build() (*BuildResult, error) {
 		return nil, fmt.Errorf("calculating output size: %w", err)
 	}
 	result.OutputSize = size
+	result.BytesSaved = mn.Saved()
+
+	// Step 12: Publish the staged output. A build that kept going past page
+	// errors still completed, so its output is published too.
+	if cs == nil {
+		warnings, err := ReplaceDir(outputDir, finalDir)
+		if err != nil {
+			return nil, fmt.Errorf("publishing output: %w", err)
+		}
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+
+	// Step 13: Record the published output, with hashes for deploys.
+	var manifest *buildmanifest.Manifest
+	if !b.options.SkipManifest {
+		var prevManifest *buildmanifest.Manifest
+		if cs != nil {
+			prevManifest = prev.manifest
+		}
+		manifest, err = writeBuildManifest(projectRoot, finalDir, producers, prevManifest)
+		if err != nil {
+			return nil, err
+		}
+	}
 	result.Duration = time.Since(start)
 
+	// A build with failed pages leaves no state behind, so the next
+	// Rebuild is a full build that retries them.
+	if len(pageErrs) > 0 {
+		slices.SortFunc(pageErrs, func(a, b *PageError) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+		return result, &BuildErrors{Errors: pageErrs}
+	}
+
+	b.state = &buildState{
+		graph:        graph,
+		lists:        listSignatures,
+		outputs:      outputs,
+		markdown:     markdownCache,
+		imgProcessor: imgProcessor,
+		assets:       assetManifest,
+		producers:    producers,
+		manifest:     manifest,
+	}
 	return result, nil
 }
 
-// writeDirectFile writes data to a named file directly in the output directory.
-func writeDirectFile(outputDir, filename string, data []byte) error {
+// forEachPage runs fn over pages in parallel and returns the pages it
+// succeeded on. Normally it stops at the first error; with KeepGoing every
+// page is processed and failures are appended to errs instead.
+func (b *Builder) forEachPage(pages []*content.Page, workers int, errs *[]*PageError, fn func(*content.Page) error) ([]*content.Page, error) {
+	if !b.options.KeepGoing {
+		return pages, renderParallel(pages, workers, fn)
+	}
+	failed := renderParallelAll(pages, workers, fn)
+	if len(failed) == 0 {
+		return pages, nil
+	}
+	ok := make([]*content.Page, 0, len(pages)-len(failed))
+	for _, p := range pages {
+		err, isFailed := failed[p]
+		if !isFailed {
+			ok = append(ok, p)
+			continue
+		}
+		path := p.SourcePath
+		if path == "" {
+			path = p.URL
+		}
+		*errs = append(*errs, &PageError{Path: path, Err: err})
+	}
+	return ok, nil
+}
+
+// writeDirectFile writes data to a named file directly in the output
+// directory, minifying it by extension when mn is non-nil.
+func writeDirectFile(mn *minifier, outputDir, filename string, data []byte) error {
+	data, err := mn.bytes(filepath.Ext(filename), data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
 	filePath := filepath.Join(outputDir, filename)
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
@@ -596,7 +1068,9 @@ func writeDirectFile(outputDir, filename string, data []byte) error {
 }
 
 // copyDirCounting copies a directory and returns the number of files copied.
-func copyDirCounting(src, dst string) (int, error) {
+// CSS and JS files are minified when mn is non-nil. Each file is recorded
+// in producers as a static copy of srcRel, the project-relative path of src.
+func copyDirCounting(mn *minifier, src, dst string, producers producerTable, srcRel string) (int, error) {
 	count := 0
 	err := filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
@@ -612,15 +1086,46 @@ func copyDirCounting(src, dst string) (int, error) {
 			return os.MkdirAll(dstPath, 0o755)
 		}
 
-		if err := CopyFile(path, dstPath); err != nil {
+		if err := mn.copyFile(path, dstPath); err != nil {
 			return err
 		}
+		producers.record(rel, buildmanifest.Producer{Kind: buildmanifest.ProducerStatic, Source: srcRel + "/" + filepath.ToSlash(rel)})
 		count++
 		return nil
 	})
 	return count, err
 }
 
+// sitemapLastmod returns when p last changed for the sitemap: its lastmod,
+// else when it went live, which for a backdated post scheduled for later is
+// its publishDate rather than its date.
+func sitemapLastmod(p *content.Page) time.Time {
+	if !p.Lastmod.IsZero() {
+		return p.Lastmod
+	}
+	if p.PublishDate.After(p.Date) {
+		return p.PublishDate
+	}
+	return p.Date
+}
+
+// buildDate returns the date of the build: BuildOptions.BuildDate if set,
+// else the time in the SOURCE_DATE_EPOCH environment variable (Unix
+// seconds), else now.
+func (b *Builder) buildDate() (time.Time, error) {
+	if !b.options.BuildDate.IsZero() {
+		return b.options.BuildDate, nil
+	}
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		sec, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: must be Unix seconds", epoch)
+		}
+		return time.Unix(sec, 0).UTC(), nil
+	}
+	return time.Now(), nil
+}
+
 // buildSiteContext creates a SiteContext for template rendering.
 func (b *Builder) buildSiteContext(
 	pages []*content.Page,
@@ -629,13 +1134,16 @@ func (b *Builder) buildSiteContext(
 	baseURL string,
 	dataFiles map[string]any,
 	imgProc *image.Processor,
+	buildDate time.Time,
 ) *tmpl.SiteContext {
+	basePath := content.BasePath(baseURL)
+
 	// Build menu items.
 	menuItems := make([]tmpl.MenuItemContext, len(b.config.Menu.Main))
 	for i, item := range b.config.Menu.Main {
 		menuItems[i] = tmpl.MenuItemContext{
 			Name:   item.Name,
-			URL:    item.URL,
+			URL:    content.WithBasePath(basePath, item.URL),
 			Weight: item.Weight,
 		}
 	}
@@ -643,12 +1151,17 @@ func (b *Builder) buildSiteContext(
 	// Build section map.
 	sections := make(map[string][]*tmpl.PageContext)
 
-	// Build page contexts for site.
+	// Build page contexts for site. Unlisted pages are only reachable
+	// through GetPage.
+	allPages := make([]*tmpl.PageContext, 0, len(pages))
 	sitePages := make([]*tmpl.PageContext, 0, len(pages))
 	for _, p := range pages {
-		pc := pageToContext(p, nil, imgProc) // site will be set after
-		sitePages = append(sitePages, pc)
-		if p.Section != "" {
+		pc := pageToContext(p, nil, imgProc, basePath) // site will be set after
+		allPages = append(allPages, pc)
+		if p.ListedInSite() {
+			sitePages = append(sitePages, pc)
+		}
+		if p.Section != "" && p.ListedInSection() {
 			sections[p.Section] = append(sections[p.Section], pc)
 		}
 	}
@@ -659,7 +1172,7 @@ func (b *Builder) buildSiteContext(
 		tagMap := make(map[string][]*tmpl.PageContext)
 		for term, tagPages := range tags {
 			for _, tp := range tagPages {
-				tagMap[term] = append(tagMap[term], pageToContext(tp, nil, imgProc))
+				tagMap[term] = append(tagMap[term], pageToContext(tp, nil, imgProc, basePath))
 			}
 		}
 		taxonomies["tags"] = tagMap
@@ -668,7 +1181,7 @@ func (b *Builder) buildSiteContext(
 		catMap := make(map[string][]*tmpl.PageContext)
 		for term, catPages := range categories {
 			for _, cp := range catPages {
-				catMap[term] = append(catMap[term], pageToContext(cp, nil, imgProc))
+				catMap[term] = append(catMap[term], pageToContext(cp, nil, imgProc, basePath))
 			}
 		}
 		taxonomies["categories"] = catMap
@@ -698,15 +1211,17 @@ func (b *Builder) buildSiteContext(
 		Pages:      sitePages,
 		Sections:   sections,
 		Taxonomies: taxonomies,
-		BuildDate:  time.Now(),
+		BuildDate:  buildDate,
+		AllPages:   allPages,
 	}
 }
 
 // buildPageContexts creates a map from Page to PageContext for all pages.
 func (b *Builder) buildPageContexts(pages []*content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Processor) map[*content.Page]*tmpl.PageContext {
 	m := make(map[*content.Page]*tmpl.PageContext, len(pages))
+	basePath := content.BasePath(siteCtx.BaseURL)
 	for _, p := range pages {
-		ctx := pageToContext(p, siteCtx, imgProc)
+		ctx := pageToContext(p, siteCtx, imgProc, basePath)
 		m[p] = ctx
 	}
 
@@ -725,6 +1240,33 @@ func (b *Builder) buildPageContexts(pages []*content.Page, siteCtx *tmpl.SiteCon
 		}
 	}
 
+	// Wire up the section tree: parents, ancestors, and child sections.
+	children := make(map[*content.Page][]*content.Page)
+	for _, p := range pages {
+		if p.Parent == nil {
+			continue
+		}
+		if parentCtx, ok := m[p.Parent]; ok {
+			m[p].Parent = parentCtx
+		}
+		if p.Type == content.PageTypeList && p.ListedInSection() {
+			children[p.Parent] = append(children[p.Parent], p)
+		}
+	}
+	for _, p := range pages {
+		ctx := m[p]
+		for a := ctx.Parent; a != nil; a = a.Parent {
+			ctx.Ancestors = append([]*tmpl.PageContext{a}, ctx.Ancestors...)
+		}
+		if sections := children[p]; len(sections) > 0 {
+			content.SortByTitle(sections)
+			content.SortByWeight(sections)
+			for _, s := range sections {
+				ctx.Sections = append(ctx.Sections, m[s])
+			}
+		}
+	}
+
 	// Wire up project <-> post bidirectional linking.
 	projectPostMap := buildProjectPostMap(pages)
 	projectPageIndex := buildProjectPageIndex(pages)
@@ -766,7 +1308,8 @@ func hasHomePage(pages []*content.Page) bool {
 
 // pageToContext converts a content.Page to a template.PageContext.
 // If imgProc is non-nil, responsive image fields are populated on the cover image.
-func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Processor) *tmpl.PageContext {
+// The page and cover URLs are prefixed with basePath (see content.BasePath).
+func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Processor, basePath string) *tmpl.PageContext {
 	ctx := &tmpl.PageContext{
 		Title:           p.Title,
 		Description:     p.Description,
@@ -774,9 +1317,11 @@ func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Pr
 		Summary:         template.HTML(p.Summary),
 		Date:            p.Date,
 		Lastmod:         p.Lastmod,
+		PublishDate:     p.PublishDate,
+		ExpiryDate:      p.ExpiryDate,
 		Draft:           p.Draft,
 		Slug:            p.Slug,
-		URL:             p.URL,
+		URL:             content.WithBasePath(basePath, p.URL),
 		Permalink:       p.Permalink,
 		ReadingTime:     p.ReadingTime,
 		WordCount:       p.WordCount,
@@ -787,6 +1332,7 @@ func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Pr
 		Params:          p.Params,
 		TableOfContents: template.HTML(p.TableOfContents),
 		Section:         p.Section,
+		Path:            p.SourcePath,
 		Type:            p.Type.String(),
 		Site:            siteCtx,
 	}
@@ -794,10 +1340,10 @@ func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Pr
 	if p.Cover != nil {
 		coverURL := p.Cover.Image
 		if coverURL != "" && !strings.HasPrefix(coverURL, "/") && !strings.HasPrefix(coverURL, "http") {
-			coverURL = strings.TrimSuffix(p.URL, "/") + "/" + coverURL
+			coverURL = content.DirURL(p.URL) + coverURL
 		}
 		cover := &tmpl.CoverImage{
-			Image:   coverURL,
+			Image:   content.WithBasePath(basePath, coverURL),
 			Alt:     p.Cover.Alt,
 			Caption: p.Cover.Caption,
 		}
@@ -806,8 +1352,8 @@ func pageToContext(p *content.Page, siteCtx *tmpl.SiteContext, imgProc *image.Pr
 			if pi := imgProc.GetImage(coverURL); pi != nil {
 				cover.Width = pi.Width
 				cover.Height = pi.Height
-				cover.Srcset = image.BuildSrcset(pi, "")     // original format
-				cover.WebPSrcset = image.BuildSrcset(pi, "webp")
+				cover.Srcset = image.BuildSrcset(pi, "", basePath) // original format
+				cover.WebPSrcset = image.BuildSrcset(pi, "webp", basePath)
 				cover.Sizes = image.DefaultSizes
 			}
 		}
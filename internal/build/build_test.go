@@ -1,13 +1,23 @@
 package build
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"runtime"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/aellingwood/forge/embedded"
+	"github.com/aellingwood/forge/internal/buildmanifest"
 	"github.com/aellingwood/forge/internal/config"
 	"github.com/aellingwood/forge/internal/content"
 	"github.com/aellingwood/forge/internal/scaffold"
@@ -188,6 +198,147 @@ func TestCleanDir_NonExistent(t *testing.T) {
 	}
 }
 
+func TestReplaceDir(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, ".public.staging")
+	dst := filepath.Join(root, "public")
+
+	for dir, name := range map[string]string{src: "new.txt", dst: "old.txt"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ReplaceDir(src, dst); err != nil {
+		t.Fatalf("ReplaceDir error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "new.txt")); err != nil {
+		t.Errorf("new.txt not in place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt still present: %v", err)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("root has %d entries after ReplaceDir, want only public/", len(entries))
+	}
+}
+
+func TestReplaceDir_NoExistingDst(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	if err := os.MkdirAll(src, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReplaceDir(src, dst); err != nil {
+		t.Fatalf("ReplaceDir error: %v", err)
+	}
+	if info, err := os.Stat(dst); err != nil || !info.IsDir() {
+		t.Errorf("dst not created: %v", err)
+	}
+}
+
+func TestReplaceDir_ReadOnlyParent(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs Unix directory permissions that the user cannot bypass")
+	}
+	root := t.TempDir()
+	parent := filepath.Join(root, "site")
+	dst := filepath.Join(parent, "public")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "old.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(parent, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(parent, 0o755) })
+
+	src, err := makeStagingDir(dst)
+	if err != nil {
+		t.Fatalf("makeStagingDir error: %v", err)
+	}
+	defer os.RemoveAll(src)
+	if filepath.Dir(src) == parent {
+		t.Fatalf("staging dir %s is in the read-only parent", src)
+	}
+	if err := os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReplaceDir(src, dst); err != nil {
+		t.Fatalf("ReplaceDir error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "new.txt")); err != nil {
+		t.Errorf("new.txt not copied into place: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("old.txt still present: %v", err)
+	}
+}
+
+func TestRenameUnsupported(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}, true},
+		{&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EBUSY}, true},
+		{&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EACCES}, true},
+		{&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOSPC}, false},
+		{errors.New("other"), false},
+	}
+	for _, tc := range tests {
+		if got := renameUnsupported(tc.err); got != tc.want {
+			t.Errorf("renameUnsupported(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestCopyIntoDir(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	for _, f := range []string{filepath.Join(src, "css", "new.css"), filepath.Join(dst, "stale", "old.txt")} {
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyIntoDir(src, dst); err != nil {
+		t.Fatalf("copyIntoDir error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "css", "new.css")); err != nil {
+		t.Errorf("css/new.css not copied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "stale")); !os.IsNotExist(err) {
+		t.Errorf("stale/ still present: %v", err)
+	}
+	after, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("copyIntoDir should keep dst itself, which may be a mount point")
+	}
+}
+
 func TestDirSize(t *testing.T) {
 	dir := t.TempDir()
 
@@ -276,6 +427,29 @@ func TestRenderParallel_Error(t *testing.T) {
 	}
 }
 
+func TestRenderParallelAll(t *testing.T) {
+	pages := []*content.Page{
+		{Title: "A", SourcePath: "a.md"},
+		{Title: "B", SourcePath: "b.md"},
+		{Title: "C", SourcePath: "c.md"},
+	}
+
+	var visited atomic.Int32
+	failed := renderParallelAll(pages, 1, func(p *content.Page) error {
+		visited.Add(1)
+		if p.Title != "B" {
+			return os.ErrInvalid
+		}
+		return nil
+	})
+	if visited.Load() != 3 {
+		t.Errorf("visited %d pages, want 3", visited.Load())
+	}
+	if len(failed) != 2 || failed[pages[0]] == nil || failed[pages[2]] == nil {
+		t.Errorf("failed = %v, want errors for A and C", failed)
+	}
+}
+
 func TestSetSectionNavigation(t *testing.T) {
 	p1 := &content.Page{Title: "Post 1", Type: content.PageTypeSingle, Section: "blog"}
 	p2 := &content.Page{Title: "Post 2", Type: content.PageTypeSingle, Section: "blog"}
@@ -558,8 +732,8 @@ func TestBuild_FullPipeline(t *testing.T) {
 
 	// Verify static files were copied.
 	staticFiles := []string{
-		"css/style.css",       // From theme
-		"images/logo.png",     // From site
+		"css/style.css",   // From theme
+		"images/logo.png", // From site
 	}
 	for _, f := range staticFiles {
 		path := filepath.Join(outputDir, f)
@@ -582,162 +756,1557 @@ func TestBuild_FullPipeline(t *testing.T) {
 	}
 }
 
-func TestBuild_IncludeDrafts(t *testing.T) {
+func TestBuild_Minify(t *testing.T) {
 	root := setupTestSite(t)
 	outputDir := filepath.Join(root, "public")
 
 	cfg := config.Default()
 	cfg.Title = "Test Site"
 	cfg.BaseURL = "https://example.com"
-	cfg.Theme = "default"
+	cfg.Build.Minify = true
 
 	builder := NewBuilder(cfg, BuildOptions{
-		ProjectRoot:   root,
-		OutputDir:     outputDir,
-		IncludeDrafts: true,
+		ProjectRoot: root,
+		OutputDir:   outputDir,
 	})
-
 	result, err := builder.Build()
 	if err != nil {
-		t.Fatalf("Build() with drafts error: %v", err)
+		t.Fatalf("Build() error: %v", err)
+	}
+	if result.BytesSaved <= 0 {
+		t.Errorf("BytesSaved = %d, want > 0", result.BytesSaved)
 	}
 
-	// Draft should now be present.
-	draftPath := filepath.Join(outputDir, "blog", "draft-post", "index.html")
-	if _, err := os.Stat(draftPath); os.IsNotExist(err) {
-		t.Error("draft post should be in output when IncludeDrafts is true")
+	postHTML, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatalf("reading first post: %v", err)
+	}
+	if strings.Contains(string(postHTML), "\n<head>") {
+		t.Errorf("page HTML not minified:\n%s", postHTML)
 	}
 
-	// Should have more pages rendered than without drafts.
-	if result.PagesRendered < 4 {
-		t.Errorf("PagesRendered = %d with drafts, want >= 4", result.PagesRendered)
+	css, err := os.ReadFile(filepath.Join(outputDir, "css", "style.css"))
+	if err != nil {
+		t.Fatalf("reading style.css: %v", err)
+	}
+	if string(css) != "body{margin:0}" {
+		t.Errorf("style.css = %q, want minified", css)
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(outputDir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("reading sitemap.xml: %v", err)
+	}
+	if strings.Contains(string(sitemap), "\n  ") {
+		t.Errorf("sitemap.xml not minified:\n%s", sitemap)
 	}
 }
 
-func TestBuild_FilterFuture(t *testing.T) {
+func TestBuild_Fingerprint(t *testing.T) {
 	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
 
-	// Create a future-dated post.
-	futureDate := time.Now().Add(24 * time.Hour * 365).Format("2006-01-02")
-	futurePost := `---
-title: "Future Post"
-date: ` + futureDate + `
----
-This is from the future.
-`
-	if err := os.WriteFile(filepath.Join(root, "content", "blog", "future-post.md"), []byte(futurePost), 0o644); err != nil {
+	cssTemplate := `<!DOCTYPE html>
+<html>
+<head><link rel="stylesheet" href="{{ asset "/css/style.css" }}"></head>
+<body>{{ .Content }}</body>
+</html>`
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "single.html"),
+		[]byte(cssTemplate), 0o644,
+	); err != nil {
 		t.Fatal(err)
 	}
 
-	outputDir := filepath.Join(root, "public")
-
 	cfg := config.Default()
-	cfg.Title = "Test Site"
 	cfg.BaseURL = "https://example.com"
-	cfg.Theme = "default"
+	cfg.Build.Fingerprint = []string{"css/*.css", "js/*.js"}
 
-	// Without IncludeFuture (default) - future post should be excluded.
 	builder := NewBuilder(cfg, BuildOptions{
 		ProjectRoot: root,
 		OutputDir:   outputDir,
 	})
-	_, err := builder.Build()
-	if err != nil {
+	if _, err := builder.Build(); err != nil {
 		t.Fatalf("Build() error: %v", err)
 	}
 
-	futurePath := filepath.Join(outputDir, "blog", "future-post", "index.html")
-	if _, err := os.Stat(futurePath); !os.IsNotExist(err) {
-		t.Error("future post should not be in output when IncludeFuture is false")
+	data, err := os.ReadFile(filepath.Join(outputDir, AssetManifestFile))
+	if err != nil {
+		t.Fatalf("reading asset manifest: %v", err)
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("decoding asset manifest: %v", err)
+	}
+	hashed, ok := manifest["/css/style.css"]
+	if !ok {
+		t.Fatalf("manifest has no entry for /css/style.css: %v", manifest)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(hashed))); err != nil {
+		t.Errorf("fingerprinted file %s missing: %v", hashed, err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "css", "style.css")); !os.IsNotExist(err) {
+		t.Error("original css/style.css should have been renamed")
 	}
 
-	// With IncludeFuture - future post should be included.
-	builder2 := NewBuilder(cfg, BuildOptions{
-		ProjectRoot:   root,
-		OutputDir:     outputDir,
-		IncludeFuture: true,
-	})
-	_, err = builder2.Build()
+	// The hashed stylesheet keeps its producer in the build manifest.
+	m, err := buildmanifest.Read(root)
 	if err != nil {
-		t.Fatalf("Build() with future error: %v", err)
+		t.Fatalf("buildmanifest.Read() error: %v", err)
+	}
+	var producer buildmanifest.Producer
+	for _, f := range m.Files {
+		if "/"+f.Path == hashed {
+			producer = f.Producer
+		}
+	}
+	if producer != (buildmanifest.Producer{Kind: buildmanifest.ProducerStatic, Source: "themes/default/static/css/style.css"}) {
+		t.Errorf("%s producer = %+v", hashed, producer)
 	}
 
-	if _, err := os.Stat(futurePath); os.IsNotExist(err) {
-		t.Error("future post should be in output when IncludeFuture is true")
+	postHTML, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatalf("reading first post: %v", err)
+	}
+	if !strings.Contains(string(postHTML), `href="`+hashed+`"`) {
+		t.Errorf("page should link %s:\n%s", hashed, postHTML)
+	}
+
+	// Serving keeps stable names.
+	builder = NewBuilder(cfg, BuildOptions{
+		ProjectRoot:     root,
+		OutputDir:       outputDir,
+		SkipFingerprint: true,
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "css", "style.css")); err != nil {
+		t.Errorf("css/style.css should exist with SkipFingerprint: %v", err)
 	}
 }
 
-func TestBuild_PageBundleAssets(t *testing.T) {
+func TestBuild_UserLayoutLinksPlainCSS(t *testing.T) {
 	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
 
-	// Create a page bundle.
-	bundleDir := filepath.Join(root, "content", "blog", "bundle-post")
-	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+	// A user layout that links the theme stylesheet by its plain name, as
+	// layouts written before fingerprinting do.
+	layout := `<!DOCTYPE html>
+<html>
+<head><link rel="stylesheet" href="/css/style.css"></head>
+<body>{{ .Content }}</body>
+</html>`
+	if err := os.MkdirAll(filepath.Join(root, "layouts", "_default"), 0o755); err != nil {
 		t.Fatal(err)
 	}
-
-	bundleContent := `---
-title: "Bundle Post"
-date: 2024-04-01
----
-A post with assets.
-`
-	if err := os.WriteFile(filepath.Join(bundleDir, "index.md"), []byte(bundleContent), 0o644); err != nil {
+	if err := os.WriteFile(filepath.Join(root, "layouts", "_default", "single.html"), []byte(layout), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	if err := os.WriteFile(filepath.Join(bundleDir, "hero.jpg"), []byte("fake-jpg-data"), 0o644); err != nil {
-		t.Fatal(err)
+
+	cfg := config.Default()
+	cfg.BaseURL = "https://example.com"
+	cfg.Build.CheckLinks = true
+	result, err := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir}).Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
 	}
-	if err := os.WriteFile(filepath.Join(bundleDir, "diagram.svg"), []byte("<svg></svg>"), 0o644); err != nil {
-		t.Fatal(err)
+
+	if _, err := os.Stat(filepath.Join(outputDir, "css", "style.css")); err != nil {
+		t.Errorf("css/style.css should be published under its plain name: %v", err)
 	}
+	if _, err := os.Stat(filepath.Join(outputDir, AssetManifestFile)); !os.IsNotExist(err) {
+		t.Error("no asset manifest should be written without fingerprint patterns")
+	}
+	for _, w := range result.Warnings {
+		if w.Code == WarnBrokenLink {
+			t.Errorf("unexpected broken link: %s", w)
+		}
+	}
+}
 
+func TestBuild_UglyURLs(t *testing.T) {
+	root := setupTestSite(t)
 	outputDir := filepath.Join(root, "public")
+
+	post := `---
+title: "Moved Post"
+date: 2024-01-10
+tags:
+  - go
+aliases:
+  - /old-post/
+---
+Moved.
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "moved-post.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
 	cfg := config.Default()
-	cfg.Title = "Test Site"
 	cfg.BaseURL = "https://example.com"
-	cfg.Theme = "default"
+	cfg.Build.CleanURLs = false
+	cfg.Pagination.PageSize = 2
 
 	builder := NewBuilder(cfg, BuildOptions{
 		ProjectRoot: root,
 		OutputDir:   outputDir,
 	})
-
 	result, err := builder.Build()
 	if err != nil {
 		t.Fatalf("Build() error: %v", err)
 	}
 
-	// Verify the bundle page was rendered.
-	postPath := filepath.Join(outputDir, "blog", "bundle-post", "index.html")
-	if _, err := os.Stat(postPath); os.IsNotExist(err) {
-		t.Error("bundle post page should exist")
+	for _, url := range []string{"/index.html", "/blog/index.html", "/blog/page/2.html", "/blog/first-post.html", "/tags/index.html", "/tags/go/index.html"} {
+		if !slices.Contains(result.Pages, url) {
+			t.Errorf("result.Pages missing %s: %v", url, result.Pages)
+		}
 	}
 
-	// Verify bundle assets were copied.
-	heroPath := filepath.Join(outputDir, "blog", "bundle-post", "hero.jpg")
-	if _, err := os.Stat(heroPath); os.IsNotExist(err) {
-		t.Error("bundle asset hero.jpg should be copied to output")
+	wantFiles := []string{
+		"index.html",
+		"blog/index.html",
+		"blog/page/2.html",
+		"blog/first-post.html",
+		"tags/go/index.html",
+		"old-post.html",
 	}
-	diagramPath := filepath.Join(outputDir, "blog", "bundle-post", "diagram.svg")
-	if _, err := os.Stat(diagramPath); os.IsNotExist(err) {
-		t.Error("bundle asset diagram.svg should be copied to output")
+	for _, f := range wantFiles {
+		if _, err := os.Stat(filepath.Join(outputDir, f)); err != nil {
+			t.Errorf("expected output file %s: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "blog", "first-post", "index.html")); !os.IsNotExist(err) {
+		t.Error("clean URL output blog/first-post/index.html should not exist")
 	}
 
-	// Verify FilesCopied count includes bundle assets.
-	if result.FilesCopied < 2 {
-		t.Errorf("FilesCopied = %d, want >= 2 (at least bundle assets)", result.FilesCopied)
+	alias, err := os.ReadFile(filepath.Join(outputDir, "old-post.html"))
+	if err != nil {
+		t.Fatalf("reading alias: %v", err)
+	}
+	if !strings.Contains(string(alias), "/blog/moved-post.html") {
+		t.Errorf("alias should redirect to /blog/moved-post.html:\n%s", alias)
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(outputDir, "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("reading sitemap.xml: %v", err)
+	}
+	if !strings.Contains(string(sitemap), "https://example.com/blog/first-post.html") {
+		t.Errorf("sitemap should list ugly permalinks:\n%s", sitemap)
 	}
 }
 
-func TestBuild_OutputStructure(t *testing.T) {
+func TestBuild_BasePath(t *testing.T) {
 	root := setupTestSite(t)
 	outputDir := filepath.Join(root, "public")
 
+	listTemplate := `<html><body><a id="home" href="{{ relURL "/" }}">home</a>` +
+		`<link href="{{ asset "/css/style.css" }}">` +
+		`{{ with .Paginator }}{{ range .Pages }}<a class="post" href="{{ .URL }}">{{ .Title }}</a>{{ end }}{{ end }}` +
+		`{{ range .Site.Menu }}<a class="menu" href="{{ .URL }}">{{ .Name }}</a>{{ end }}</body></html>`
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "list.html"),
+		[]byte(listTemplate), 0o644,
+	); err != nil {
+		t.Fatal(err)
+	}
+	figure, err := embedded.DefaultTheme.ReadFile("themes/default/layouts/shortcodes/figure.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "layouts", "shortcodes"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "layouts", "shortcodes", "figure.html"), figure, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	post := `---
+title: "Moved Post"
+date: 2024-01-10
+aliases:
+  - /old-post/
+---
+Moved.
+
+{{< figure src="/images/map.png" link="/blog/" caption="Map" >}}
+{{< figure src="local.png" >}}
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "moved-post.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
 	cfg := config.Default()
-	cfg.Title = "Test Site"
-	cfg.BaseURL = "https://example.com"
-	cfg.Theme = "default"
+	cfg.BaseURL = "https://example.com/docs"
+	cfg.Menu.Main = []config.MenuItem{{Name: "Blog", URL: "/blog/"}}
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   outputDir,
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	// Output paths are unaffected by the base path.
+	list, err := os.ReadFile(filepath.Join(outputDir, "blog", "index.html"))
+	if err != nil {
+		t.Fatalf("reading blog list: %v", err)
+	}
+	for _, want := range []string{
+		`id="home" href="/docs/"`,
+		`href="/docs/css/style.`,
+		`class="post" href="/docs/blog/first-post/"`,
+		`class="menu" href="/docs/blog/"`,
+	} {
+		if !strings.Contains(string(list), want) {
+			t.Errorf("blog list missing %s:\n%s", want, list)
+		}
+	}
+
+	files := map[string]string{
+		"old-post/index.html": `url=/docs/blog/moved-post/`,
+		"blog/moved-post/index.html": `<img src="/docs/images/map.png"`,
+		"search-index.json":   `"/docs/blog/first-post/"`,
+		"manifest.json":       `"/docs/"`,
+		"sitemap.xml":         `https://example.com/docs/blog/first-post/`,
+	}
+	for name, want := range files {
+		data, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Errorf("reading %s: %v", name, err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s missing %s:\n%s", name, want, data)
+		}
+	}
+}
+
+func TestBuild_RelativeURLs(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	singleTemplate := `<html><head><link href="{{ asset "/css/style.css" }}"></head>` +
+		`<body><a id="home" href="{{ relURL "/" }}">home</a>` +
+		`<a id="blog" href="{{ relURL "/blog/" }}">blog</a>` +
+		`<a id="ext" href="https://example.org/">ext</a></body></html>`
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "single.html"),
+		[]byte(singleTemplate), 0o644,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.BaseURL = "https://example.com/docs"
+	cfg.Build.RelativeURLs = true
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   outputDir,
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	post, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatalf("reading post: %v", err)
+	}
+	for _, want := range []string{
+		`href="../../css/style.css"`,
+		`id="home" href="../../index.html"`,
+		`id="blog" href="../../blog/index.html"`,
+		`id="ext" href="https://example.org/"`,
+	} {
+		if !strings.Contains(string(post), want) {
+			t.Errorf("post missing %s:\n%s", want, post)
+		}
+	}
+}
+
+func TestBuild_KeepGoing(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	broken := map[string]string{
+		"bad-frontmatter.md": "---\ntitle: [unclosed\n---\nBody.\n",
+		"bad-template.md":    "---\ntitle: Bad Template\nlayout: broken\n---\nBody.\n",
+	}
+	for name, data := range broken {
+		if err := os.WriteFile(filepath.Join(root, "content", "blog", name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "broken.html"),
+		[]byte(`{{ .Title.Missing }}`), 0o644,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without KeepGoing the build stops at the first error.
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err == nil {
+		t.Fatal("Build() error = nil, want an error")
+	}
+
+	builder = NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir, KeepGoing: true})
+	result, err := builder.Build()
+	var buildErrs *BuildErrors
+	if !errors.As(err, &buildErrs) {
+		t.Fatalf("Build() error = %v, want *BuildErrors", err)
+	}
+	var paths []string
+	for _, pe := range buildErrs.Errors {
+		paths = append(paths, pe.Path)
+	}
+	if want := []string{"blog/bad-frontmatter.md", "blog/bad-template.md"}; !slices.Equal(paths, want) {
+		t.Errorf("error paths = %v, want %v", paths, want)
+	}
+
+	// The rest of the site still builds.
+	if result == nil || result.PagesRendered == 0 {
+		t.Fatalf("result = %+v, want rendered pages", result)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "blog", "first-post", "index.html")); err != nil {
+		t.Errorf("first post not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "blog", "bad-template", "index.html")); !os.IsNotExist(err) {
+		t.Errorf("broken page was written: %v", err)
+	}
+}
+
+func TestBuild_Warnings(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	post := `---
+title: "Warned Post"
+date: 2024-01-12
+cover:
+  image: /images/missing.png
+layout: nonexistent
+project: no-such-project
+tags: ["go", ""]
+---
+Body.
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "warned.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	want := map[string]int{
+		WarnMissingCover:      4,
+		WarnUnknownLayout:     6,
+		WarnUnknownProject:    7,
+		WarnEmptyTaxonomyTerm: 8,
+	}
+	for _, w := range result.Warnings {
+		if w.File != "content/blog/warned.md" {
+			continue
+		}
+		line, ok := want[w.Code]
+		if !ok {
+			t.Errorf("unexpected warning %s", w)
+			continue
+		}
+		if w.Line != line {
+			t.Errorf("%s: line = %d, want %d", w.Code, w.Line, line)
+		}
+		delete(want, w.Code)
+	}
+	for code := range want {
+		t.Errorf("missing %s warning; got %v", code, result.Warnings)
+	}
+}
+
+func TestBuild_CheckLinks(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	post := `---
+title: "Linking Post"
+date: 2024-01-12
+---
+See [the first post](/blog/first-post/) and [a missing page](/blog/missing/).
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "linking.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	cfg.Build.CheckLinks = true
+	builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	var found bool
+	for _, w := range result.Warnings {
+		if w.Code != WarnBrokenLink {
+			continue
+		}
+		if strings.Contains(w.Message, "/blog/first-post/") {
+			t.Errorf("valid link reported broken: %s", w)
+		}
+		if strings.Contains(w.Message, "/blog/missing/") {
+			found = true
+			if w.File != "content/blog/linking.md" || w.Line != 5 {
+				t.Errorf("broken link attributed to %s:%d, want content/blog/linking.md:5", w.File, w.Line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("broken link to /blog/missing/ not reported; warnings: %v", result.Warnings)
+	}
+}
+
+func TestBuild_URLCollision(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	dup := `---
+title: "Duplicate"
+date: 2024-01-12
+slug: first-post
+---
+Same URL as the first post.
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "duplicate.md"), []byte(dup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	_, err := builder.Build()
+	var collErr *URLCollisionError
+	if !errors.As(err, &collErr) {
+		t.Fatalf("Build() error = %v, want *URLCollisionError", err)
+	}
+	if len(collErr.Collisions) != 1 || collErr.Collisions[0].File != "blog/first-post/index.html" {
+		t.Fatalf("collisions = %+v, want one on blog/first-post/index.html", collErr.Collisions)
+	}
+	for _, want := range []string{"content/blog/duplicate.md", "content/blog/first-post.md"} {
+		if !slices.Contains(collErr.Collisions[0].Claimants, want) {
+			t.Errorf("claimants %v missing %s", collErr.Collisions[0].Claimants, want)
+		}
+	}
+}
+
+func TestBuild_FailedBuildKeepsOutput(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("first Build() error: %v", err)
+	}
+	before, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatalf("reading first post: %v", err)
+	}
+
+	// Break the single template so the next build fails while rendering.
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "single.html"),
+		[]byte(`{{ .Title.Missing }}`), 0o644,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Build(); err == nil {
+		t.Fatal("second Build() error = nil, want a template error")
+	}
+
+	after, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatalf("last good output was removed: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Error("last good output was modified by the failed build")
+	}
+	if _, err := os.Stat(stagingDir(outputDir)); !os.IsNotExist(err) {
+		t.Errorf("staging directory left behind: %v", err)
+	}
+}
+
+func TestBuild_Locked(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	lock, err := acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	_, err = builder.Build()
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("Build() error = %v, want *LockError", err)
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("locked build wrote output: %v", err)
+	}
+
+	lock.release()
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() after release error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(LockFile))); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestBuild_Manifest(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+	outputDir := filepath.Join(root, "public")
+
+	m, err := buildmanifest.Read(root)
+	if err != nil {
+		t.Fatalf("buildmanifest.Read() error: %v", err)
+	}
+	if m.OutputDir != "public" {
+		t.Errorf("OutputDir = %q, want public", m.OutputDir)
+	}
+	files := make(map[string]buildmanifest.File, len(m.Files))
+	for _, f := range m.Files {
+		files[f.Path] = f
+		sum, err := hashFile(filepath.Join(outputDir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.SHA256 != sum {
+			t.Errorf("%s: SHA256 = %s, want %s", f.Path, f.SHA256, sum)
+		}
+		if f.Producer.Kind == "" {
+			t.Errorf("%s: no producer recorded", f.Path)
+		}
+	}
+
+	post := files["blog/first-post/index.html"]
+	if post.Producer.Kind != buildmanifest.ProducerPage || post.Producer.Source != "content/blog/first-post.md" || post.Producer.Template == "" {
+		t.Errorf("first post producer = %+v", post.Producer)
+	}
+	if post.ContentType != "text/html; charset=utf-8" {
+		t.Errorf("first post ContentType = %q", post.ContentType)
+	}
+	if got := files["images/logo.png"].Producer; got != (buildmanifest.Producer{Kind: buildmanifest.ProducerStatic, Source: "static/images/logo.png"}) {
+		t.Errorf("logo producer = %+v", got)
+	}
+	if got := files["css/style.css"].Producer; got != (buildmanifest.Producer{Kind: buildmanifest.ProducerStatic, Source: "themes/default/static/css/style.css"}) {
+		t.Errorf("theme stylesheet producer = %+v", got)
+	}
+	if got := files["sitemap.xml"].Producer; got != (buildmanifest.Producer{Kind: buildmanifest.ProducerGenerator, Generator: "sitemap"}) {
+		t.Errorf("sitemap producer = %+v", got)
+	}
+
+	// An incremental build updates the edited page and keeps the rest.
+	postPath := filepath.Join(root, "content", "blog", "second-post.md")
+	if err := os.WriteFile(postPath, []byte("---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\nEdited.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Rebuild([]string{postPath}); err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	m, err = buildmanifest.Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range m.Files {
+		sum, err := hashFile(filepath.Join(outputDir, filepath.FromSlash(f.Path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.SHA256 != sum {
+			t.Errorf("after rebuild, %s: SHA256 = %s, want %s", f.Path, f.SHA256, sum)
+		}
+		if f.Path == "images/logo.png" && f.Producer.Kind != buildmanifest.ProducerStatic {
+			t.Errorf("after rebuild, logo producer = %+v", f.Producer)
+		}
+	}
+}
+
+func TestBuild_BuildDateAndSkipManifest(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(t.TempDir(), "out")
+	if err := os.WriteFile(
+		filepath.Join(root, "themes", "default", "layouts", "_default", "single.html"),
+		[]byte(`built {{ .Site.BuildDate.Format "2006-01-02" }}`), 0o644,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := NewBuilder(config.Default(), BuildOptions{
+		ProjectRoot:  root,
+		OutputDir:    outputDir,
+		BuildDate:    time.Date(2025, 2, 3, 4, 5, 6, 0, time.UTC),
+		SkipManifest: true,
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "built 2025-02-03" {
+		t.Errorf("page = %q, want the pinned build date", data)
+	}
+	if _, err := buildmanifest.Read(root); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("buildmanifest.Read() error = %v, want no manifest", err)
+	}
+}
+
+func TestBuild_Reproducible(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/blog/third-post.md": "---\ntitle: \"Third Post\"\ndate: 2024-03-10\naliases: [/old/c/, /old/a/, /old/b/]\ntags: [go, web]\n---\nThird.\n",
+		"content/blog/future-post.md": "---\ntitle: \"Future Post\"\ndate: 2030-01-01\n---\nNot yet.\n",
+		"data/team.yaml":              "members:\n  zed: 1\n  amy: 2\n  bob: 3\n",
+		"themes/default/layouts/_default/single.html": `<p>{{ .Title }} built {{ .Site.BuildDate }} at {{ now.Unix }}</p>
+<ul>{{ range shuffle .Site.Pages }}<li>{{ .Title }}</li>{{ end }}</ul>
+<ul>{{ range $k, $v := .Site.Data.team.members }}<li>{{ $k }}={{ $v }}</li>{{ end }}</ul>`,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1735689600") // 2025-01-01T00:00:00Z
+
+	build := func() map[string][]byte {
+		t.Helper()
+		outputDir := filepath.Join(t.TempDir(), "public")
+		cfg := config.Default()
+		cfg.BaseURL = "https://example.com"
+		builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+		if _, err := builder.Build(); err != nil {
+			t.Fatalf("Build() error: %v", err)
+		}
+		out := make(map[string][]byte)
+		err := filepath.WalkDir(outputDir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(outputDir, p)
+			data, err := os.ReadFile(p)
+			out[filepath.ToSlash(rel)] = data
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	first := build()
+	page := string(first["blog/third-post/index.html"])
+	if !strings.Contains(page, "built 2025-01-01 00:00:00 &#43;0000 UTC at 1735689600") {
+		t.Errorf("page = %q, want the SOURCE_DATE_EPOCH build date", page)
+	}
+	if _, ok := first["blog/future-post/index.html"]; ok {
+		t.Error("post dated after SOURCE_DATE_EPOCH should not be built")
+	}
+	for _, alias := range []string{"old/a/index.html", "old/b/index.html", "old/c/index.html"} {
+		if _, ok := first[alias]; !ok {
+			t.Errorf("missing alias %s", alias)
+		}
+	}
+
+	second := build()
+	for name, data := range first {
+		if other, ok := second[name]; !ok {
+			t.Errorf("%s missing from second build", name)
+		} else if !bytes.Equal(data, other) {
+			t.Errorf("%s differs between builds:\n%s\n---\n%s", name, data, other)
+		}
+	}
+	for name := range second {
+		if _, ok := first[name]; !ok {
+			t.Errorf("%s missing from first build", name)
+		}
+	}
+}
+
+func TestBuild_InvalidSourceDateEpoch(t *testing.T) {
+	root := setupTestSite(t)
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+
+	builder := NewBuilder(config.Default(), BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   filepath.Join(root, "public"),
+	})
+	_, err := builder.Build()
+	if err == nil || !strings.Contains(err.Error(), "SOURCE_DATE_EPOCH") {
+		t.Fatalf("Build() error = %v, want an invalid SOURCE_DATE_EPOCH error", err)
+	}
+
+	// An explicit build date takes precedence.
+	builder = NewBuilder(config.Default(), BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   filepath.Join(root, "public"),
+		BuildDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() with BuildDate error: %v", err)
+	}
+}
+
+func TestBuild_AsOf(t *testing.T) {
+	root := setupTestSite(t)
+	posts := map[string]string{
+		"scheduled.md": "---\ntitle: \"Scheduled\"\ndate: 2030-06-01\n---\nSoon.\n",
+		"expiring.md":  "---\ntitle: \"Expiring\"\ndate: 2024-01-01\nexpiryDate: 2030-03-01\n---\nGoing.\n",
+		"backdated.md": "---\ntitle: \"Backdated\"\ndate: 2024-01-01\npublishDate: 2030-06-01\n---\nWritten long ago.\n",
+	}
+	for name, data := range posts {
+		if err := os.WriteFile(filepath.Join(root, "content", "blog", name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		asOf                           time.Time
+		scheduled, expiring, backdated bool
+	}{
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), false, true, false},
+		{time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), true, false, true},
+	}
+	for _, tt := range tests {
+		outputDir := filepath.Join(t.TempDir(), "public")
+		builder := NewBuilder(config.Default(), BuildOptions{
+			ProjectRoot: root,
+			OutputDir:   outputDir,
+			BuildDate:   tt.asOf,
+		})
+		if _, err := builder.Build(); err != nil {
+			t.Fatalf("Build() as of %s error: %v", tt.asOf.Format(time.DateOnly), err)
+		}
+		for page, want := range map[string]bool{"scheduled": tt.scheduled, "expiring": tt.expiring, "backdated": tt.backdated} {
+			_, err := os.Stat(filepath.Join(outputDir, "blog", page, "index.html"))
+			if got := err == nil; got != want {
+				t.Errorf("as of %s: %s built = %v, want %v", tt.asOf.Format(time.DateOnly), page, got, want)
+			}
+		}
+	}
+}
+
+func TestSitemapLastmod(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name string
+		page content.Page
+		want time.Time
+	}{
+		{"lastmod", content.Page{Date: day(1), PublishDate: day(5), Lastmod: day(3)}, day(3)},
+		{"backdated", content.Page{Date: day(1), PublishDate: day(5)}, day(5)},
+		{"date", content.Page{Date: day(1), PublishDate: day(1)}, day(1)},
+		{"no dates", content.Page{}, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := sitemapLastmod(&tt.page); !got.Equal(tt.want) {
+			t.Errorf("%s: sitemapLastmod() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuild_PageBuildOptions(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/_index.md":              "---\ntitle: \"Home\"\npaginate: 10\n---\n",
+		"content/snippets/hero.md":       "---\ntitle: \"Hero Omega\"\nbuild:\n  render: never\n  list: never\n---\nBig **hero**.\n",
+		"content/blog/data.md":           "---\ntitle: \"Data Sigma\"\ndate: 2024-05-04\ntags: [go]\nbuild:\n  render: never\n---\nData only.\n",
+		"content/blog/landing.md":        "---\ntitle: \"Landing Zeta\"\ndate: 2024-05-01\ntags: [go]\naliases: [/promo/]\nbuild:\n  list: never\n---\nBuy now.\n",
+		"content/blog/local.md":          "---\ntitle: \"Local Kappa\"\ndate: 2024-05-02\ntags: [go]\nbuild:\n  list: local\n---\nSection only.\n",
+		"content/blog/bundled/index.md":  "---\ntitle: \"Bundled\"\ndate: 2024-05-03\nbuild:\n  publish: false\n---\nPrivate files.\n",
+		"content/blog/bundled/notes.txt": "internal",
+		"themes/default/layouts/index.html": `{{ with .Site.GetPage "snippets/hero" }}<div class="hero">{{ .Content }}</div>{{ end }}
+{{ range .Paginator.Pages }}<li>{{ .Title }}</li>{{ end }}
+{{ range .Site.Pages }}<p>{{ .Title }}</p>{{ end }}`,
+		"themes/default/layouts/_default/list.html": `{{ with .Paginator }}{{ range .Pages }}<li>{{ .Title }}</li>{{ end }}{{ end }}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	cfg := config.Default()
+	cfg.BaseURL = "https://example.com"
+	builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name)))
+		return err == nil
+	}
+
+	// render: never writes no page, but templates can still use it.
+	if exists("snippets/hero/index.html") {
+		t.Error("headless page should not be rendered")
+	}
+	home := read("index.html")
+	if !strings.Contains(home, `<div class="hero"><p>Big <strong>hero</strong>.</p>`) {
+		t.Errorf("home should embed the headless page via GetPage:\n%s", home)
+	}
+
+	// A page that is not rendered is listed nowhere, even with the default
+	// build.list, so no output links to it.
+	if exists("blog/data/index.html") {
+		t.Error("render: never page should not be rendered")
+	}
+	err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(data), "/blog/data/") || strings.Contains(string(data), "Data Sigma") {
+			t.Errorf("%s links to the unrendered page", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// list: never renders the page and its aliases but lists it nowhere.
+	if !exists("blog/landing/index.html") || !exists("promo/index.html") {
+		t.Error("unlisted page and its alias should be rendered")
+	}
+	// list: local lists the page only in its section.
+	blog := read("blog/index.html")
+	if !strings.Contains(blog, "Local Kappa") || strings.Contains(blog, "Landing Zeta") {
+		t.Errorf("blog list should show the local page only:\n%s", blog)
+	}
+	for _, name := range []string{"index.html", "sitemap.xml", "index.xml", "atom.xml", "search-index.json", "tags/go/index.html"} {
+		out := read(name)
+		for _, hidden := range []string{"Landing Zeta", "blog/landing/", "Local Kappa", "blog/local/", "Hero Omega"} {
+			if strings.Contains(out, hidden) {
+				t.Errorf("%s lists %q", name, hidden)
+			}
+		}
+	}
+
+	// publish: false keeps bundle resources out of the output.
+	if !exists("blog/bundled/index.html") {
+		t.Error("bundle page should be rendered")
+	}
+	if exists("blog/bundled/notes.txt") {
+		t.Error("bundle resources should not be published")
+	}
+}
+
+func TestBuild_NestedSections(t *testing.T) {
+	root := setupTestSite(t)
+	crumbs := `{{ range .Ancestors }}<a href="{{ .URL }}">{{ .Title }}</a> / {{ end }}`
+	files := map[string]string{
+		"content/docs/_index.md":                "---\ntitle: \"Docs\"\n---\n",
+		"content/docs/intro.md":                 "---\ntitle: \"Intro\"\n---\n",
+		"content/docs/guides/_index.md":         "---\ntitle: \"Guides\"\nweight: 2\n---\n",
+		"content/docs/api/_index.md":            "---\ntitle: \"API\"\nweight: 1\n---\n",
+		"content/docs/guides/install/_index.md": "---\ntitle: \"Install\"\n---\n",
+		"content/docs/guides/install/linux.md":  "---\ntitle: \"Linux\"\n---\n",
+		"themes/default/layouts/_default/list.html": crumbs + `<h1>{{ .Title }}</h1>
+{{ range .Sections }}<section>{{ .Title }}</section>{{ end }}
+{{ with .Paginator }}{{ range .Pages }}<li>{{ .Title }}</li>{{ end }}{{ end }}`,
+		"themes/default/layouts/docs/single.html": crumbs + `<h1 class="doc">{{ .Title }}</h1>{{ with .Parent }}<p>in {{ .Title }}</p>{{ end }}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	cfg := config.Default()
+	builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Each nested section gets its own list page listing its own pages and
+	// child sections.
+	docs := read("docs/index.html")
+	if !strings.Contains(docs, "<h1>Docs</h1>") || !strings.Contains(docs, "<li>Intro</li>") ||
+		!strings.Contains(docs, "<section>API</section><section>Guides</section>") {
+		t.Errorf("docs list should show Intro and its sections by weight:\n%s", docs)
+	}
+	if strings.Contains(docs, "Linux") {
+		t.Errorf("docs list should not show pages of nested sections:\n%s", docs)
+	}
+	guides := read("docs/guides/index.html")
+	if !strings.Contains(guides, `<a href="/">Home</a> / <a href="/docs/">Docs</a> / <h1>Guides</h1>`) ||
+		!strings.Contains(guides, "<section>Install</section>") {
+		t.Errorf("guides list should have breadcrumbs and the install section:\n%s", guides)
+	}
+	install := read("docs/guides/install/index.html")
+	if !strings.Contains(install, "<h1>Install</h1>") || !strings.Contains(install, "<li>Linux</li>") {
+		t.Errorf("install list should show Linux:\n%s", install)
+	}
+
+	// Single pages in nested sections fall back to the docs layout.
+	linux := read("docs/guides/install/linux/index.html")
+	want := `<a href="/">Home</a> / <a href="/docs/">Docs</a> / <a href="/docs/guides/">Guides</a> / ` +
+		`<a href="/docs/guides/install/">Install</a> / <h1 class="doc">Linux</h1><p>in Install</p>`
+	if !strings.Contains(linux, want) {
+		t.Errorf("linux page = %s, want %s", linux, want)
+	}
+}
+
+func TestBuild_Permalinks(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/about.md":   "---\ntitle: \"About\"\nurl: /about-us/\n---\n",
+		"content/blog/ml.md": "---\ntitle: \"ML\"\ndate: 2024-03-01\ntags: [\"Machine Learning\"]\n---\n",
+		"themes/default/layouts/_default/single.html": `<h1>{{ .Title }}</h1>{{ range .Tags }}<a href="{{ termURL "tags" . }}">{{ . }}</a>{{ end }}`,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	cfg := config.Default()
+	cfg.Pagination.PageSize = 1
+	cfg.Permalinks = map[string]string{
+		"blog": "/:year/:month/:slug/",
+		"tags": "/topics/:slug/",
+	}
+	cfg.Build.CheckLinks = true
+	builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	for _, w := range result.Warnings {
+		if w.Code == WarnBrokenLink {
+			t.Errorf("unexpected broken link: %s", w)
+		}
+	}
+
+	for _, name := range []string{
+		"2024/01/first-post/index.html",
+		"2024/02/second-post/index.html",
+		"about-us/index.html",
+		"topics/go/index.html",
+		"topics/go/page/2/index.html",
+		"topics/machine-learning/index.html",
+		"blog/index.html",
+	} {
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"blog/first-post/index.html", "tags/go/index.html", "about/index.html"} {
+		if _, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(name))); err == nil {
+			t.Errorf("%s should not be written", name)
+		}
+	}
+
+	first, err := os.ReadFile(filepath.Join(outputDir, "2024", "01", "first-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(first), `<a href="/topics/go/">go</a>`) {
+		t.Errorf("termURL should follow the tags pattern:\n%s", first)
+	}
+	ml, err := os.ReadFile(filepath.Join(outputDir, "2024", "03", "ml", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(ml), `<a href="/topics/machine-learning/">Machine Learning</a>`) {
+		t.Errorf("termURL should link the slugified term:\n%s", ml)
+	}
+}
+
+func TestBuild_TaxonomyTermsWithSameSlug(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/blog/c.md":   "---\ntitle: \"C\"\ndate: 2024-03-01\ntags: [\"c\"]\n---\n",
+		"content/blog/cpp.md": "---\ntitle: \"CPP\"\ndate: 2024-03-02\ntags: [\"c++\"]\n---\n",
+		"themes/default/layouts/_default/taxonomy.html": `{{ range .Paginator.Pages }}<li>{{ .Title }}</li>{{ end }}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	term, err := os.ReadFile(filepath.Join(outputDir, "tags", "c", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"<li>C</li>", "<li>CPP</li>"} {
+		if !strings.Contains(string(term), title) {
+			t.Errorf("tags/c/ should list %s:\n%s", title, term)
+		}
+	}
+	alias, err := os.ReadFile(filepath.Join(outputDir, "tags", "c++", "index.html"))
+	if err != nil {
+		t.Fatalf("expected a redirect at the unslugged term path: %v", err)
+	}
+	if !strings.Contains(string(alias), "/tags/c/") {
+		t.Errorf("tags/c++/ should redirect to /tags/c/:\n%s", alias)
+	}
+
+	var merged []Warning
+	for _, w := range result.Warnings {
+		if w.Code == WarnMergedTaxonomyTerm {
+			merged = append(merged, w)
+		}
+	}
+	if len(merged) != 1 || !strings.Contains(merged[0].Message, `"c++"`) {
+		t.Errorf("expected one %s warning about c++, got %v", WarnMergedTaxonomyTerm, merged)
+	}
+}
+
+func TestBuild_Shortcodes(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"layouts/shortcodes/note.html": `<aside class="{{ .Get 0 }}" data-page="{{ .Page.Title }}">{{ .Inner }}</aside>`,
+		"content/blog/second-post.md": "---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\nIntro.\n\n{{< note tip >}}Read **this**.{{< /note >}}\n\nSay {{% note info %}}**that**{{% /note %}}.\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "blog", "second-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<aside class="tip" data-page="Second Post">Read **this**.</aside>`,
+		`<p>Say <aside class="info" data-page="Second Post"><strong>that</strong></aside>.</p>`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("output should contain %s:\n%s", want, data)
+		}
+	}
+
+	// Errors point at the file and line of the call.
+	broken := "---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\nIntro.\n\n{{< missing >}}\n"
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "second-post.md"), []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = builder.Build()
+	if err == nil || !strings.Contains(err.Error(), `second-post.md:7: shortcode "missing"`) {
+		t.Errorf("Build() error = %v, want the file and line of the call", err)
+	}
+}
+
+func TestBuild_RenderHooks(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"layouts/_markup/render-link.html":      `<a href="{{ .Destination }}" rel="noopener">{{ .Text }}</a>`,
+		"layouts/_markup/render-heading.html":   `<h{{ .Level }} id="{{ .Anchor }}"><a href="#{{ .Anchor }}">#</a> {{ .Text }}</h{{ .Level }}>`,
+		"layouts/_markup/render-codeblock.html": `<figure data-lang="{{ .Type }}" data-title="{{ index .Attributes "title" }}">{{ highlight .Inner .Type }}</figure>`,
+		"layouts/_markup/render-image.html":     `<img src="{{ .Destination }}" alt="{{ .PlainText }}" data-page="{{ .Page.Title }}">`,
+		"content/blog/second-post.md":           "---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\n## Setup\n\nSee [the **docs**](https://example.com).\n\n![A diagram](/diagram.png)\n\n```go {title=\"main.go\"}\nx := 1\n```\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	builder := NewBuilder(config.Default(), BuildOptions{ProjectRoot: root, OutputDir: outputDir})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "blog", "second-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<h2 id="setup"><a href="#setup">#</a> Setup</h2>`,
+		`<a href="https://example.com" rel="noopener">the <strong>docs</strong></a>`,
+		`<img src="/diagram.png" alt="A diagram" data-page="Second Post">`,
+		`<figure data-lang="go" data-title="main.go"><pre class="chroma">`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("output should contain %s:\n%s", want, data)
+		}
+	}
+
+	// Pages without hooked elements render as usual.
+	data, err = os.ReadFile(filepath.Join(outputDir, "blog", "first-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "This is my <strong>first</strong> post.") {
+		t.Errorf("first post should render as usual:\n%s", data)
+	}
+}
+
+func TestBuild_IncludeDrafts(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot:   root,
+		OutputDir:     outputDir,
+		IncludeDrafts: true,
+	})
+
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() with drafts error: %v", err)
+	}
+
+	// Draft should now be present.
+	draftPath := filepath.Join(outputDir, "blog", "draft-post", "index.html")
+	if _, err := os.Stat(draftPath); os.IsNotExist(err) {
+		t.Error("draft post should be in output when IncludeDrafts is true")
+	}
+
+	// Should have more pages rendered than without drafts.
+	if result.PagesRendered < 4 {
+		t.Errorf("PagesRendered = %d with drafts, want >= 4", result.PagesRendered)
+	}
+}
+
+func TestBuild_FilterFuture(t *testing.T) {
+	root := setupTestSite(t)
+
+	// Create a future-dated post.
+	futureDate := time.Now().Add(24 * time.Hour * 365).Format("2006-01-02")
+	futurePost := `---
+title: "Future Post"
+date: ` + futureDate + `
+---
+This is from the future.
+`
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "future-post.md"), []byte(futurePost), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(root, "public")
+
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
+
+	// Without IncludeFuture (default) - future post should be excluded.
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   outputDir,
+	})
+	_, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	futurePath := filepath.Join(outputDir, "blog", "future-post", "index.html")
+	if _, err := os.Stat(futurePath); !os.IsNotExist(err) {
+		t.Error("future post should not be in output when IncludeFuture is false")
+	}
+
+	// With IncludeFuture - future post should be included.
+	builder2 := NewBuilder(cfg, BuildOptions{
+		ProjectRoot:   root,
+		OutputDir:     outputDir,
+		IncludeFuture: true,
+	})
+	_, err = builder2.Build()
+	if err != nil {
+		t.Fatalf("Build() with future error: %v", err)
+	}
+
+	if _, err := os.Stat(futurePath); os.IsNotExist(err) {
+		t.Error("future post should be in output when IncludeFuture is true")
+	}
+}
+
+func TestBuild_Pagination(t *testing.T) {
+	root := setupTestSite(t)
+
+	// Three more posts bring the blog to five published posts.
+	for i := 3; i <= 5; i++ {
+		post := fmt.Sprintf("---\ntitle: \"Post %d\"\ndate: 2024-03-%02d\n---\nBody.\n", i, i)
+		name := fmt.Sprintf("post-%d.md", i)
+		if err := os.WriteFile(filepath.Join(root, "content", "blog", name), []byte(post), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	listTemplate := `<h1>{{ .Title }}</h1>
+{{ with .Paginator }}<p>page {{ .PageNumber }}/{{ .TotalPages }}</p>{{ range .Pages }}<li>{{ .Title }}</li>{{ end }}{{ if .HasNext }}<a href="{{ .NextURL }}">next</a>{{ end }}{{ end }}`
+	for _, name := range []string{"_default/list.html", "index.html"} {
+		if err := os.WriteFile(
+			filepath.Join(root, "themes", "default", "layouts", filepath.FromSlash(name)),
+			[]byte(listTemplate), 0o644,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputDir := filepath.Join(root, "public")
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
+	cfg.Pagination.PageSize = 2
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   outputDir,
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	first, err := os.ReadFile(filepath.Join(outputDir, "blog", "index.html"))
+	if err != nil {
+		t.Fatalf("reading blog list: %v", err)
+	}
+	if !strings.Contains(string(first), "page 1/3") {
+		t.Errorf("blog list should be page 1 of 3, got:\n%s", first)
+	}
+	if !strings.Contains(string(first), `href="/blog/page/2/"`) {
+		t.Error("blog list should link to /blog/page/2/")
+	}
+	// Newest first: the latest two posts are on page 1.
+	if !strings.Contains(string(first), "<li>Post 5</li><li>Post 4</li>") {
+		t.Errorf("page 1 should list the two newest posts, got:\n%s", first)
+	}
+
+	last, err := os.ReadFile(filepath.Join(outputDir, "blog", "page", "3", "index.html"))
+	if err != nil {
+		t.Fatalf("reading blog page 3: %v", err)
+	}
+	if !strings.Contains(string(last), "page 3/3") || !strings.Contains(string(last), "<li>First Post</li>") {
+		t.Errorf("page 3 should hold the oldest post, got:\n%s", last)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "blog", "page", "4", "index.html")); !os.IsNotExist(err) {
+		t.Error("blog/page/4 should not exist")
+	}
+
+	// Taxonomy term pages are paginated too: two posts are tagged "go".
+	if _, err := os.Stat(filepath.Join(outputDir, "tags", "go", "index.html")); err != nil {
+		t.Errorf("expected tags/go/index.html: %v", err)
+	}
+
+	// The home page is paginated only when its _index.md sets paginate.
+	home, err := os.ReadFile(filepath.Join(outputDir, "index.html"))
+	if err != nil {
+		t.Fatalf("reading home page: %v", err)
+	}
+	if strings.Contains(string(home), "page 1/") {
+		t.Errorf("home page should have no Paginator by default, got:\n%s", home)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "page", "2", "index.html")); !os.IsNotExist(err) {
+		t.Error("page/2 should not exist without paginate on the home page")
+	}
+
+	// A per-section paginate override wins over the site default.
+	blogSection := "---\ntitle: \"Blog\"\npaginate: 5\n---\n"
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "_index.md"), []byte(blogSection), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	homeSection := "---\ntitle: \"Home\"\npaginate: 2\n---\n"
+	if err := os.WriteFile(filepath.Join(root, "content", "_index.md"), []byte(homeSection), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: outputDir}).Build(); err != nil {
+		t.Fatalf("Build() with override error: %v", err)
+	}
+	first, err = os.ReadFile(filepath.Join(outputDir, "blog", "index.html"))
+	if err != nil {
+		t.Fatalf("reading blog list: %v", err)
+	}
+	if !strings.Contains(string(first), "page 1/1") {
+		t.Errorf("paginate: 5 should fit all posts on one page, got:\n%s", first)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "blog", "page", "2", "index.html")); !os.IsNotExist(err) {
+		t.Error("blog/page/2 should not exist with paginate: 5")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "page", "3", "index.html")); err != nil {
+		t.Errorf("home page with paginate: 2 should have page/3: %v", err)
+	}
+}
+
+func TestBuild_PaginationWarnsOnce(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/blog/_index.md":    "---\ntitle: \"Blog\"\ncover:\n  image: /images/missing.png\n---\n",
+		"content/blog/third-post.md": "---\ntitle: \"Third Post\"\ndate: 2024-03-10\n---\nThird.\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Pagination.PageSize = 1
+	builder := NewBuilder(cfg, BuildOptions{ProjectRoot: root, OutputDir: filepath.Join(root, "public")})
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "public", "blog", "page", "3", "index.html")); err != nil {
+		t.Fatalf("expected three pages of the blog list: %v", err)
+	}
+
+	// The pages 2..N of the list are copies of _index.md, not sources of
+	// their own.
+	var got []Warning
+	for _, w := range result.Warnings {
+		if w.File == "content/blog/_index.md" {
+			got = append(got, w)
+		}
+	}
+	if len(got) != 1 || got[0].Code != WarnMissingCover {
+		t.Errorf("warnings for content/blog/_index.md = %v, want one %s", got, WarnMissingCover)
+	}
+}
+
+func TestBuild_PageBundleAssets(t *testing.T) {
+	root := setupTestSite(t)
+
+	// Create a page bundle.
+	bundleDir := filepath.Join(root, "content", "blog", "bundle-post")
+	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	bundleContent := `---
+title: "Bundle Post"
+date: 2024-04-01
+---
+A post with assets.
+`
+	if err := os.WriteFile(filepath.Join(bundleDir, "index.md"), []byte(bundleContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "hero.jpg"), []byte("fake-jpg-data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "diagram.svg"), []byte("<svg></svg>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := filepath.Join(root, "public")
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   outputDir,
+	})
+
+	result, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	// Verify the bundle page was rendered.
+	postPath := filepath.Join(outputDir, "blog", "bundle-post", "index.html")
+	if _, err := os.Stat(postPath); os.IsNotExist(err) {
+		t.Error("bundle post page should exist")
+	}
+
+	// Verify bundle assets were copied.
+	heroPath := filepath.Join(outputDir, "blog", "bundle-post", "hero.jpg")
+	if _, err := os.Stat(heroPath); os.IsNotExist(err) {
+		t.Error("bundle asset hero.jpg should be copied to output")
+	}
+	diagramPath := filepath.Join(outputDir, "blog", "bundle-post", "diagram.svg")
+	if _, err := os.Stat(diagramPath); os.IsNotExist(err) {
+		t.Error("bundle asset diagram.svg should be copied to output")
+	}
+
+	// Verify FilesCopied count includes bundle assets.
+	if result.FilesCopied < 2 {
+		t.Errorf("FilesCopied = %d, want >= 2 (at least bundle assets)", result.FilesCopied)
+	}
+}
+
+func TestBuild_OutputStructure(t *testing.T) {
+	root := setupTestSite(t)
+	outputDir := filepath.Join(root, "public")
+
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
 
 	builder := NewBuilder(cfg, BuildOptions{
 		ProjectRoot: root,
@@ -817,7 +2386,7 @@ func TestPageToContext(t *testing.T) {
 		},
 	}
 
-	ctx := pageToContext(page, nil, nil)
+	ctx := pageToContext(page, nil, nil, "")
 
 	if ctx.Title != "Test Page" {
 		t.Errorf("Title = %q, want %q", ctx.Title, "Test Page")
@@ -897,4 +2466,387 @@ func TestBuild_NewSite(t *testing.T) {
 	if _, err := builder.Build(); err != nil {
 		t.Fatalf("Build on fresh site failed: %v", err)
 	}
+
+	// New sites fingerprint their stylesheets.
+	if _, err := os.Stat(filepath.Join(siteName, "public", AssetManifestFile)); err != nil {
+		t.Errorf("fresh site should write %s: %v", AssetManifestFile, err)
+	}
+}
+
+// --- Incremental rebuild tests ---
+
+// newRebuildTestBuilder returns a Builder for a setupTestSite project that
+// has completed one full build.
+func newRebuildTestBuilder(t *testing.T, root string) *Builder {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Title = "Test Site"
+	cfg.BaseURL = "https://example.com"
+	cfg.Theme = "default"
+
+	builder := NewBuilder(cfg, BuildOptions{
+		ProjectRoot: root,
+		OutputDir:   filepath.Join(root, "public"),
+	})
+	if _, err := builder.Build(); err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	return builder
+}
+
+func TestRebuild_ContentChange(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+	outputDir := filepath.Join(root, "public")
+
+	// Mark an unrelated output so we can tell whether it was rewritten.
+	firstPath := filepath.Join(outputDir, "blog", "first-post", "index.html")
+	if err := os.WriteFile(firstPath, []byte("untouched"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	postPath := filepath.Join(root, "content", "blog", "second-post.md")
+	post := `---
+title: "Second Post"
+date: 2024-02-20
+tags:
+  - go
+---
+This is my **edited** post.
+`
+	if err := os.WriteFile(postPath, []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := builder.Rebuild([]string{postPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if !result.Incremental {
+		t.Error("content change should rebuild incrementally")
+	}
+	if !slices.Contains(result.Changed, "/blog/second-post/") {
+		t.Errorf("Changed = %v, want it to include /blog/second-post/", result.Changed)
+	}
+	if slices.Contains(result.Changed, "/blog/first-post/") {
+		t.Errorf("Changed = %v, should not include the unedited post", result.Changed)
+	}
+	if result.PagesRendered >= len(result.Pages) {
+		t.Errorf("PagesRendered = %d of %d pages, want only affected pages", result.PagesRendered, len(result.Pages))
+	}
+
+	data, err := os.ReadFile(filepath.Join(outputDir, "blog", "second-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<strong>edited</strong>") {
+		t.Error("edited post should be re-rendered")
+	}
+	data, err = os.ReadFile(firstPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "untouched" {
+		t.Error("unaffected post should not be rewritten")
+	}
+}
+
+func TestRebuild_CascadeFromDraftSection(t *testing.T) {
+	root := setupTestSite(t)
+	files := map[string]string{
+		"content/guides/_index.md": "---\ntitle: \"Guides\"\ndraft: true\ncascade:\n  params:\n    banner: old\n---\n",
+		"content/guides/intro.md":  "---\ntitle: \"Intro\"\n---\nHello.\n",
+		"layouts/guides/single.html": `<p class="banner">{{ .Params.banner }}</p>`,
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	builder := newRebuildTestBuilder(t, root)
+
+	// The draft section is not rendered, but the page still inherits its
+	// cascade and is rebuilt when it changes.
+	indexPath := filepath.Join(root, "content", "guides", "_index.md")
+	if err := os.WriteFile(indexPath, []byte("---\ntitle: \"Guides\"\ndraft: true\ncascade:\n  params:\n    banner: new\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Rebuild([]string{indexPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if !slices.Contains(result.Changed, "/guides/intro/") {
+		t.Errorf("Changed = %v, want it to include /guides/intro/", result.Changed)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "public", "guides", "intro", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<p class="banner">new</p>`) {
+		t.Errorf("page should use the edited cascade:\n%s", data)
+	}
+}
+
+func TestRebuild_AddAndRemovePost(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+	outputDir := filepath.Join(root, "public")
+
+	newPath := filepath.Join(root, "content", "blog", "third-post.md")
+	post := "---\ntitle: \"Third Post\"\ndate: 2024-04-01\ntags:\n  - rust\n---\nNew.\n"
+	if err := os.WriteFile(newPath, []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := builder.Rebuild([]string{newPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	for _, url := range []string{"/blog/third-post/", "/tags/rust/"} {
+		if !slices.Contains(result.Changed, url) {
+			t.Errorf("Changed = %v, want it to include %s", result.Changed, url)
+		}
+		if _, err := os.Stat(outputPath(outputDir, url)); err != nil {
+			t.Errorf("expected output for %s: %v", url, err)
+		}
+	}
+
+	if err := os.Remove(newPath); err != nil {
+		t.Fatal(err)
+	}
+	result, err = builder.Rebuild([]string{newPath})
+	if err != nil {
+		t.Fatalf("Rebuild() after delete error: %v", err)
+	}
+	for _, url := range []string{"/blog/third-post/", "/tags/rust/"} {
+		if !slices.Contains(result.Changed, url) {
+			t.Errorf("Changed = %v, want it to include removed %s", result.Changed, url)
+		}
+		if _, err := os.Stat(outputPath(outputDir, url)); !os.IsNotExist(err) {
+			t.Errorf("output for removed %s should be deleted", url)
+		}
+	}
+}
+
+func TestRebuild_AliasChange(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+	outputDir := filepath.Join(root, "public")
+	postPath := filepath.Join(root, "content", "blog", "second-post.md")
+
+	rebuildWithAliases := func(aliases string) {
+		t.Helper()
+		post := "---\ntitle: \"Second Post\"\ndate: 2024-02-20\naliases: [" + aliases + "]\n---\nBody.\n"
+		if err := os.WriteFile(postPath, []byte(post), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := builder.Rebuild([]string{postPath})
+		if err != nil {
+			t.Fatalf("Rebuild() error: %v", err)
+		}
+		if !result.Incremental {
+			t.Error("alias change should rebuild incrementally")
+		}
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(rel)))
+		return err == nil
+	}
+
+	rebuildWithAliases("/old/second/")
+	if !exists("old/second/index.html") {
+		t.Fatal("alias redirect should be written")
+	}
+
+	rebuildWithAliases("/older/")
+	if exists("old/second/index.html") || exists("old") {
+		t.Error("redirect for a dropped alias should be deleted, with its directory")
+	}
+	if !exists("older/index.html") {
+		t.Error("redirect for the new alias should be written")
+	}
+
+	// Removing the page removes its redirects too.
+	if err := os.Remove(postPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Rebuild([]string{postPath}); err != nil {
+		t.Fatalf("Rebuild() after delete error: %v", err)
+	}
+	if exists("older/index.html") {
+		t.Error("redirect for a removed page should be deleted")
+	}
+	if exists("blog/second-post/index.html") {
+		t.Error("output for a removed page should be deleted")
+	}
+}
+
+func TestRebuild_BundleIndexRemoved(t *testing.T) {
+	root := setupTestSite(t)
+	bundleDir := filepath.Join(root, "content", "blog", "trip")
+	if err := os.MkdirAll(bundleDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(bundleDir, "index.md")
+	index := []byte("---\ntitle: \"Trip\"\ndate: 2024-03-01\n---\nPhotos.\n")
+	if err := os.WriteFile(indexPath, index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "notes.txt"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	builder := newRebuildTestBuilder(t, root)
+	assetPath := filepath.Join(root, "public", "blog", "trip", "notes.txt")
+	if _, err := os.Stat(assetPath); err != nil {
+		t.Fatalf("bundle resource should be copied: %v", err)
+	}
+
+	if err := os.Remove(indexPath); err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Rebuild([]string{indexPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if result.Incremental {
+		t.Error("removing a bundle's index should fall back to a full build")
+	}
+	if _, err := os.Stat(assetPath); !os.IsNotExist(err) {
+		t.Errorf("resource of a removed bundle should be deleted: %v", err)
+	}
+
+	// Restoring it copies the resources again.
+	if err := os.WriteFile(indexPath, index, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := builder.Rebuild([]string{indexPath}); err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if _, err := os.Stat(assetPath); err != nil {
+		t.Errorf("resource of a restored bundle should be copied: %v", err)
+	}
+}
+
+func TestRebuild_TemplateChange(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+
+	singlePath := filepath.Join(root, "themes", "default", "layouts", "_default", "single.html")
+	if err := os.WriteFile(singlePath, []byte(`<article>{{ .Content }}</article>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := builder.Rebuild([]string{singlePath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if !result.Incremental {
+		t.Error("template change should rebuild incrementally")
+	}
+	want := []string{"/blog/first-post/", "/blog/second-post/"}
+	if !slices.Equal(result.Changed, want) {
+		t.Errorf("Changed = %v, want %v", result.Changed, want)
+	}
+}
+
+func TestRebuild_ShortcodeChange(t *testing.T) {
+	root := setupTestSite(t)
+	scPath := filepath.Join(root, "layouts", "shortcodes", "note.html")
+	if err := os.MkdirAll(filepath.Dir(scPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(scPath, []byte(`<aside>{{ .Inner }}</aside>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	post := "---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\n{{< note >}}hi{{< /note >}}\n"
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "second-post.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	builder := newRebuildTestBuilder(t, root)
+
+	if err := os.WriteFile(scPath, []byte(`<div class="note">{{ .Inner }}</div>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Rebuild([]string{scPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if !result.Incremental {
+		t.Error("shortcode change should rebuild incrementally")
+	}
+	if want := []string{"/blog/second-post/"}; !slices.Equal(result.Changed, want) {
+		t.Errorf("Changed = %v, want %v", result.Changed, want)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "public", "blog", "second-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<div class="note">hi</div>`) {
+		t.Errorf("post should use the edited shortcode:\n%s", data)
+	}
+}
+
+func TestRebuild_RenderHookChange(t *testing.T) {
+	root := setupTestSite(t)
+	hookPath := filepath.Join(root, "layouts", "_markup", "render-link.html")
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hookPath, []byte(`<a href="{{ .Destination }}">{{ .Text }}</a>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	post := "---\ntitle: \"Second Post\"\ndate: 2024-02-20\n---\nA [link](/about/).\n"
+	if err := os.WriteFile(filepath.Join(root, "content", "blog", "second-post.md"), []byte(post), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	builder := newRebuildTestBuilder(t, root)
+
+	if err := os.WriteFile(hookPath, []byte(`<a class="link" href="{{ .Destination }}">{{ .Text }}</a>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Rebuild([]string{hookPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if !result.Incremental {
+		t.Error("render hook change should rebuild incrementally")
+	}
+	if want := []string{"/blog/second-post/"}; !slices.Equal(result.Changed, want) {
+		t.Errorf("Changed = %v, want %v", result.Changed, want)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "public", "blog", "second-post", "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<a class="link" href="/about/">link</a>`) {
+		t.Errorf("post should use the edited render hook:\n%s", data)
+	}
+}
+
+func TestRebuild_StaticChangeFallsBackToFullBuild(t *testing.T) {
+	root := setupTestSite(t)
+	builder := newRebuildTestBuilder(t, root)
+
+	cssPath := filepath.Join(root, "static", "extra.css")
+	if err := os.WriteFile(cssPath, []byte("a{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := builder.Rebuild([]string{cssPath})
+	if err != nil {
+		t.Fatalf("Rebuild() error: %v", err)
+	}
+	if result.Incremental {
+		t.Error("static change should trigger a full build")
+	}
+	if len(result.Changed) != 0 {
+		t.Errorf("Changed = %v, want none (no page output differs)", result.Changed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "public", "extra.css")); err != nil {
+		t.Errorf("expected static file to be copied: %v", err)
+	}
 }
@@ -0,0 +1,239 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/content"
+	tmpl "github.com/aellingwood/forge/internal/template"
+)
+
+// depGraph records which rendered page URLs depend on which build inputs.
+// Inputs are identified by string keys built with sourceKey, templateKey,
+// dataKey, listKey, and siteKey. The Builder keeps the graph from its last
+// build so that a rebuild can re-render only the pages a change affects.
+type depGraph struct {
+	edges map[string]map[string]struct{} // input key -> dependent URLs
+}
+
+// newDepGraph returns an empty dependency graph.
+func newDepGraph() *depGraph {
+	return &depGraph{edges: make(map[string]map[string]struct{})}
+}
+
+// add records that the page at url depends on input.
+func (g *depGraph) add(input, url string) {
+	urls, ok := g.edges[input]
+	if !ok {
+		urls = make(map[string]struct{})
+		g.edges[input] = urls
+	}
+	urls[url] = struct{}{}
+}
+
+// has reports whether any page depends on input.
+func (g *depGraph) has(input string) bool {
+	_, ok := g.edges[input]
+	return ok
+}
+
+// affected returns the sorted URLs of every page that depends on at least
+// one of the given inputs.
+func (g *depGraph) affected(inputs []string) []string {
+	seen := make(map[string]struct{})
+	for _, in := range inputs {
+		for url := range g.edges[in] {
+			seen[url] = struct{}{}
+		}
+	}
+	urls := make([]string, 0, len(seen))
+	for url := range seen {
+		urls = append(urls, url)
+	}
+	slices.Sort(urls)
+	return urls
+}
+
+// siteKey is the input for site-wide page collections (.Site.Pages,
+// .Site.AllPages, .Site.Sections, .Site.Taxonomies, .Site.GetPage). Any
+// content change affects it.
+const siteKey = "site"
+
+// sourceKey is the input for a content source file, by its path relative to
+// the content directory (Page.SourcePath).
+func sourceKey(path string) string { return "source:" + path }
+
+// templateKey is the input for a template, by engine name
+// (e.g. "_default/single.html").
+func templateKey(name string) string { return "template:" + name }
+
+// dataKey is the input for a top-level entry of .Site.Data, i.e. one file
+// or directory under data/.
+func dataKey(name string) string { return "data:" + name }
+
+// listKey is the input for the membership and order of a paginated list —
+// a section list, the home page, or a taxonomy term — identified by the URL
+// of its first page.
+func listKey(url string) string { return "list:" + url }
+
+var (
+	siteCollectionRe = regexp.MustCompile(`Site\.(?:Pages|AllPages|Sections|Taxonomies|GetPage)\b`)
+	siteDataRe       = regexp.MustCompile(`Site\.Data(?:\.(\w+))?`)
+)
+
+// templateRefs describes which site-wide inputs a template reads.
+type templateRefs struct {
+	site    bool     // reads .Site.Pages, .Site.Sections, or .Site.Taxonomies
+	allData bool     // reads .Site.Data without naming a specific entry
+	data    []string // named .Site.Data entries
+}
+
+// scanTemplateRefs inspects a template source for references to site-wide
+// collections and data files. It is a textual scan, so values reached only
+// through a variable or a partial argument (e.g. {{ partial "x" .Site }})
+// are not detected.
+func scanTemplateRefs(src string) templateRefs {
+	var refs templateRefs
+	refs.site = siteCollectionRe.MatchString(src)
+	for _, m := range siteDataRe.FindAllStringSubmatch(src, -1) {
+		if m[1] == "" {
+			refs.allData = true
+			continue
+		}
+		refs.data = append(refs.data, m[1])
+	}
+	return refs
+}
+
+// resolveTemplate returns the template used to render p, falling back to the
+// default single template. It returns "" when no template applies and the
+// page's rendered markdown is written as-is.
+func resolveTemplate(engine *tmpl.Engine, p *content.Page) string {
+	name := engine.Resolve(p.Type.String(), p.CurrentSection(), p.Layout)
+	if name == "" {
+		name = engine.Resolve("single", "_default", "")
+	}
+	return name
+}
+
+// buildDepGraph records the inputs every rendered page depends on: its own
+// source file, the templates in its render set, its shortcodes and render
+// hooks, the data files and site-wide collections those templates read,
+// the sections it inherits cascade values from, the sources of pages it
+// links to (prev/next, project, ancestors, child sections, paginator
+// members), and the membership of the list it paginates. It also returns a
+// signature per paginated list that changes whenever the list's members or
+// their order change.
+func buildDepGraph(
+	pages []*content.Page,
+	ctxMap map[*content.Page]*tmpl.PageContext,
+	engine *tmpl.Engine,
+	dataNames []string,
+) (*depGraph, map[string]string) {
+	g := newDepGraph()
+
+	pageOf := make(map[*tmpl.PageContext]*content.Page, len(ctxMap))
+	for p, ctx := range ctxMap {
+		pageOf[ctx] = p
+	}
+	addSource := func(ctx *tmpl.PageContext, url string) {
+		if p, ok := pageOf[ctx]; ok && p.SourcePath != "" {
+			g.add(sourceKey(p.SourcePath), url)
+		}
+	}
+
+	refCache := make(map[string]templateRefs)
+	addTemplate := func(name, url string) {
+		g.add(templateKey(name), url)
+		refs, ok := refCache[name]
+		if !ok {
+			refs = scanTemplateRefs(engine.Source(name))
+			refCache[name] = refs
+		}
+		if refs.site {
+			g.add(siteKey, url)
+		}
+		if refs.allData {
+			for _, d := range dataNames {
+				g.add(dataKey(d), url)
+			}
+		}
+		for _, d := range refs.data {
+			g.add(dataKey(d), url)
+		}
+	}
+	listPages := make(map[string][]string)
+
+	for _, p := range pages {
+		// The pages of a list share the list page's entries, so each source
+		// is recorded once per list.
+		url := p.URL
+		if p.PagerOf != nil {
+			url = p.PagerOf.URL
+		}
+		if p.SourcePath != "" {
+			g.add(sourceKey(p.SourcePath), url)
+		}
+		// The sections whose cascade the page inherits, which need not be
+		// among its ancestors: a draft section still cascades.
+		for _, src := range p.Cascaded {
+			g.add(sourceKey(src), url)
+		}
+
+		if name := resolveTemplate(engine, p); name != "" {
+			for _, dep := range engine.Dependencies(name) {
+				addTemplate(dep, url)
+			}
+		}
+		for _, name := range p.Shortcodes {
+			addTemplate("shortcodes/"+name+".html", url)
+		}
+		for _, kind := range p.RenderHooks {
+			addTemplate("_markup/render-"+kind+".html", url)
+		}
+
+		ctx := ctxMap[p]
+		if ctx == nil {
+			continue
+		}
+		if ctx.PrevPage != nil {
+			addSource(ctx.PrevPage, url)
+		}
+		if ctx.NextPage != nil {
+			addSource(ctx.NextPage, url)
+		}
+		if ctx.ProjectPage != nil {
+			addSource(ctx.ProjectPage, url)
+		}
+		for _, pp := range ctx.ProjectPosts {
+			addSource(pp, url)
+		}
+		for _, a := range ctx.Ancestors {
+			addSource(a, url)
+		}
+		for _, s := range ctx.Sections {
+			addSource(s, url)
+		}
+		if pg := ctx.Paginator; pg != nil {
+			g.add(listKey(pg.First), url)
+			members := make([]string, 0, len(pg.Pages))
+			for _, m := range pg.Pages {
+				addSource(m, url)
+				if mp, ok := pageOf[m]; ok {
+					members = append(members, mp.SourcePath)
+				}
+			}
+			listPages[pg.First] = append(listPages[pg.First],
+				fmt.Sprintf("%d:%s", pg.PageNumber, strings.Join(members, ",")))
+		}
+	}
+
+	signatures := make(map[string]string, len(listPages))
+	for first, parts := range listPages {
+		slices.Sort(parts)
+		signatures[first] = strings.Join(parts, "\n")
+	}
+	return g, signatures
+}
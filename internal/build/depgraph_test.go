@@ -0,0 +1,70 @@
+package build
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDepGraph_Affected(t *testing.T) {
+	g := newDepGraph()
+	g.add(sourceKey("blog/a.md"), "/a/")
+	g.add(sourceKey("blog/a.md"), "/blog/")
+	g.add(templateKey("_default/single.html"), "/a/")
+	g.add(templateKey("_default/single.html"), "/b/")
+	g.add(listKey("/blog/"), "/blog/")
+	g.add(listKey("/blog/"), "/blog/page/2/")
+
+	tests := []struct {
+		inputs []string
+		want   []string
+	}{
+		{[]string{sourceKey("blog/a.md")}, []string{"/a/", "/blog/"}},
+		{[]string{templateKey("_default/single.html")}, []string{"/a/", "/b/"}},
+		{[]string{listKey("/blog/"), sourceKey("blog/a.md")}, []string{"/a/", "/blog/", "/blog/page/2/"}},
+		{[]string{sourceKey("blog/unknown.md")}, []string{}},
+	}
+	for _, tt := range tests {
+		got := g.affected(tt.inputs)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("affected(%v) = %v, want %v", tt.inputs, got, tt.want)
+		}
+	}
+
+	if !g.has(listKey("/blog/")) {
+		t.Error("has(list:/blog/) should be true")
+	}
+	if g.has(dataKey("authors")) {
+		t.Error("has(data:authors) should be false")
+	}
+}
+
+func TestScanTemplateRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		site    bool
+		allData bool
+		data    []string
+	}{
+		{"plain", `<h1>{{ .Title }}</h1>`, false, false, nil},
+		{"paginator", `{{ range .Paginator.Pages }}{{ .Title }}{{ end }}`, false, false, nil},
+		{"sections", `{{ range .Site.Sections.blog }}{{ end }}`, true, false, nil},
+		{"root pages", `{{ range $.Site.Pages }}{{ end }}`, true, false, nil},
+		{"named data", `{{ range .Site.Data.authors }}{{ end }}{{ .Site.Data.links.github }}`, false, false, []string{"authors", "links"}},
+		{"indexed data", `{{ index .Site.Data "authors" }}`, false, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := scanTemplateRefs(tt.src)
+			if refs.site != tt.site {
+				t.Errorf("site = %v, want %v", refs.site, tt.site)
+			}
+			if refs.allData != tt.allData {
+				t.Errorf("allData = %v, want %v", refs.allData, tt.allData)
+			}
+			if !slices.Equal(refs.data, tt.data) {
+				t.Errorf("data = %v, want %v", refs.data, tt.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package build
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PageError is a build failure attributed to a single page.
+type PageError struct {
+	Path string // source path relative to content/, or the URL of a generated page
+	Err  error
+}
+
+func (e *PageError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *PageError) Unwrap() error {
+	return e.Err
+}
+
+// BuildErrors is returned by a build run with BuildOptions.KeepGoing when
+// one or more pages failed. It lists every failure, sorted by path. The
+// accompanying BuildResult describes the pages that did build.
+type BuildErrors struct {
+	Errors []*PageError
+}
+
+func (e *BuildErrors) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d errors:", len(e.Errors))
+	for _, pe := range e.Errors {
+		sb.WriteString("\n  " + pe.Error())
+	}
+	return sb.String()
+}
+
+func (e *BuildErrors) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, pe := range e.Errors {
+		errs[i] = pe
+	}
+	return errs
+}
@@ -0,0 +1,86 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// AssetManifestFile is the name of the asset manifest written to the output
+// directory. It maps each fingerprinted asset's original URL to its hashed URL.
+const AssetManifestFile = "asset-manifest.json"
+
+// fingerprintLen is the number of hex characters of the content hash used in
+// fingerprinted file names.
+const fingerprintLen = 12
+
+// fingerprintAssets renames every file in outputDir whose slash-separated
+// relative path matches one of patterns (path.Match syntax, e.g. "css/*.css")
+// to name.<hash>.ext, where hash is derived from the file contents. It
+// returns a manifest from the original URL ("/css/style.css") to the
+// fingerprinted URL ("/css/style.3f9a1c2b7d4e.css").
+func fingerprintAssets(outputDir string, patterns []string) (map[string]string, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid fingerprint pattern %q: %w", pattern, err)
+		}
+	}
+
+	var matched []string
+	err := filepath.WalkDir(outputDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(outputDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, rel); ok {
+				matched = append(matched, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]string, len(matched))
+	for _, rel := range matched {
+		src := filepath.Join(outputDir, filepath.FromSlash(rel))
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", src, err)
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])[:fingerprintLen]
+
+		ext := path.Ext(rel)
+		hashed := strings.TrimSuffix(rel, ext) + "." + hash + ext
+		if err := os.Rename(src, filepath.Join(outputDir, filepath.FromSlash(hashed))); err != nil {
+			return nil, fmt.Errorf("renaming %s: %w", src, err)
+		}
+		manifest["/"+rel] = "/" + hashed
+	}
+	return manifest, nil
+}
+
+// writeAssetManifest writes manifest to AssetManifestFile in outputDir.
+func writeAssetManifest(mn *minifier, outputDir string, manifest map[string]string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding asset manifest: %w", err)
+	}
+	return writeDirectFile(mn, outputDir, AssetManifestFile, append(data, '\n'))
+}
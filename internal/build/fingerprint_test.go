@@ -0,0 +1,75 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprintAssets(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"css/style.css":   "body{margin:0}",
+		"js/app.js":       "var a=1",
+		"js/vendor/x.js":  "var x=1",
+		"images/logo.png": "png",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest, err := fingerprintAssets(dir, []string{"css/*.css", "js/*.js"})
+	if err != nil {
+		t.Fatalf("fingerprintAssets() error: %v", err)
+	}
+	if len(manifest) != 2 {
+		t.Fatalf("manifest = %v, want 2 entries", manifest)
+	}
+
+	hashed := manifest["/css/style.css"]
+	if len(hashed) != len("/css/style..css")+fingerprintLen {
+		t.Errorf("manifest[/css/style.css] = %q, want /css/style.<hash>.css", hashed)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(hashed)))
+	if err != nil || string(got) != files["css/style.css"] {
+		t.Errorf("fingerprinted CSS = %q, %v", got, err)
+	}
+	for _, name := range []string{"css/style.css", "js/app.js"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+			t.Errorf("%s should have been renamed", name)
+		}
+	}
+	for _, name := range []string{"js/vendor/x.js", "images/logo.png"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s should be untouched: %v", name, err)
+		}
+	}
+
+	// Identical content yields the same name.
+	again := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(again, "css"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(again, "css", "style.css"), []byte(files["css/style.css"]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manifest2, err := fingerprintAssets(again, []string{"css/*.css"})
+	if err != nil {
+		t.Fatalf("fingerprintAssets() error: %v", err)
+	}
+	if manifest2["/css/style.css"] != hashed {
+		t.Errorf("hash not stable: %q vs %q", manifest2["/css/style.css"], hashed)
+	}
+}
+
+func TestFingerprintAssets_InvalidPattern(t *testing.T) {
+	if _, err := fingerprintAssets(t.TempDir(), []string{"css/[.css"}); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,207 @@
+package build
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/buildmanifest"
+	"github.com/aellingwood/forge/internal/content"
+	"github.com/aellingwood/forge/internal/image"
+)
+
+// buildState is what a Builder remembers from its last successful build so
+// that Rebuild can limit work to the outputs a change affects.
+type buildState struct {
+	graph        *depGraph
+	lists        map[string]string            // paginated list URL -> member signature
+	outputs      map[string][sha256.Size]byte // page URL -> hash of rendered HTML
+	markdown     map[string]renderedMarkdown  // source path -> cached markdown render
+	imgProcessor *image.Processor
+	assets       map[string]string // asset URL -> fingerprinted URL
+	producers    producerTable     // output file -> what wrote it
+	manifest     *buildmanifest.Manifest
+}
+
+// renderedMarkdown caches the markdown render of one source file, keyed by
+// the raw content it was rendered from.
+type renderedMarkdown struct {
+	raw       string
+	content   string
+	toc       string
+	templated bool // rendered with shortcodes or render hooks
+}
+
+// changeSet describes the inputs a rebuild has to account for.
+type changeSet struct {
+	inputs    []string // dependency-graph keys touched by the change
+	renderAll bool     // a template was added or removed; re-render every page
+}
+
+// Rebuild brings the output directory up to date after the given files
+// changed. Changes to content markdown, layouts, and data files re-render
+// only the pages that depend on them, as recorded in the dependency graph
+// of the previous build, and rewrite only outputs whose bytes changed.
+// Outputs of removed pages and alias redirects no longer generated are
+// deleted. Any other change (config, static files, assets, bundle
+// resources, a bundle's index file appearing or disappearing), or a
+// Rebuild without a previous successful build, falls back to a full Build.
+// The returned BuildResult lists the URLs whose output changed.
+func (b *Builder) Rebuild(changed []string) (*BuildResult, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == nil {
+		return b.build(nil)
+	}
+	projectRoot, err := b.projectRoot()
+	if err != nil {
+		return nil, err
+	}
+	cs, ok := b.classifyChanges(projectRoot, changed)
+	if !ok {
+		return b.build(nil)
+	}
+	return b.build(cs)
+}
+
+// classifyChanges maps changed file paths to dependency-graph inputs. It
+// reports false when a path cannot be handled incrementally and a full
+// build is required.
+func (b *Builder) classifyChanges(projectRoot string, changed []string) (*changeSet, bool) {
+	contentDir := filepath.Join(projectRoot, "content")
+	dataDir := filepath.Join(projectRoot, "data")
+	layoutDirs := []string{
+		filepath.Join(projectRoot, "layouts"),
+		filepath.Join(b.themePath(projectRoot), "layouts"),
+	}
+
+	cs := &changeSet{}
+	for _, path := range changed {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectRoot, path)
+		}
+
+		if rel, ok := relWithin(contentDir, path); ok {
+			if filepath.Ext(rel) != ".md" {
+				return nil, false
+			}
+			// A bundle's resources are copied, or left behind, with its
+			// index file only by a full build.
+			if base := filepath.Base(path); base == "index.md" || base == "_index.md" {
+				if _, err := os.Stat(path); err != nil || !b.state.graph.has(sourceKey(rel)) {
+					return nil, false
+				}
+			}
+			cs.inputs = append(cs.inputs, sourceKey(rel), siteKey)
+			continue
+		}
+
+		if rel, ok := relWithin(dataDir, path); ok {
+			top, _, _ := strings.Cut(rel, "/")
+			cs.inputs = append(cs.inputs, dataKey(strings.TrimSuffix(top, filepath.Ext(top))))
+			continue
+		}
+
+		handled := false
+		for _, dir := range layoutDirs {
+			rel, ok := relWithin(dir, path)
+			if !ok {
+				continue
+			}
+			key := templateKey(rel)
+			cs.inputs = append(cs.inputs, key)
+			// A new or deleted template can change which template every
+			// page resolves to.
+			if _, err := os.Stat(path); err != nil || !b.state.graph.has(key) {
+				cs.renderAll = true
+			}
+			handled = true
+			break
+		}
+		if !handled {
+			return nil, false
+		}
+	}
+	return cs, true
+}
+
+// relWithin returns path relative to dir, with forward slashes, if path is
+// inside dir.
+func relWithin(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// outputPath returns the file that WriteFile writes for a page URL.
+func outputPath(outputDir, url string) string {
+	return filepath.Join(outputDir, urlToFilePath(url))
+}
+
+// removeOutput deletes the rendered file for a page URL that no longer
+// exists, along with any directories that are left empty.
+func removeOutput(outputDir, url string) error {
+	return removeOutputFile(outputDir, urlToFilePath(url))
+}
+
+// removeOutputFile deletes the output file rel (OS or slash separated),
+// along with any directories below outputDir that are left empty.
+func removeOutputFile(outputDir, rel string) error {
+	path := filepath.Join(outputDir, filepath.FromSlash(rel))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing %s: %w", path, err)
+	}
+	// Best effort: a directory may still hold bundle assets or child pages.
+	for dir := filepath.Dir(path); dir != outputDir && strings.HasPrefix(dir, outputDir); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// affectedPages narrows pages to those an incremental build must render:
+// pages that depend on a changed input in either the previous or the new
+// dependency graph, pages of lists whose membership or order changed, and
+// pages that did not exist in the previous build. The pages 2..N of a list
+// are rendered along with the list page.
+func affectedPages(pages []*content.Page, cs *changeSet, prev *buildState, graph *depGraph, lists map[string]string) []*content.Page {
+	inputs := slices.Clone(cs.inputs)
+	for first, sig := range lists {
+		if prev.lists[first] != sig {
+			inputs = append(inputs, listKey(first))
+		}
+	}
+	for first := range prev.lists {
+		if _, ok := lists[first]; !ok {
+			inputs = append(inputs, listKey(first))
+		}
+	}
+
+	want := make(map[string]struct{})
+	for _, url := range prev.graph.affected(inputs) {
+		want[url] = struct{}{}
+	}
+	for _, url := range graph.affected(inputs) {
+		want[url] = struct{}{}
+	}
+
+	var out []*content.Page
+	for _, p := range pages {
+		_, hit := want[p.URL]
+		if p.PagerOf != nil {
+			_, hit = want[p.PagerOf.URL]
+		}
+		_, existed := prev.outputs[p.URL]
+		if hit || !existed {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	stdhtml "html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/content"
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+)
+
+// BrokenLink is an internal href, src, or srcset URL in a generated HTML
+// file that does not resolve to a file, or to an element ID, in the output
+// tree.
+type BrokenLink struct {
+	Page   string // output file containing the link, e.g. "blog/post/index.html"
+	Source string // source file that produced Page, e.g. "content/blog/post.md", if known
+	URL    string // the link as written
+	Reason string // "not found" or "missing fragment #id"
+}
+
+func (l BrokenLink) String() string {
+	where := l.Page
+	if l.Source != "" {
+		where = l.Source + " (" + l.Page + ")"
+	}
+	return fmt.Sprintf("%s: %s: %s", where, l.URL, l.Reason)
+}
+
+// LinkSources maps the output file of each page with a source file to that
+// source, relative to the project root. It is the sources argument of
+// CheckLinks.
+func LinkSources(pages []*content.Page) map[string]string {
+	sources := make(map[string]string, len(pages))
+	for _, p := range pages {
+		if p.SourcePath != "" {
+			sources[filepath.ToSlash(urlToFilePath(p.URL))] = "content/" + p.SourcePath
+		}
+	}
+	return sources
+}
+
+// htmlDoc is what the link checker records about one HTML file.
+type htmlDoc struct {
+	links []string
+	ids   map[string]bool
+}
+
+// CheckLinks parses every HTML file in outputDir and resolves each internal
+// href, src, and srcset URL against the output tree, including fragments,
+// which must match an id (or an <a name>) in the target page. Root-relative
+// URLs may carry basePath, the path the site is hosted under. sources maps
+// output files to the source files that produced them (see LinkSources).
+// Broken links are returned sorted by page, each URL once per page.
+func CheckLinks(outputDir, basePath string, sources map[string]string) ([]BrokenLink, error) {
+	docs := make(map[string]*htmlDoc)
+	err := filepath.WalkDir(outputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".html") {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", p, err)
+		}
+		rel, err := filepath.Rel(outputDir, p)
+		if err != nil {
+			return fmt.Errorf("computing relative path for %s: %w", p, err)
+		}
+		docs[filepath.ToSlash(rel)] = parseHTMLDoc(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning output directory: %w", err)
+	}
+
+	pages := make([]string, 0, len(docs))
+	for page := range docs {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+
+	var broken []BrokenLink
+	for _, page := range pages {
+		seen := make(map[string]bool)
+		for _, link := range docs[page].links {
+			if seen[link] {
+				continue // e.g. the same menu link in desktop and mobile navigation
+			}
+			seen[link] = true
+			target, fragment, ok := resolveLink(page, link, basePath)
+			if !ok {
+				continue // external or not a navigable URL
+			}
+			if _, isHTML := docs[target]; !isHTML && path.Ext(target) == "" {
+				// A directory link without a trailing slash, e.g. "/blog".
+				if _, isIndex := docs[path.Join(target, "index.html")]; isIndex {
+					target = path.Join(target, "index.html")
+				}
+			}
+			reason := ""
+			if doc, isHTML := docs[target]; isHTML {
+				if fragment != "" && !doc.ids[fragment] {
+					reason = "missing fragment #" + fragment
+				}
+			} else if info, err := os.Stat(filepath.Join(outputDir, filepath.FromSlash(target))); err != nil || info.IsDir() {
+				reason = "not found"
+			}
+			if reason != "" {
+				broken = append(broken, BrokenLink{Page: page, Source: sources[page], URL: link, Reason: reason})
+			}
+		}
+	}
+	return broken, nil
+}
+
+// parseHTMLDoc collects the link URLs and element IDs of an HTML document.
+func parseHTMLDoc(data []byte) *htmlDoc {
+	doc := &htmlDoc{ids: make(map[string]bool)}
+	l := html.NewLexer(parse.NewInputBytes(data))
+	var tag []byte
+	for {
+		tt, _ := l.Next()
+		switch tt {
+		case html.ErrorToken:
+			return doc
+		case html.StartTagToken:
+			tag = bytes.ToLower(l.Text())
+		case html.AttributeToken:
+			key := strings.ToLower(string(l.AttrKey()))
+			val := stdhtml.UnescapeString(strings.Trim(string(l.AttrVal()), `"'`))
+			switch {
+			case key == "id", key == "name" && string(tag) == "a":
+				doc.ids[val] = true
+			case key == "href", key == "src":
+				doc.links = append(doc.links, strings.TrimSpace(val))
+			case key == "srcset":
+				for _, candidate := range strings.Split(val, ",") {
+					if u, _, _ := strings.Cut(strings.TrimSpace(candidate), " "); u != "" {
+						doc.links = append(doc.links, u)
+					}
+				}
+			}
+		}
+	}
+}
+
+// resolveLink resolves link, found in the output file page, to the output
+// file it refers to and its fragment. It reports false for links that are
+// not checked: absolute and protocol-relative URLs, other schemes (mailto:,
+// data:, ...), and empty links.
+func resolveLink(page, link, basePath string) (target, fragment string, ok bool) {
+	if link == "" || link == "#" || strings.HasPrefix(link, "//") {
+		return "", "", false
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "", "", false
+	}
+	fragment = u.Fragment
+
+	p := u.Path
+	switch {
+	case p == "":
+		return page, fragment, true // fragment or query on the same page
+	case strings.HasPrefix(p, "/"):
+		if basePath != "" {
+			if rest, found := strings.CutPrefix(p, basePath); found && (rest == "" || strings.HasPrefix(rest, "/")) {
+				p = rest
+			}
+		}
+		if p == "" {
+			p = "/"
+		}
+	default:
+		p = path.Join(path.Dir("/"+page), p)
+		if strings.HasSuffix(u.Path, "/") {
+			p += "/"
+		}
+	}
+
+	if strings.HasSuffix(p, "/") {
+		p += "index.html"
+	}
+	return strings.TrimPrefix(path.Clean(p), "/"), fragment, true
+}
@@ -0,0 +1,80 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLink(t *testing.T) {
+	tests := []struct {
+		page, link, basePath string
+		target, fragment     string
+		ok                   bool
+	}{
+		{"blog/post/index.html", "/css/style.css", "", "css/style.css", "", true},
+		{"blog/post/index.html", "/blog/", "", "blog/index.html", "", true},
+		{"blog/post/index.html", "/", "", "index.html", "", true},
+		{"blog/post/index.html", "#intro", "", "blog/post/index.html", "intro", true},
+		{"blog/post/index.html", "../other/#x", "", "blog/other/index.html", "x", true},
+		{"blog/post/index.html", "diagram.png", "", "blog/post/diagram.png", "", true},
+		{"blog/post/index.html", "/docs/blog/?page=2", "/docs", "blog/index.html", "", true},
+		{"blog/post/index.html", "/docs", "/docs", "index.html", "", true},
+		{"blog/post/index.html", "https://example.com/", "", "", "", false},
+		{"blog/post/index.html", "//cdn.example.com/a.js", "", "", "", false},
+		{"blog/post/index.html", "mailto:me@example.com", "", "", "", false},
+		{"blog/post/index.html", "", "", "", "", false},
+	}
+	for _, tt := range tests {
+		target, fragment, ok := resolveLink(tt.page, tt.link, tt.basePath)
+		if target != tt.target || fragment != tt.fragment || ok != tt.ok {
+			t.Errorf("resolveLink(%q, %q, %q) = %q, %q, %v; want %q, %q, %v",
+				tt.page, tt.link, tt.basePath, target, fragment, ok, tt.target, tt.fragment, tt.ok)
+		}
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.html": `<a href="/blog/">Blog</a><a href="/blog/post/#setup">Setup</a>` +
+			`<a href="/blog/post/#missing">Missing</a><a href="/nope/">Nope</a><a href="/nope/">Nope again</a>` +
+			`<a href="https://example.com/broken">External</a>`,
+		"blog/index.html": `<a href="post/">Post</a><a href="/blog">Self</a>`,
+		"blog/post/index.html": `<h2 id="setup">Setup</h2><link rel="stylesheet" href="/css/style.css">` +
+			`<img src="diagram.png" srcset="/img/a-320.jpg 320w, /img/gone.jpg 640w">` +
+			`<a href="#setup">Top</a><a href="#nowhere">Nowhere</a>`,
+		"blog/post/diagram.png": "png",
+		"css/style.css":         "body{}",
+		"img/a-320.jpg":         "jpg",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broken, err := CheckLinks(dir, "", map[string]string{"blog/post/index.html": "content/blog/post.md"})
+	if err != nil {
+		t.Fatalf("CheckLinks() error: %v", err)
+	}
+
+	want := []BrokenLink{
+		{Page: "blog/post/index.html", Source: "content/blog/post.md", URL: "/img/gone.jpg", Reason: "not found"},
+		{Page: "blog/post/index.html", Source: "content/blog/post.md", URL: "#nowhere", Reason: "missing fragment #nowhere"},
+		{Page: "index.html", URL: "/blog/post/#missing", Reason: "missing fragment #missing"},
+		{Page: "index.html", URL: "/nope/", Reason: "not found"},
+	}
+	if len(broken) != len(want) {
+		t.Fatalf("CheckLinks() found %d broken links, want %d: %v", len(broken), len(want), broken)
+	}
+	for i := range want {
+		if broken[i] != want[i] {
+			t.Errorf("broken[%d] = %+v, want %+v", i, broken[i], want[i])
+		}
+	}
+}
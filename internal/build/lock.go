@@ -0,0 +1,245 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LockFile is the advisory lock, relative to the project root, that a
+// build holds while it writes the output directory.
+const LockFile = ".forge/build.lock"
+
+// lockPollInterval is how often a waiting build retries the lock.
+const lockPollInterval = 100 * time.Millisecond
+
+// lockWriteGrace is how long a lock file created without a hard link (see
+// placeLockFile) may stay empty before it counts as abandoned.
+const lockWriteGrace = time.Second
+
+// LockInfo identifies the process holding the build lock. A PID alone
+// does not: after a reboot or container restart another process may have
+// it, so the lock also records the machine, its boot, and when the process
+// started, where the platform can tell.
+type LockInfo struct {
+	PID          int       `json:"pid"`
+	Host         string    `json:"host,omitempty"`
+	BootID       string    `json:"bootId,omitempty"`
+	ProcessStart string    `json:"processStart,omitempty"` // opaque, see processStart
+	Command      string    `json:"command"`
+	Started      time.Time `json:"started"`
+}
+
+// LockError is returned when another build holds the lock for longer than
+// the caller is willing to wait.
+type LockError struct {
+	Path   string
+	Holder LockInfo
+}
+
+func (e *LockError) Error() string {
+	pid := fmt.Sprintf("pid %d", e.Holder.PID)
+	if host, _ := os.Hostname(); e.Holder.Host != "" && e.Holder.Host != host {
+		pid += " on " + e.Holder.Host
+	}
+	return fmt.Sprintf("another build is running (%s: %s, started %s); remove %s if that process is gone",
+		pid, e.Holder.Command, e.Holder.Started.Format(time.TimeOnly), e.Path)
+}
+
+// buildLock is a held build lock.
+type buildLock struct {
+	path string
+}
+
+// acquireBuildLock takes the build lock of the project at projectRoot,
+// waiting up to timeout for another holder to release it. A lock left
+// behind by a process that no longer exists (see holderAlive) is taken
+// over. It returns a *LockError naming the holder if the lock cannot be
+// taken in time.
+func acquireBuildLock(projectRoot string, timeout time.Duration) (*buildLock, error) {
+	path := filepath.Join(projectRoot, filepath.FromSlash(LockFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating lock directory: %w", err)
+	}
+	host, _ := os.Hostname()
+	info, err := json.Marshal(LockInfo{
+		PID:          os.Getpid(),
+		Host:         host,
+		BootID:       bootID(),
+		ProcessStart: processStart(os.Getpid()),
+		Command:      lockCommand(),
+		Started:      time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding lock info: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := createLockFile(path, info)
+		if err == nil {
+			return &buildLock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("creating lock file: %w", err)
+		}
+
+		holder, data, readErr := readLockInfo(path)
+		if os.IsNotExist(readErr) {
+			continue // released in the meantime
+		}
+		// A lock created without a hard link is empty until its holder
+		// has written it.
+		unwritten := data != nil && len(data) == 0 && lockAge(path) < lockWriteGrace
+		if data != nil && !unwritten && (readErr != nil || !holderAlive(holder)) {
+			// The holder died without releasing the lock, or the lock
+			// file is not one of ours.
+			if err := removeStaleLock(path, data); err != nil {
+				return nil, fmt.Errorf("removing stale lock file: %w", err)
+			}
+			continue
+		}
+		if readErr != nil && !unwritten {
+			return nil, fmt.Errorf("reading lock file: %w", readErr)
+		}
+		if time.Now().After(deadline) {
+			return nil, &LockError{Path: path, Holder: holder}
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// release removes the lock file.
+func (l *buildLock) release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing lock file: %w", err)
+	}
+	return nil
+}
+
+// holderAlive reports whether the process that wrote the lock h may still
+// hold it. A process on another machine sharing the project cannot be
+// checked, so it is assumed alive.
+func holderAlive(h LockInfo) bool {
+	if host, err := os.Hostname(); err == nil && h.Host != "" && h.Host != host {
+		return true
+	}
+	if h.BootID != "" && h.BootID != bootID() {
+		return false // the machine has restarted since
+	}
+	if !processAlive(h.PID) {
+		return false
+	}
+	// The PID may belong to a newer process by now.
+	start := processStart(h.PID)
+	return h.ProcessStart == "" || start == "" || start == h.ProcessStart
+}
+
+// lockAge returns how long ago the lock file at path was last written, or
+// zero if that cannot be told.
+func lockAge(path string) time.Duration {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return time.Since(fi.ModTime())
+}
+
+// createLockFile creates the lock file at path holding info, failing with
+// os.ErrExist if it already exists. The content is written to a temporary
+// file first and put into place by placeLockFile.
+func createLockFile(path string, info []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".build.lock.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(info); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return placeLockFile(tmp.Name(), path, info)
+}
+
+// placeLockFile puts the file src, which holds data, into place at path,
+// failing with os.ErrExist if path exists. It hard-links src, so the lock
+// never exists without its holder's details. Where that fails, as on
+// filesystems without hard links, it creates path exclusively and writes
+// data to it instead; until then the lock is empty, which acquireBuildLock
+// allows for.
+func placeLockFile(src, path string, data []byte) error {
+	err := os.Link(src, path)
+	if err == nil || errors.Is(err, os.ErrExist) {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// removeStaleLock removes the lock file at path if it still holds stale.
+// Another build may have replaced the stale lock with its own since it was
+// read, so the file is first renamed to a name no other build uses and
+// compared there; a live lock moved aside that way is linked back into
+// place. Lock contents name the holder's PID and start time, so two locks
+// never hold the same bytes.
+func removeStaleLock(path string, stale []byte) error {
+	aside := fmt.Sprintf("%s.stale.%d.%d", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil // another build removed it first
+		}
+		return err
+	}
+	defer os.Remove(aside)
+	moved, err := os.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(moved, stale) {
+		return nil
+	}
+	if err := placeLockFile(aside, path, moved); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// readLockInfo reads the holder of the lock file at path. It also returns
+// the file's raw contents, or nil if it could not be read.
+func readLockInfo(path string) (LockInfo, []byte, error) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, nil, err
+	}
+	err = json.Unmarshal(data, &info)
+	return info, data, err
+}
+
+// lockCommand describes the current process for LockInfo, e.g.
+// "forge serve".
+func lockCommand() string {
+	if len(os.Args) == 0 {
+		return "unknown"
+	}
+	args := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+	return strings.Join(args, " ")
+}
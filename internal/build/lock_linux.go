@@ -0,0 +1,38 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// bootID returns the kernel's random ID for the current boot, or "" if it
+// cannot be read.
+func bootID() string {
+	b, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// processStart returns when the process with the given PID started, in
+// clock ticks since boot (field 22 of /proc/<pid>/stat), or "" if that
+// cannot be read.
+func processStart(pid int) string {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// Field 2, the command name, is in parentheses and may hold spaces.
+	i := bytes.LastIndexByte(b, ')')
+	if i < 0 {
+		return ""
+	}
+	fields := strings.Fields(string(b[i+1:]))
+	if len(fields) < 20 { // fields[0] is field 3
+		return ""
+	}
+	return fields[19]
+}
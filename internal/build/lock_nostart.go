@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+
+package build
+
+// bootID returns an ID of the current boot. This platform offers none
+// that is cheap to read, so it returns "".
+func bootID() string {
+	return ""
+}
+
+// processStart returns when the process with the given PID started. This
+// platform offers no portable way to tell, so it returns "" and a lock is
+// judged by its PID alone.
+func processStart(pid int) string {
+	return ""
+}
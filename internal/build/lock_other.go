@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package build
+
+// processAlive reports whether a process with the given PID exists. This
+// platform offers no way to tell, so every holder is assumed alive and a
+// stale lock must be removed by hand, as LockError says.
+func processAlive(pid int) bool {
+	return pid > 0
+}
@@ -0,0 +1,197 @@
+package build
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquireBuildLock(t *testing.T) {
+	root := t.TempDir()
+
+	lock, err := acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatalf("acquireBuildLock() error: %v", err)
+	}
+
+	// A second build fails immediately, naming the holder.
+	_, err = acquireBuildLock(root, 0)
+	var lockErr *LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("second acquireBuildLock() error = %v, want *LockError", err)
+	}
+	if lockErr.Holder.PID != os.Getpid() || lockErr.Holder.Command == "" {
+		t.Errorf("holder = %+v, want this process", lockErr.Holder)
+	}
+
+	if err := lock.release(); err != nil {
+		t.Fatalf("release() error: %v", err)
+	}
+	lock, err = acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatalf("acquireBuildLock() after release error: %v", err)
+	}
+	lock.release()
+}
+
+func TestAcquireBuildLock_Waits(t *testing.T) {
+	root := t.TempDir()
+	lock, err := acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(3 * lockPollInterval)
+		lock.release()
+	}()
+
+	second, err := acquireBuildLock(root, 5*time.Second)
+	if err != nil {
+		t.Fatalf("acquireBuildLock() with timeout error: %v", err)
+	}
+	second.release()
+}
+
+func TestAcquireBuildLock_StaleLock(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, filepath.FromSlash(LockFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A PID far above any real pid_max.
+	data, _ := json.Marshal(LockInfo{PID: 1 << 30, Command: "forge build", Started: time.Now()})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatalf("acquireBuildLock() over stale lock error: %v", err)
+	}
+	lock.release()
+}
+
+func TestRemoveStaleLock_Replaced(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build.lock")
+	stale := []byte(`{"pid":1073741824}`)
+	if err := os.WriteFile(path, stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another build takes over the stale lock before this one removes it.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	live := []byte(`{"pid":1}`)
+	if err := createLockFile(path, live); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := removeStaleLock(path, stale); err != nil {
+		t.Fatalf("removeStaleLock() error: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(live) {
+		t.Errorf("live lock = %q, %v; want it left in place", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("lock directory holds %d files, want only the lock", len(entries))
+	}
+
+	// A lock that still holds what was read is removed.
+	if err := removeStaleLock(path, live); err != nil {
+		t.Fatalf("removeStaleLock() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stale lock still exists: %v", err)
+	}
+}
+
+func TestHolderAlive(t *testing.T) {
+	host, _ := os.Hostname()
+	self := LockInfo{
+		PID:          os.Getpid(),
+		Host:         host,
+		BootID:       bootID(),
+		ProcessStart: processStart(os.Getpid()),
+	}
+	if !holderAlive(self) {
+		t.Errorf("holderAlive(%+v) = false for this process", self)
+	}
+
+	dead := LockInfo{PID: 1 << 30, Host: host}
+	if holderAlive(dead) {
+		t.Error("holderAlive() = true for a PID that does not exist")
+	}
+
+	// A process on another machine cannot be checked.
+	remote := LockInfo{PID: 1 << 30, Host: host + ".elsewhere"}
+	if !holderAlive(remote) {
+		t.Error("holderAlive() = false for a holder on another host")
+	}
+
+	if self.ProcessStart != "" {
+		reused := self
+		reused.ProcessStart += "0"
+		if holderAlive(reused) {
+			t.Error("holderAlive() = true for a PID reused by a newer process")
+		}
+	}
+	if self.BootID != "" {
+		rebooted := self
+		rebooted.BootID += "-before"
+		if holderAlive(rebooted) {
+			t.Error("holderAlive() = true for a lock from an earlier boot")
+		}
+	}
+}
+
+func TestPlaceLockFile_NoHardLinks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build.lock")
+	data := []byte(`{"pid":1}`)
+
+	// Linking a missing file fails the way a filesystem without hard
+	// links does, so the lock is created exclusively instead.
+	if err := placeLockFile(filepath.Join(dir, "missing"), path, data); err != nil {
+		t.Fatalf("placeLockFile() error: %v", err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != string(data) {
+		t.Errorf("lock = %q, %v; want %q", got, err, data)
+	}
+	if err := placeLockFile(filepath.Join(dir, "missing"), path, data); !errors.Is(err, os.ErrExist) {
+		t.Errorf("placeLockFile() over existing lock error = %v, want os.ErrExist", err)
+	}
+}
+
+func TestAcquireBuildLock_EmptyLock(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, filepath.FromSlash(LockFile))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A lock still being written is left alone.
+	var lockErr *LockError
+	if _, err := acquireBuildLock(root, 0); !errors.As(err, &lockErr) {
+		t.Fatalf("acquireBuildLock() over new empty lock error = %v, want *LockError", err)
+	}
+
+	// One that stayed empty was abandoned.
+	old := time.Now().Add(-2 * lockWriteGrace)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := acquireBuildLock(root, 0)
+	if err != nil {
+		t.Fatalf("acquireBuildLock() over abandoned empty lock error: %v", err)
+	}
+	lock.release()
+}
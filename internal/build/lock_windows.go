@@ -0,0 +1,52 @@
+package build
+
+import (
+	"errors"
+	"strconv"
+	"syscall"
+)
+
+// stillActive is the exit code Windows reports for a running process
+// (STILL_ACTIVE).
+const stillActive = 259
+
+// processAlive reports whether a process with the given PID is running.
+// Windows has no null signal, so it opens the process and asks for its
+// exit code instead.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// A process of another user exists but cannot be opened.
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
+
+// bootID returns an ID of the current boot. Windows needs none: a process
+// started after a reboot has a later creation time (see processStart).
+func bootID() string {
+	return ""
+}
+
+// processStart returns the creation time of the process with the given
+// PID, or "" if it cannot be opened.
+func processStart(pid int) string {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(h)
+	var created, exited, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &created, &exited, &kernel, &user); err != nil {
+		return ""
+	}
+	return strconv.FormatInt(created.Nanoseconds(), 10)
+}
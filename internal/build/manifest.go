@@ -0,0 +1,137 @@
+package build
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/buildmanifest"
+	"github.com/aellingwood/forge/internal/content"
+)
+
+// producerTable records the producer of each output file, keyed by the
+// slash-separated path relative to the output directory.
+type producerTable map[string]buildmanifest.Producer
+
+// record notes that p wrote the output file rel (OS or slash separated).
+func (t producerTable) record(rel string, p buildmanifest.Producer) {
+	t[filepath.ToSlash(rel)] = p
+}
+
+// rename moves the producer of the output file from to to, e.g. after
+// fingerprinting.
+func (t producerTable) rename(from, to string) {
+	if p, ok := t[from]; ok {
+		delete(t, from)
+		t[to] = p
+	}
+}
+
+// clone returns a copy of t; a nil table yields an empty one.
+func (t producerTable) clone() producerTable {
+	c := make(producerTable, len(t))
+	maps.Copy(c, t)
+	return c
+}
+
+// pageProducer returns the producer of a page rendered with templateName,
+// which is empty when the page was written without a template.
+func pageProducer(p *content.Page, templateName string) buildmanifest.Producer {
+	prod := buildmanifest.Producer{Kind: buildmanifest.ProducerPage, Template: templateName}
+	if p.SourcePath != "" {
+		prod.Source = "content/" + p.SourcePath
+	}
+	return prod
+}
+
+// projectPath returns path relative to projectRoot with forward slashes,
+// or path itself if it is outside the project.
+func projectPath(projectRoot, path string) string {
+	rel, err := filepath.Rel(projectRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writeBuildManifest walks outputDir and writes buildmanifest.Path under
+// projectRoot, listing every file with its hash and its producer from
+// producers. Hashes from prev, the manifest of the build before, are reused
+// for files whose size and modification time are unchanged, so an
+// incremental build only hashes what it rewrote.
+func writeBuildManifest(projectRoot, outputDir string, producers producerTable, prev *buildmanifest.Manifest) (*buildmanifest.Manifest, error) {
+	relOut, err := filepath.Rel(projectRoot, outputDir)
+	if err != nil {
+		relOut = outputDir
+	}
+	m := &buildmanifest.Manifest{OutputDir: filepath.ToSlash(relOut), Files: []buildmanifest.File{}}
+
+	err = filepath.WalkDir(outputDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outputDir, p)
+		if err != nil {
+			return fmt.Errorf("computing relative path for %s: %w", p, err)
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum := ""
+		if old, ok := prev.Unchanged(rel, info); ok {
+			sum = old.SHA256
+		} else if sum, err = hashFile(p); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, buildmanifest.File{
+			Path:        rel,
+			Size:        info.Size(),
+			SHA256:      sum,
+			ContentType: buildmanifest.ContentTypeForExt(filepath.Ext(rel)),
+			ModTime:     info.ModTime(),
+			Producer:    producers[rel],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning output directory: %w", err)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding build manifest: %w", err)
+	}
+	path := filepath.Join(projectRoot, filepath.FromSlash(buildmanifest.Path))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return nil, fmt.Errorf("writing build manifest: %w", err)
+	}
+	return m, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 hash of the file at path.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,105 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	"github.com/tdewolff/minify/v2/json"
+	"github.com/tdewolff/minify/v2/svg"
+	"github.com/tdewolff/minify/v2/xml"
+)
+
+// minifyMediaTypes maps output file extensions to minifier media types.
+// Files with other extensions are written unchanged.
+var minifyMediaTypes = map[string]string{
+	".html":        "text/html",
+	".css":         "text/css",
+	".js":          "application/javascript",
+	".mjs":         "application/javascript",
+	".json":        "application/json",
+	".webmanifest": "application/json",
+	".svg":         "image/svg+xml",
+	".xml":         "text/xml",
+}
+
+// minifier minifies build outputs by file extension and tracks the bytes it
+// saves. A nil *minifier passes data through unchanged, so call sites do not
+// need to check whether minification is enabled.
+type minifier struct {
+	m     *minify.M
+	saved atomic.Int64
+}
+
+// newMinifier returns a minifier for HTML, CSS, JS, JSON, SVG, and XML.
+// HTML keeps document and end tags and attribute quotes so that later
+// processing (live reload and nonce injection in the dev server) still
+// finds the markup it looks for; <pre> and <textarea> whitespace is always
+// preserved.
+func newMinifier() *minifier {
+	m := minify.New()
+	m.Add("text/html", &html.Minifier{
+		KeepDocumentTags: true,
+		KeepEndTags:      true,
+		KeepQuotes:       true,
+	})
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("application/javascript", js.Minify)
+	m.AddFunc("application/json", json.Minify)
+	m.AddFunc("image/svg+xml", svg.Minify)
+	m.AddFunc("text/xml", xml.Minify)
+	return &minifier{m: m}
+}
+
+// bytes minifies data as the type implied by the file extension ext
+// (e.g. ".html"). Unknown extensions are returned unchanged.
+func (mn *minifier) bytes(ext string, data []byte) ([]byte, error) {
+	if mn == nil {
+		return data, nil
+	}
+	mediaType, ok := minifyMediaTypes[strings.ToLower(ext)]
+	if !ok {
+		return data, nil
+	}
+	out, err := mn.m.Bytes(mediaType, data)
+	if err != nil {
+		return nil, fmt.Errorf("minifying %s: %w", mediaType, err)
+	}
+	mn.saved.Add(int64(len(data) - len(out)))
+	return out, nil
+}
+
+// Saved returns the total number of bytes removed so far.
+func (mn *minifier) Saved() int64 {
+	if mn == nil {
+		return 0
+	}
+	return mn.saved.Load()
+}
+
+// copyFile copies src to dst, minifying CSS and JS files on the way.
+// Files that are already minified (*.min.css, *.min.js) are copied as-is.
+func (mn *minifier) copyFile(src, dst string) error {
+	ext := strings.ToLower(filepath.Ext(src))
+	if mn == nil || (ext != ".css" && ext != ".js") || strings.HasSuffix(strings.ToLower(src), ".min"+ext) {
+		return CopyFile(src, dst)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	out, err := mn.bytes(ext, data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", dst, err)
+	}
+	return os.WriteFile(dst, out, 0o644)
+}
@@ -0,0 +1,116 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMinifier_HTML(t *testing.T) {
+	mn := newMinifier()
+	in := `<!DOCTYPE html>
+<html>
+<head>
+  <title>Test</title>
+  <script nonce="abc123">
+    var x = 1;
+  </script>
+</head>
+<body>
+  <p>
+    Hello,   world
+  </p>
+  <pre><code>line one
+    indented   line
+</code></pre>
+</body>
+</html>`
+
+	out, err := mn.bytes(".html", []byte(in))
+	if err != nil {
+		t.Fatalf("bytes() error: %v", err)
+	}
+	got := string(out)
+
+	if len(got) >= len(in) {
+		t.Errorf("output not smaller: %d >= %d bytes", len(got), len(in))
+	}
+	if !strings.Contains(got, "line one\n    indented   line\n") {
+		t.Errorf("<pre> whitespace not preserved:\n%s", got)
+	}
+	if !strings.Contains(got, `nonce="abc123"`) {
+		t.Errorf("script nonce not preserved:\n%s", got)
+	}
+	if !strings.Contains(got, "</body>") {
+		t.Errorf("</body> end tag removed:\n%s", got)
+	}
+	if mn.Saved() != int64(len(in)-len(got)) {
+		t.Errorf("Saved() = %d, want %d", mn.Saved(), len(in)-len(got))
+	}
+}
+
+func TestMinifier_ByExtension(t *testing.T) {
+	mn := newMinifier()
+	tests := []struct {
+		ext  string
+		in   string
+		want string
+	}{
+		{".css", "body {\n  margin: 0px;\n}\n", "body{margin:0}"},
+		{".js", "var  a = 1 ;\n", "var a=1"},
+		{".json", "{\n  \"a\": [1, 2]\n}", `{"a":[1,2]}`},
+		{".xml", "<urlset>\n  <url>x</url>\n</urlset>", "<urlset><url>x</url></urlset>"},
+		{".txt", "keep   this\n", "keep   this\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			out, err := mn.bytes(tt.ext, []byte(tt.in))
+			if err != nil {
+				t.Fatalf("bytes(%q) error: %v", tt.ext, err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("bytes(%q) = %q, want %q", tt.ext, out, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinifier_NilPassesThrough(t *testing.T) {
+	var mn *minifier
+	in := "<p>  spaced  </p>"
+	out, err := mn.bytes(".html", []byte(in))
+	if err != nil {
+		t.Fatalf("bytes() error: %v", err)
+	}
+	if string(out) != in {
+		t.Errorf("nil minifier changed output: %q", out)
+	}
+	if mn.Saved() != 0 {
+		t.Errorf("Saved() = %d, want 0", mn.Saved())
+	}
+}
+
+func TestMinifier_CopyFileSkipsMinified(t *testing.T) {
+	dir := t.TempDir()
+	mn := newMinifier()
+	src := "body {\n  margin: 0;\n}\n"
+
+	for _, name := range []string{"app.css", "vendor.min.css"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := mn.copyFile(filepath.Join(dir, name), filepath.Join(dir, "out", name)); err != nil {
+			t.Fatalf("copyFile(%s) error: %v", name, err)
+		}
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "out", "app.css"))
+	if string(got) != "body{margin:0}" {
+		t.Errorf("app.css = %q, want minified", got)
+	}
+	got, _ = os.ReadFile(filepath.Join(dir, "out", "vendor.min.css"))
+	if string(got) != src {
+		t.Errorf("vendor.min.css = %q, want copied unchanged", got)
+	}
+}
@@ -0,0 +1,97 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/content"
+)
+
+// URLCollision is an output file claimed by more than one page or alias.
+type URLCollision struct {
+	File      string   // output file, e.g. "blog/post/index.html"
+	Claimants []string // what claims it, e.g. "content/blog/a.md" or "alias /old/ in content/blog/b.md"
+}
+
+// URLCollisionError is returned by a build in which several pages or
+// aliases would write the same output file. Files are compared
+// case-insensitively, since deploy targets on macOS and Windows would
+// otherwise overwrite one with the other.
+type URLCollisionError struct {
+	Collisions []URLCollision
+}
+
+func (e *URLCollisionError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d output URL collisions:", len(e.Collisions))
+	for _, c := range e.Collisions {
+		fmt.Fprintf(&sb, "\n  %s: %s", c.File, strings.Join(c.Claimants, ", "))
+	}
+	return sb.String()
+}
+
+// checkURLOwnership builds the table of output files claimed by pages and
+// by their aliases, and returns a *URLCollisionError listing every file with
+// more than one claimant. With cleanURLs false, aliases are mapped to their
+// .html form the same way the build writes them.
+func checkURLOwnership(pages []*content.Page, cleanURLs bool) error {
+	type claim struct {
+		file      string
+		claimants []string
+	}
+	owners := make(map[string]*claim)
+	add := func(file, claimant string) {
+		key := strings.ToLower(file)
+		c, ok := owners[key]
+		if !ok {
+			c = &claim{file: file}
+			owners[key] = c
+		}
+		c.claimants = append(c.claimants, claimant)
+	}
+
+	for _, p := range pages {
+		add(filepath.ToSlash(urlToFilePath(p.URL)), claimantName(p))
+		for _, alias := range p.Aliases {
+			if !cleanURLs {
+				alias = content.UglyURL(alias, false)
+			}
+			add(aliasURLToFilePath(alias), fmt.Sprintf("alias %s in %s", alias, claimantName(p)))
+		}
+	}
+
+	var collisions []URLCollision
+	for _, c := range owners {
+		if len(c.claimants) > 1 {
+			sort.Strings(c.claimants)
+			collisions = append(collisions, URLCollision{File: c.file, Claimants: c.claimants})
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].File < collisions[j].File
+	})
+	return &URLCollisionError{Collisions: collisions}
+}
+
+// claimantName describes the page that claims an output file: its source
+// file, or what generated it.
+func claimantName(p *content.Page) string {
+	if p.SourcePath != "" {
+		return "content/" + p.SourcePath
+	}
+	switch p.Type {
+	case content.PageTypeHome:
+		return "generated home page"
+	case content.PageTypeTaxonomyList:
+		return fmt.Sprintf("%s taxonomy list", p.Section)
+	case content.PageTypeTaxonomy:
+		return fmt.Sprintf("%s term %q", p.Section, p.Title)
+	default:
+		return "generated page " + p.URL
+	}
+}
@@ -0,0 +1,66 @@
+package build
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aellingwood/forge/internal/content"
+)
+
+func TestCheckURLOwnership(t *testing.T) {
+	pages := []*content.Page{
+		{SourcePath: "blog/a.md", URL: "/blog/post/"},
+		{SourcePath: "blog/b.md", URL: "/blog/post/"},
+		{SourcePath: "blog/c.md", URL: "/blog/c/", Aliases: []string{"/about/"}},
+		{SourcePath: "about.md", URL: "/about/"},
+		{SourcePath: "tags/_index.md", URL: "/tags/", Type: content.PageTypeList},
+		{URL: "/tags/", Type: content.PageTypeTaxonomyList, Section: "tags"},
+		{SourcePath: "Docs/_index.md", URL: "/Docs/", Type: content.PageTypeList},
+		{SourcePath: "docs.md", URL: "/docs/"},
+		{SourcePath: "blog/unique.md", URL: "/blog/unique/", Aliases: []string{"/old-unique/"}},
+	}
+
+	err := checkURLOwnership(pages, true)
+	var collErr *URLCollisionError
+	if !errors.As(err, &collErr) {
+		t.Fatalf("checkURLOwnership() error = %v, want *URLCollisionError", err)
+	}
+
+	want := []URLCollision{
+		{File: "Docs/index.html", Claimants: []string{"content/Docs/_index.md", "content/docs.md"}},
+		{File: "about/index.html", Claimants: []string{"alias /about/ in content/blog/c.md", "content/about.md"}},
+		{File: "blog/post/index.html", Claimants: []string{"content/blog/a.md", "content/blog/b.md"}},
+		{File: "tags/index.html", Claimants: []string{"content/tags/_index.md", "tags taxonomy list"}},
+	}
+	if len(collErr.Collisions) != len(want) {
+		t.Fatalf("got %d collisions, want %d:\n%v", len(collErr.Collisions), len(want), err)
+	}
+	for i, c := range collErr.Collisions {
+		if c.File != want[i].File || !slices.Equal(c.Claimants, want[i].Claimants) {
+			t.Errorf("collision %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+}
+
+func TestCheckURLOwnership_NoCollisions(t *testing.T) {
+	pages := []*content.Page{
+		{SourcePath: "blog/a.md", URL: "/blog/a/", Aliases: []string{"/a/"}},
+		{SourcePath: "blog/b.md", URL: "/blog/b/"},
+	}
+	if err := checkURLOwnership(pages, true); err != nil {
+		t.Errorf("checkURLOwnership() error = %v, want nil", err)
+	}
+}
+
+func TestCheckURLOwnership_UglyAliases(t *testing.T) {
+	// With ugly URLs the alias /old/ is written to old.html, colliding with
+	// the page /old.html.
+	pages := []*content.Page{
+		{SourcePath: "old.md", URL: "/old.html"},
+		{SourcePath: "new.md", URL: "/new.html", Aliases: []string{"/old/"}},
+	}
+	if err := checkURLOwnership(pages, false); err == nil {
+		t.Error("checkURLOwnership() error = nil, want a collision on old.html")
+	}
+}
@@ -0,0 +1,145 @@
+package build
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/content"
+	tmpl "github.com/aellingwood/forge/internal/template"
+)
+
+// paginateLists attaches a Paginator to every section list page, every
+// taxonomy term page, and the home page if its _index.md sets paginate (a
+// home page usually shows a few recent posts rather than the whole stream). Page 1 of each list is the list page
+// itself; for pages 2..N a virtual copy of the list page is created at the
+// pager URL and its context is registered in ctxMap. The virtual pages are
+// returned so they can be rendered alongside the regular pages.
+func (b *Builder) paginateLists(
+	pages []*content.Page,
+	taxonomies map[string]*content.Taxonomy,
+	ctxMap map[*content.Page]*tmpl.PageContext,
+	baseURL string,
+) []*content.Page {
+	homeSections := b.feedSections()
+	basePath := content.BasePath(baseURL)
+
+	var extra []*content.Page
+	for _, p := range pages {
+		var members []*content.Page
+		switch p.Type {
+		case content.PageTypeList:
+			members = sectionMembers(pages, func(m *content.Page) bool { return m.CurrentSection() == p.CurrentSection() })
+		case content.PageTypeHome:
+			if p.Paginate <= 0 {
+				continue
+			}
+			members = sectionMembers(pages, func(m *content.Page) bool { return slices.Contains(homeSections, m.Section) })
+			members = slices.DeleteFunc(members, func(p *content.Page) bool { return !p.ListedInSite() })
+		case content.PageTypeTaxonomy:
+			members = taxonomyTermMembers(p, taxonomies)
+		default:
+			continue
+		}
+
+		ctx := ctxMap[p]
+		if ctx == nil {
+			continue
+		}
+
+		pageSize := p.Paginate
+		if pageSize <= 0 {
+			pageSize = b.config.Pagination.PageSize
+		}
+
+		pagers := content.Paginate(members, pageSize, p.URL)
+		if len(pagers) == 0 {
+			// Nothing to list; still expose an empty first page so templates
+			// can range over .Paginator.Pages unconditionally.
+			url := content.WithBasePath(basePath, p.URL)
+			ctx.Paginator = &tmpl.Paginator{
+				PageNumber: 1,
+				TotalPages: 1,
+				URL:        url,
+				First:      url,
+				Last:       url,
+			}
+			continue
+		}
+
+		ctx.Paginator = pagerToContext(pagers[0], ctxMap, basePath)
+		for _, pager := range pagers[1:] {
+			vp := *p
+			vp.URL = pager.URL
+			vp.Permalink = strings.TrimRight(baseURL, "/") + pager.URL
+			vp.Aliases = nil
+			vp.PagerOf = p
+
+			vctx := *ctx
+			vctx.URL = content.WithBasePath(basePath, vp.URL)
+			vctx.Permalink = vp.Permalink
+			vctx.Paginator = pagerToContext(pager, ctxMap, basePath)
+
+			ctxMap[&vp] = &vctx
+			extra = append(extra, &vp)
+		}
+	}
+	return extra
+}
+
+// feedSections returns the sections whose pages make up the site's main
+// chronological stream: the configured feed sections, or "blog" by default.
+// The home page paginates over the same set.
+func (b *Builder) feedSections() []string {
+	if len(b.config.Feeds.Sections) > 0 {
+		return b.config.Feeds.Sections
+	}
+	return []string{"blog"}
+}
+
+// sectionMembers returns the regular (single) pages listed in their section
+// that satisfy match, preserving the order of pages.
+func sectionMembers(pages []*content.Page, match func(p *content.Page) bool) []*content.Page {
+	var members []*content.Page
+	for _, p := range pages {
+		if p.Type == content.PageTypeSingle && p.ListedInSection() && match(p) {
+			members = append(members, p)
+		}
+	}
+	return members
+}
+
+// taxonomyTermMembers returns the pages tagged with the term that the given
+// taxonomy term page represents.
+func taxonomyTermMembers(p *content.Page, taxonomies map[string]*content.Taxonomy) []*content.Page {
+	name, _ := p.Params["taxonomy"].(string)
+	term, _ := p.Params["term"].(string)
+	tax, ok := taxonomies[name]
+	if !ok {
+		return nil
+	}
+	return tax.Terms[term]
+}
+
+// pagerToContext converts a content.Pager to a template Paginator, resolving
+// each member page to its already-built PageContext. Pager URLs are prefixed
+// with basePath.
+func pagerToContext(pager *content.Pager, ctxMap map[*content.Page]*tmpl.PageContext, basePath string) *tmpl.Paginator {
+	members := make([]*tmpl.PageContext, 0, len(pager.Pages))
+	for _, mp := range pager.Pages {
+		if mctx, ok := ctxMap[mp]; ok {
+			members = append(members, mctx)
+		}
+	}
+	return &tmpl.Paginator{
+		Pages:      members,
+		PageNumber: pager.PageNumber,
+		TotalPages: pager.TotalPages,
+		HasPrev:    pager.HasPrev,
+		HasNext:    pager.HasNext,
+		URL:        content.WithBasePath(basePath, pager.URL),
+		PrevURL:    content.WithBasePath(basePath, pager.PrevURL),
+		NextURL:    content.WithBasePath(basePath, pager.NextURL),
+		First:      content.WithBasePath(basePath, pager.First),
+		Last:       content.WithBasePath(basePath, pager.Last),
+	}
+}
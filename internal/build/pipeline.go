@@ -3,6 +3,7 @@ package build
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 
 	"github.com/aellingwood/forge/internal/content"
@@ -61,14 +62,32 @@ func renderParallel(pages []*content.Page, workers int, fn func(*content.Page) e
 	return nil
 }
 
+// renderParallelAll is like renderParallel but processes every page even
+// when some fail, returning the error of each page whose fn failed.
+func renderParallelAll(pages []*content.Page, workers int, fn func(*content.Page) error) map[*content.Page]error {
+	var mu sync.Mutex
+	failed := make(map[*content.Page]error)
+	// The wrapped fn never fails, so renderParallel visits every page.
+	_ = renderParallel(pages, workers, func(p *content.Page) error {
+		if err := fn(p); err != nil {
+			mu.Lock()
+			failed[p] = err
+			mu.Unlock()
+		}
+		return nil
+	})
+	return failed
+}
+
 // setSectionNavigation sets PrevPage and NextPage links for pages within
-// the same section. Pages should already be sorted (newest first).
+// the same (nested) section, skipping pages not listed there. Pages should
+// already be sorted (newest first).
 func setSectionNavigation(pages []*content.Page) {
 	// Group pages by section.
 	sections := make(map[string][]*content.Page)
 	for _, p := range pages {
-		if p.Type == content.PageTypeSingle {
-			sections[p.Section] = append(sections[p.Section], p)
+		if p.Type == content.PageTypeSingle && p.ListedInSection() {
+			sections[p.CurrentSection()] = append(sections[p.CurrentSection()], p)
 		}
 	}
 
@@ -85,6 +104,52 @@ func setSectionNavigation(pages []*content.Page) {
 	}
 }
 
+// setSectionTree sets the Parent of every regular and section list page:
+// the list page of the nearest enclosing section, or the home page for
+// top-level sections and pages outside any section. A section without an
+// _index.md has no list page and is skipped over.
+func setSectionTree(pages []*content.Page) {
+	var home *content.Page
+	lists := make(map[string]*content.Page)
+	for _, p := range pages {
+		switch p.Type {
+		case content.PageTypeHome:
+			home = p
+		case content.PageTypeList:
+			lists[p.CurrentSection()] = p
+		}
+	}
+
+	for _, p := range pages {
+		var dir string
+		switch p.Type {
+		case content.PageTypeSingle:
+			dir = p.CurrentSection()
+		case content.PageTypeList:
+			dir = parentSection(p.CurrentSection())
+		default:
+			continue
+		}
+		p.Parent = home
+		for ; dir != ""; dir = parentSection(dir) {
+			if list, ok := lists[dir]; ok {
+				p.Parent = list
+				break
+			}
+		}
+	}
+}
+
+// parentSection returns the section enclosing a nested section path, or ""
+// for a top-level section.
+func parentSection(section string) string {
+	i := strings.LastIndex(section, "/")
+	if i < 0 {
+		return ""
+	}
+	return section[:i]
+}
+
 // buildTaxonomyMaps builds maps from taxonomy term to pages.
 // Returns maps for tags and categories.
 func buildTaxonomyMaps(pages []*content.Page) (tags map[string][]*content.Page, categories map[string][]*content.Page) {
@@ -102,6 +167,17 @@ func buildTaxonomyMaps(pages []*content.Page) (tags map[string][]*content.Page,
 	return tags, categories
 }
 
+// taxonomyNames converts the config taxonomy map (singular -> plural, as
+// written in forge.yaml) into the plural -> singular form expected by
+// content.BuildTaxonomies.
+func taxonomyNames(cfg map[string]string) map[string]string {
+	names := make(map[string]string, len(cfg))
+	for singular, plural := range cfg {
+		names[plural] = singular
+	}
+	return names
+}
+
 // buildProjectPostMap maps project slug to posts that reference that project,
 // sorted newest-first by date.
 func buildProjectPostMap(pages []*content.Page) map[string][]*content.Page {
@@ -0,0 +1,78 @@
+package build
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// urlAttrRe matches href, src, and srcset attributes with a quoted value.
+var urlAttrRe = regexp.MustCompile(`(?i)(\s(?:href|src|srcset)\s*=\s*)("[^"]*"|'[^']*')`)
+
+// refreshURLRe matches the target URL of a <meta http-equiv="refresh">
+// content attribute, as written on alias redirect pages.
+var refreshURLRe = regexp.MustCompile(`(?i)(content="\d+;\s*url=)([^"]*)`)
+
+// relativizeURLs rewrites every root-relative href, src, and srcset URL in
+// html, and the target of any meta refresh, to a path relative to the page
+// at pageURL, so the output can be opened from disk (file://) or hosted under
+// an arbitrary path. For example "/css/style.css" on "/blog/post/" becomes
+// "../../css/style.css". Links to directories point at their index.html.
+// basePath, the path the site is hosted under, is stripped from URLs first
+// since the output directory is the site root. Absolute, protocol-relative,
+// and already relative URLs are left alone.
+func relativizeURLs(html []byte, pageURL, basePath string) []byte {
+	// The directory of the page's output file, relative to the output root.
+	dir := path.Dir("/" + filepath.ToSlash(urlToFilePath(pageURL)))
+	up := strings.Repeat("../", strings.Count(strings.TrimPrefix(dir, "/"), "/")+1)
+	if dir == "/" {
+		up = ""
+	}
+
+	html = refreshURLRe.ReplaceAllFunc(html, func(m []byte) []byte {
+		sub := refreshURLRe.FindSubmatch(m)
+		return []byte(string(sub[1]) + relativeURL(string(sub[2]), up, basePath))
+	})
+	return urlAttrRe.ReplaceAllFunc(html, func(m []byte) []byte {
+		sub := urlAttrRe.FindSubmatch(m)
+		prefix, quoted := string(sub[1]), string(sub[2])
+		quote, value := quoted[:1], quoted[1:len(quoted)-1]
+
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(prefix)), "srcset") {
+			candidates := strings.Split(value, ",")
+			for i, c := range candidates {
+				c = strings.TrimSpace(c)
+				u, descriptor, _ := strings.Cut(c, " ")
+				candidates[i] = strings.TrimSpace(relativeURL(u, up, basePath) + " " + descriptor)
+			}
+			value = strings.Join(candidates, ", ")
+		} else {
+			value = relativeURL(value, up, basePath)
+		}
+		return []byte(prefix + quote + value + quote)
+	})
+}
+
+// relativeURL rewrites one root-relative URL u to a path below up, the
+// "../" sequence that leads from the page to the output root.
+func relativeURL(u, up, basePath string) string {
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") {
+		return u
+	}
+	if basePath != "" {
+		if rest, ok := strings.CutPrefix(u, basePath); ok && (rest == "" || strings.ContainsAny(rest[:1], "/?#")) {
+			u = rest
+		}
+	}
+
+	p, suffix := u, ""
+	if i := strings.IndexAny(u, "?#"); i >= 0 {
+		p, suffix = u[:i], u[i:]
+	}
+	p = strings.TrimPrefix(p, "/")
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index.html"
+	}
+	return up + p + suffix
+}
@@ -0,0 +1,83 @@
+package build
+
+import "testing"
+
+func TestRelativizeURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageURL  string
+		basePath string
+		in       string
+		want     string
+	}{
+		{
+			name:    "stylesheet from nested page",
+			pageURL: "/blog/post/",
+			in:      `<link rel="stylesheet" href="/css/style.css">`,
+			want:    `<link rel="stylesheet" href="../../css/style.css">`,
+		},
+		{
+			name:    "directory link points at index.html",
+			pageURL: "/blog/post/",
+			in:      `<a href="/blog/">Blog</a><a href="/">Home</a>`,
+			want:    `<a href="../../blog/index.html">Blog</a><a href="../../index.html">Home</a>`,
+		},
+		{
+			name:    "home page",
+			pageURL: "/",
+			in:      `<a href="/blog/">Blog</a><script src='/js/app.js'></script>`,
+			want:    `<a href="blog/index.html">Blog</a><script src='js/app.js'></script>`,
+		},
+		{
+			name:    "ugly URL page",
+			pageURL: "/blog/post.html",
+			in:      `<a href="/tags/go/index.html#top">go</a>`,
+			want:    `<a href="../tags/go/index.html#top">go</a>`,
+		},
+		{
+			name:    "fragment and query kept",
+			pageURL: "/about/",
+			in:      `<a href="/blog/?page=2#list">x</a>`,
+			want:    `<a href="../blog/index.html?page=2#list">x</a>`,
+		},
+		{
+			name:    "srcset candidates",
+			pageURL: "/blog/post/",
+			in:      `<img srcset="/img/a-320.jpg 320w, /img/a-640.jpg 640w" src="/img/a.jpg">`,
+			want:    `<img srcset="../../img/a-320.jpg 320w, ../../img/a-640.jpg 640w" src="../../img/a.jpg">`,
+		},
+		{
+			name:    "absolute, protocol-relative, relative, and fragment URLs untouched",
+			pageURL: "/blog/post/",
+			in:      `<a href="https://example.com/x"></a><script src="//cdn.example.com/a.js"></script><img src="hero.jpg"><a href="#top"></a>`,
+			want:    `<a href="https://example.com/x"></a><script src="//cdn.example.com/a.js"></script><img src="hero.jpg"><a href="#top"></a>`,
+		},
+		{
+			name:     "base path stripped",
+			pageURL:  "/blog/post/",
+			basePath: "/docs",
+			in:       `<a href="/docs/">Home</a><link href="/docs/css/style.css">`,
+			want:     `<a href="../../index.html">Home</a><link href="../../css/style.css">`,
+		},
+		{
+			name:    "meta refresh target",
+			pageURL: "/old-post/index.html",
+			in:      `<meta http-equiv="refresh" content="0; url=/blog/new-post/">`,
+			want:    `<meta http-equiv="refresh" content="0; url=../blog/new-post/index.html">`,
+		},
+		{
+			name:    "attribute names need leading whitespace",
+			pageURL: "/blog/post/",
+			in:      `<a data-href="/x/" href="/x/">x</a>`,
+			want:    `<a data-href="/x/" href="../../x/index.html">x</a>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(relativizeURLs([]byte(tt.in), tt.pageURL, tt.basePath))
+			if got != tt.want {
+				t.Errorf("relativizeURLs() =\n  %s\nwant\n  %s", got, tt.want)
+			}
+		})
+	}
+}
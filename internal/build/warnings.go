@@ -0,0 +1,272 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/content"
+	tmpl "github.com/aellingwood/forge/internal/template"
+)
+
+// Warning codes identify the kind of problem a Warning reports.
+const (
+	WarnTailwindUnavailable = "tailwind-unavailable" // the Tailwind CSS binary could not be downloaded
+	WarnMissingCover        = "missing-cover"        // a cover image file does not exist
+	WarnUnknownLayout       = "unknown-layout"       // a layout template was not found
+	WarnNoTemplate          = "no-template"          // no template matched; raw content was written
+	WarnUnknownProject      = "unknown-project"      // a project: slug matches no project page
+	WarnEmptyTaxonomyTerm   = "empty-taxonomy-term"  // a tag, category, or other term is blank
+	WarnMergedTaxonomyTerm  = "merged-taxonomy-term" // terms with the same URL slug share one term page
+	WarnBrokenLink          = "broken-link"          // an internal link resolves to nothing (build.checkLinks)
+	WarnLeftoverOutput      = "leftover-output"      // the previous output directory could not be removed
+)
+
+// WarningCodes lists every warning code with what it usually means for the
+// author, for tools that explain warnings.
+var WarningCodes = []WarningCode{
+	{WarnMissingCover, "the cover image path is wrong or the file is missing"},
+	{WarnUnknownLayout, "the layout frontmatter names a template that does not exist"},
+	{WarnNoTemplate, "no layout template matches the page"},
+	{WarnUnknownProject, "the project frontmatter slug matches no page in content/projects"},
+	{WarnEmptyTaxonomyTerm, "a tags, categories, or other taxonomy list has a blank entry"},
+	{WarnMergedTaxonomyTerm, "two spellings of a term, such as \"c\" and \"c++\", map to the same URL; rename one to keep them apart"},
+	{WarnBrokenLink, "a link points to a page or file the site does not publish"},
+	{WarnLeftoverOutput, "the previous build output could not be deleted after the new one was published (a permissions problem, not a content fix); delete it by hand"},
+	{WarnTailwindUnavailable, "the Tailwind CSS binary could not be downloaded (usually a network problem, not a content fix)"},
+}
+
+// WarningCode describes a warning code.
+type WarningCode struct {
+	Code    string
+	Meaning string
+}
+
+// Warning is a problem that did not stop the build but likely produces
+// output the author did not intend.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"` // relative to the project root, e.g. "content/blog/post.md"
+	Line    int    `json:"line,omitempty"` // 1-based line in File, or 0 when unknown
+}
+
+func (w Warning) String() string {
+	var loc string
+	switch {
+	case w.File != "" && w.Line > 0:
+		loc = fmt.Sprintf("%s:%d: ", w.File, w.Line)
+	case w.File != "":
+		loc = w.File + ": "
+	}
+	return fmt.Sprintf("%s%s [%s]", loc, w.Message, w.Code)
+}
+
+// sortWarnings orders warnings by file, line, and code.
+func sortWarnings(warnings []Warning) {
+	slices.SortStableFunc(warnings, func(a, b Warning) int {
+		return cmp.Or(
+			strings.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			strings.Compare(a.Code, b.Code),
+		)
+	})
+}
+
+// pageWarnings checks pages for frontmatter that refers to things that do
+// not exist: cover images, layouts, and projects, as well as blank taxonomy
+// terms. staticDirs are searched for root-relative cover images;
+// taxonomies is the plural -> singular map of configured taxonomies.
+func pageWarnings(pages []*content.Page, engine *tmpl.Engine, projectRoot string, staticDirs []string, taxonomies map[string]string) []Warning {
+	contentDir := filepath.Join(projectRoot, "content")
+	projects := buildProjectPageIndex(pages)
+
+	var warnings []Warning
+	for _, p := range pages {
+		if p.SourcePath == "" || p.PagerOf != nil {
+			continue // generated pages have no frontmatter to fix
+		}
+		file := "content/" + p.SourcePath
+		src := filepath.Join(contentDir, filepath.FromSlash(p.SourcePath))
+		warn := func(code, key, format string, args ...any) {
+			warnings = append(warnings, Warning{
+				Code:    code,
+				Message: fmt.Sprintf(format, args...),
+				File:    file,
+				Line:    frontmatterLine(src, key),
+			})
+		}
+
+		if p.Cover != nil && p.Cover.Image != "" && !coverExists(p, staticDirs) {
+			warn(WarnMissingCover, "cover", "cover image %q not found", p.Cover.Image)
+		}
+
+		// Only single pages honor a layout (see tmpl.Engine.Resolve).
+		name := resolveTemplate(engine, p)
+		if p.Type == content.PageTypeSingle && p.Layout != "" &&
+			path.Base(name) != p.Layout+".html" {
+			fallback := "raw content"
+			if name != "" {
+				fallback = name
+			}
+			warn(WarnUnknownLayout, "layout", "layout %q not found, using %s", p.Layout, fallback)
+		} else if name == "" {
+			warn(WarnNoTemplate, "", "no template found, writing raw content")
+		}
+
+		if p.Project != "" {
+			if _, ok := projects[p.Project]; !ok {
+				warn(WarnUnknownProject, "project", "project %q matches no page in content/projects", p.Project)
+			}
+		}
+
+		for plural := range taxonomies {
+			for _, term := range pageTerms(p, plural) {
+				if strings.TrimSpace(term) == "" {
+					warn(WarnEmptyTaxonomyTerm, plural, "empty %s term is ignored", taxonomies[plural])
+					break
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// mergedTermWarnings reports the terms BuildTaxonomies merged because they
+// share a URL with another term.
+func mergedTermWarnings(taxonomies map[string]*content.Taxonomy, permalinks map[string]string) []Warning {
+	var warnings []Warning
+	for _, plural := range slices.Sorted(maps.Keys(taxonomies)) {
+		tax := taxonomies[plural]
+		for _, term := range slices.Sorted(maps.Keys(tax.Merged)) {
+			for _, other := range tax.Merged[term] {
+				warnings = append(warnings, Warning{
+					Code: WarnMergedTaxonomyTerm,
+					Message: fmt.Sprintf("%s %q shares the URL %s with %q; their pages are listed together",
+						tax.Singular, other, content.TermPermalink(permalinks, plural, term), term),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// coverExists reports whether the cover image of p exists: in the page
+// bundle for relative paths, or in one of staticDirs for root-relative
+// ones. Remote images are assumed to exist.
+func coverExists(p *content.Page, staticDirs []string) bool {
+	img := p.Cover.Image
+	if i := strings.IndexAny(img, "?#"); i >= 0 {
+		img = img[:i]
+	}
+	switch {
+	case strings.Contains(img, "://") || strings.HasPrefix(img, "//"):
+		return true
+	case strings.HasPrefix(img, "/"):
+		for _, dir := range staticDirs {
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(img))); err == nil {
+				return true
+			}
+		}
+		return false
+	case p.IsBundle:
+		_, err := os.Stat(filepath.Join(filepath.FromSlash(p.BundleDir), filepath.FromSlash(img)))
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// pageTerms returns the terms p lists for the taxonomy plural, without
+// discarding blank ones.
+func pageTerms(p *content.Page, plural string) []string {
+	switch plural {
+	case "tags":
+		return p.Tags
+	case "categories":
+		return p.Categories
+	}
+	var terms []string
+	switch v := p.Params[plural].(type) {
+	case []string:
+		terms = v
+	case []any:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				terms = append(terms, s)
+			}
+		}
+	case string:
+		terms = []string{v}
+	}
+	return terms
+}
+
+// brokenLinkWarnings converts broken links to warnings against the source
+// file of the linking page, or its output file for generated pages.
+func brokenLinkWarnings(broken []BrokenLink, projectRoot, outputDir string) []Warning {
+	warnings := make([]Warning, 0, len(broken))
+	for _, l := range broken {
+		w := Warning{
+			Code:    WarnBrokenLink,
+			Message: fmt.Sprintf("broken link %s on %s: %s", l.URL, l.Page, l.Reason),
+			File:    l.Source,
+		}
+		if l.Source != "" {
+			w.Line = textLine(filepath.Join(projectRoot, filepath.FromSlash(l.Source)), l.URL)
+		} else if rel, err := filepath.Rel(projectRoot, filepath.Join(outputDir, filepath.FromSlash(l.Page))); err == nil {
+			w.File = filepath.ToSlash(rel)
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+// textLine returns the 1-based line of the first occurrence of needle in
+// the file at path, or 0 if it does not occur.
+func textLine(path, needle string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	i := bytes.Index(data, []byte(needle))
+	if i < 0 {
+		return 0
+	}
+	return bytes.Count(data[:i], []byte("\n")) + 1
+}
+
+// frontmatterKeyRe matches a top-level YAML or TOML key at the start of a
+// line.
+var frontmatterKeyRe = regexp.MustCompile(`^([A-Za-z0-9_]+)\s*[:=]`)
+
+// frontmatterLine returns the 1-based line of the top-level frontmatter key
+// in the file at path, or 0 if the key is empty or cannot be found.
+func frontmatterLine(path, key string) int {
+	if key == "" {
+		return 0
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if line > 1 && (text == "---" || text == "+++") {
+			return 0 // end of frontmatter
+		}
+		if m := frontmatterKeyRe.FindStringSubmatch(text); m != nil && strings.EqualFold(m[1], key) {
+			return line
+		}
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package build
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFrontmatterLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "post.md")
+	src := "---\ntitle: Post\ncover:\n  image: a.png\nproject: nope\n---\nproject: in the body\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"title", 2},
+		{"cover", 3},
+		{"image", 0}, // nested keys are not top-level
+		{"project", 5},
+		{"layout", 0}, // body lines are not frontmatter
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := frontmatterLine(path, tt.key); got != tt.want {
+			t.Errorf("frontmatterLine(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	tests := []struct {
+		w    Warning
+		want string
+	}{
+		{Warning{Code: "c", Message: "m", File: "content/a.md", Line: 3}, "content/a.md:3: m [c]"},
+		{Warning{Code: "c", Message: "m", File: "content/a.md"}, "content/a.md: m [c]"},
+		{Warning{Code: "c", Message: "m"}, "m [c]"},
+	}
+	for _, tt := range tests {
+		if got := tt.w.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWarningCodes(t *testing.T) {
+	// Every Warn* constant in warnings.go must be described.
+	f, err := parser.ParseFile(token.NewFileSet(), "warnings.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	described := make(map[string]bool, len(WarningCodes))
+	for _, c := range WarningCodes {
+		if c.Meaning == "" {
+			t.Errorf("warning code %s has no meaning", c.Code)
+		}
+		described[c.Code] = true
+	}
+	for _, obj := range f.Scope.Objects {
+		if obj.Kind != ast.Con || !strings.HasPrefix(obj.Name, "Warn") {
+			continue
+		}
+		lit, ok := obj.Decl.(*ast.ValueSpec).Values[0].(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		code, _ := strconv.Unquote(lit.Value)
+		if !described[code] {
+			t.Errorf("%s (%q) is missing from WarningCodes", obj.Name, code)
+		}
+	}
+}
@@ -1,31 +1,23 @@
 package build
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 // WriteFile writes data to a file determined by the page URL.
 // For a URL like "/blog/my-post/", it writes to outputDir/blog/my-post/index.html.
 // For the root URL "/", it writes to outputDir/index.html.
+// URLs ending in ".html" (ugly URLs, e.g. "/blog/my-post.html") name the file
+// directly.
 func WriteFile(outputDir, url string, data []byte) error {
-	// Determine the file path from the URL.
-	relPath := strings.TrimPrefix(url, "/")
-	var filePath string
-	if relPath == "" {
-		// Root URL "/" -> index.html
-		filePath = filepath.Join(outputDir, "index.html")
-	} else if strings.HasSuffix(relPath, "/") {
-		// Directory URL like "blog/my-post/" -> blog/my-post/index.html
-		filePath = filepath.Join(outputDir, relPath, "index.html")
-	} else {
-		// URL without trailing slash like "blog/my-post" -> blog/my-post/index.html
-		filePath = filepath.Join(outputDir, relPath, "index.html")
-	}
+	filePath := filepath.Join(outputDir, urlToFilePath(url))
 
 	// Ensure the parent directory exists.
 	dir := filepath.Dir(filePath)
@@ -39,6 +31,23 @@ func WriteFile(outputDir, url string, data []byte) error {
 	return nil
 }
 
+// urlToFilePath returns the output file, relative to the output directory,
+// that serves url.
+func urlToFilePath(url string) string {
+	relPath := strings.TrimPrefix(url, "/")
+	switch {
+	case relPath == "":
+		// Root URL "/" -> index.html
+		return "index.html"
+	case strings.HasSuffix(relPath, ".html"):
+		// Ugly URL like "blog/my-post.html" is the file itself.
+		return filepath.FromSlash(relPath)
+	default:
+		// Directory URL like "blog/my-post/" or "blog/my-post" -> blog/my-post/index.html
+		return filepath.Join(filepath.FromSlash(relPath), "index.html")
+	}
+}
+
 // CopyDir recursively copies the contents of src into dst.
 // If dst does not exist, it is created. Existing files at dst are overwritten.
 func CopyDir(src, dst string) error {
@@ -111,6 +120,91 @@ func CleanDir(dir string) error {
 	return nil
 }
 
+// stagingDir returns the sibling of dir that a full build is written to
+// before it replaces dir, e.g. "site/.public.staging" for "site/public".
+func stagingDir(dir string) string {
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+".staging")
+}
+
+// makeStagingDir creates an empty staging directory for a full build of dir:
+// stagingDir(dir), or a temporary directory if the parent of dir is not
+// writable, e.g. when dir is a volume mounted into a read-only tree.
+// ReplaceDir then copies it into place instead of renaming it.
+func makeStagingDir(dir string) (string, error) {
+	staging := stagingDir(dir)
+	err := CleanDir(staging)
+	switch {
+	case err == nil:
+		return staging, nil
+	case errors.Is(err, fs.ErrPermission):
+		return os.MkdirTemp("", "forge-staging-")
+	default:
+		return "", err
+	}
+}
+
+// ReplaceDir moves the directory src into place at dst, replacing any
+// existing dst. The old dst is renamed aside first and removed only once
+// src is in place, so dst is missing for no longer than the gap between two
+// renames and is restored if the second rename fails.
+//
+// When dst cannot be renamed, because it is a mount point, lies on another
+// filesystem than src, or its parent is not writable, ReplaceDir instead
+// empties dst and copies src into it. If the old dst cannot be removed once
+// src is in place, the build still succeeded: that is returned as a warning.
+func ReplaceDir(src, dst string) ([]Warning, error) {
+	old := filepath.Join(filepath.Dir(dst), "."+filepath.Base(dst)+".old")
+	if err := os.RemoveAll(old); err != nil {
+		return nil, fmt.Errorf("removing %s: %w", old, err)
+	}
+	if err := os.Rename(dst, old); err != nil && !os.IsNotExist(err) {
+		if renameUnsupported(err) {
+			return nil, copyIntoDir(src, dst)
+		}
+		return nil, fmt.Errorf("moving %s aside: %w", dst, err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		_ = os.Rename(old, dst) // put the previous output back
+		if renameUnsupported(err) {
+			return nil, copyIntoDir(src, dst)
+		}
+		return nil, fmt.Errorf("moving %s to %s: %w", src, dst, err)
+	}
+	if err := os.RemoveAll(old); err != nil {
+		return []Warning{{
+			Code:    WarnLeftoverOutput,
+			Message: fmt.Sprintf("previous output left at %s: %v", old, err),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// renameUnsupported reports whether err, from renaming a directory, means
+// the directory cannot be moved at all (it is a mount point, the rename
+// crosses filesystems, or its parent is read-only) rather than that
+// something went wrong.
+func renameUnsupported(err error) bool {
+	return errors.Is(err, syscall.EXDEV) || errors.Is(err, syscall.EBUSY) || errors.Is(err, fs.ErrPermission)
+}
+
+// copyIntoDir replaces the contents of dst with those of src, keeping dst
+// itself, which may be a mount point.
+func copyIntoDir(src, dst string) error {
+	entries, err := os.ReadDir(dst)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", dst, err)
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dst, e.Name())); err != nil {
+			return fmt.Errorf("removing %s: %w", filepath.Join(dst, e.Name()), err)
+		}
+	}
+	if err := CopyDir(src, dst); err != nil {
+		return fmt.Errorf("copying %s to %s: %w", src, dst, err)
+	}
+	return nil
+}
+
 // DirSize calculates the total size in bytes of all files in dir, recursively.
 // If dir does not exist, it returns 0.
 func DirSize(dir string) (int64, error) {
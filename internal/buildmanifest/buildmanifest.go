@@ -0,0 +1,193 @@
+// Package buildmanifest describes the build manifest: the list of files a
+// build wrote to the output directory, with their hashes, content types, and
+// producers. The build writes it; deploys read it to skip rehashing.
+package buildmanifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Path is the manifest of the output directory written by the last build,
+// relative to the project root.
+const Path = ".forge/build-manifest.json"
+
+// Producer kinds identify what wrote an output file.
+const (
+	ProducerPage      = "page"      // a rendered page
+	ProducerAlias     = "alias"     // a redirect page for a page alias
+	ProducerStatic    = "static"    // a file copied from a static directory
+	ProducerBundle    = "bundle"    // a page bundle resource
+	ProducerImage     = "image"     // a responsive image variant
+	ProducerGenerator = "generator" // an ancillary file such as the sitemap or a feed
+)
+
+// Producer describes what wrote an output file.
+type Producer struct {
+	Kind      string `json:"kind"`
+	Source    string `json:"source,omitempty"`    // relative to the project root, e.g. "content/blog/post.md"
+	Template  string `json:"template,omitempty"`  // template that rendered a page
+	Generator string `json:"generator,omitempty"` // e.g. "sitemap", "rss", "tailwind"
+}
+
+func (p Producer) String() string {
+	var sb strings.Builder
+	switch p.Kind {
+	case "":
+		return "unknown"
+	case ProducerImage:
+		sb.WriteString("image variant")
+	default:
+		sb.WriteString(p.Kind)
+	}
+	if p.Generator != "" {
+		sb.WriteString(" " + p.Generator)
+	}
+	if p.Source != "" {
+		if p.Kind == ProducerImage {
+			sb.WriteString(" of")
+		}
+		sb.WriteString(" " + p.Source)
+	}
+	if p.Template != "" {
+		sb.WriteString(" via " + p.Template)
+	}
+	return sb.String()
+}
+
+// File is one output file in the build manifest.
+type File struct {
+	Path        string    `json:"path"` // relative to the output directory, e.g. "blog/post/index.html"
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	ContentType string    `json:"contentType"`
+	ModTime     time.Time `json:"modTime"`
+	Producer    Producer  `json:"producer"`
+}
+
+// Manifest lists every file in the output directory, with its hash and the
+// producer that wrote it.
+type Manifest struct {
+	OutputDir string `json:"outputDir"` // relative to the project root, e.g. "public"
+	Files     []File `json:"files"`     // sorted by path
+}
+
+// Lookup returns the entry for the output file path, if the file on disk,
+// whose hex-encoded SHA-256 hash is sum, still holds what the manifest
+// records. A copy of the output that kept its bytes but not its
+// modification times is still matched.
+func (m *Manifest) Lookup(path, sum string) (File, bool) {
+	f, ok := m.entry(path)
+	if !ok || f.SHA256 != sum {
+		return File{}, false
+	}
+	return f, true
+}
+
+// Unchanged returns the entry for the output file path if the file on disk
+// still has the size and modification time it had when the manifest was
+// written. That is cheaper than hashing the file but only to be trusted by
+// the build that wrote both.
+func (m *Manifest) Unchanged(path string, info fs.FileInfo) (File, bool) {
+	f, ok := m.entry(path)
+	if !ok || f.Size != info.Size() || !f.ModTime.Equal(info.ModTime()) {
+		return File{}, false
+	}
+	return f, true
+}
+
+// entry returns the entry for the output file path.
+func (m *Manifest) entry(path string) (File, bool) {
+	if m == nil {
+		return File{}, false
+	}
+	i := sort.Search(len(m.Files), func(i int) bool { return m.Files[i].Path >= path })
+	if i == len(m.Files) || m.Files[i].Path != path {
+		return File{}, false
+	}
+	return m.Files[i], true
+}
+
+// Read reads the build manifest of the project at projectRoot. The error
+// wraps fs.ErrNotExist if the project has not been built.
+func Read(projectRoot string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(projectRoot, filepath.FromSlash(Path)))
+	if err != nil {
+		return nil, fmt.Errorf("reading build manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing build manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// ContentTypeForExt returns the MIME type for a file extension.
+// The ext parameter should include the leading dot (e.g. ".html").
+func ContentTypeForExt(ext string) string {
+	ext = strings.ToLower(ext)
+
+	// Well-known types that we want to be explicit about
+	switch ext {
+	case ".html", ".htm":
+		return "text/html; charset=utf-8"
+	case ".css":
+		return "text/css; charset=utf-8"
+	case ".js", ".mjs":
+		return "application/javascript; charset=utf-8"
+	case ".json":
+		return "application/json; charset=utf-8"
+	case ".xml":
+		return "application/xml; charset=utf-8"
+	case ".svg":
+		return "image/svg+xml"
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	case ".avif":
+		return "image/avif"
+	case ".ico":
+		return "image/x-icon"
+	case ".woff":
+		return "font/woff"
+	case ".woff2":
+		return "font/woff2"
+	case ".ttf":
+		return "font/ttf"
+	case ".otf":
+		return "font/otf"
+	case ".pdf":
+		return "application/pdf"
+	case ".txt":
+		return "text/plain; charset=utf-8"
+	case ".csv":
+		return "text/csv; charset=utf-8"
+	case ".mp4":
+		return "video/mp4"
+	case ".webm":
+		return "video/webm"
+	case ".mp3":
+		return "audio/mpeg"
+	case ".wasm":
+		return "application/wasm"
+	}
+
+	// Fall back to the standard library
+	ct := mime.TypeByExtension(ext)
+	if ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
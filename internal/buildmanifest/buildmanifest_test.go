@@ -0,0 +1,87 @@
+package buildmanifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProducerString(t *testing.T) {
+	tests := []struct {
+		p    Producer
+		want string
+	}{
+		{Producer{}, "unknown"},
+		{Producer{Kind: ProducerPage, Source: "content/blog/a.md", Template: "_default/single.html"}, "page content/blog/a.md via _default/single.html"},
+		{Producer{Kind: ProducerPage, Template: "404.html"}, "page via 404.html"},
+		{Producer{Kind: ProducerStatic, Source: "static/img/a.png"}, "static static/img/a.png"},
+		{Producer{Kind: ProducerImage, Source: "static/img/a.png"}, "image variant of static/img/a.png"},
+		{Producer{Kind: ProducerGenerator, Generator: "sitemap"}, "generator sitemap"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestManifestLookup(t *testing.T) {
+	m := &Manifest{Files: []File{
+		{Path: "a.css", Size: 1, SHA256: "def"},
+		{Path: "index.html", Size: 9, SHA256: "abc"},
+	}}
+
+	if f, ok := m.Lookup("index.html", "abc"); !ok || f.SHA256 != "abc" {
+		t.Errorf("Lookup(index.html) = %+v, %v; want the recorded entry", f, ok)
+	}
+	if _, ok := m.Lookup("index.html", "def"); ok {
+		t.Error("Lookup(index.html) matched an entry with different content")
+	}
+	if _, ok := m.Lookup("missing.html", "abc"); ok {
+		t.Error("Lookup(missing.html) found an entry")
+	}
+
+	var nilManifest *Manifest
+	if _, ok := nilManifest.Lookup("index.html", "abc"); ok {
+		t.Error("nil manifest found an entry")
+	}
+}
+
+func TestManifestUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(path, []byte("<p>hi</p>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{Files: []File{
+		{Path: "a.css", Size: 1},
+		{Path: "index.html", Size: info.Size(), ModTime: info.ModTime(), SHA256: "abc"},
+	}}
+
+	if f, ok := m.Unchanged("index.html", info); !ok || f.SHA256 != "abc" {
+		t.Errorf("Unchanged(index.html) = %+v, %v; want the recorded entry", f, ok)
+	}
+	if _, ok := m.Unchanged("missing.html", info); ok {
+		t.Error("Unchanged(missing.html) found an entry")
+	}
+
+	// A file touched since the manifest was written is not trusted.
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = os.Stat(path)
+	if _, ok := m.Unchanged("index.html", info); ok {
+		t.Error("Unchanged(index.html) trusted an entry with a stale modification time")
+	}
+
+	var nilManifest *Manifest
+	if _, ok := nilManifest.Unchanged("index.html", info); ok {
+		t.Error("nil manifest found an entry")
+	}
+}
@@ -4,7 +4,10 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -21,6 +24,7 @@ type SiteConfig struct {
 	Menu        MenuConfig        `yaml:"menu"        mapstructure:"menu"`
 	Pagination  PaginationConfig  `yaml:"pagination"  mapstructure:"pagination"`
 	Taxonomies  map[string]string `yaml:"taxonomies"  mapstructure:"taxonomies"`
+	Permalinks  map[string]string `yaml:"permalinks"  mapstructure:"permalinks"`
 	Highlight   HighlightConfig   `yaml:"highlight"   mapstructure:"highlight"`
 	Search      SearchConfig      `yaml:"search"      mapstructure:"search"`
 	Feeds       FeedsConfig       `yaml:"feeds"       mapstructure:"feeds"`
@@ -33,6 +37,18 @@ type SiteConfig struct {
 	Params      map[string]any    `yaml:"params"      mapstructure:"params"`
 }
 
+// PermalinkTokens are the placeholders a permalinks pattern may use, e.g.
+// "/:year/:month/:slug/". Permalinks maps a section (e.g. "blog" or
+// "docs/guides") or a taxonomy (e.g. "tags") to the pattern for the URLs of
+// its pages or terms.
+var PermalinkTokens = []string{
+	"year", "month", "day", "section", "sections", "slug", "title", "filename", "contentbasename",
+}
+
+// PermalinkTokenRe matches a placeholder in a permalinks pattern, e.g.
+// ":year", capturing its name.
+var PermalinkTokenRe = regexp.MustCompile(`:([a-z]+)`)
+
 // AuthorConfig holds information about the site author.
 type AuthorConfig struct {
 	Name   string       `yaml:"name"   mapstructure:"name"`
@@ -117,6 +133,20 @@ type ServerConfig struct {
 type BuildConfig struct {
 	Minify    bool `yaml:"minify"    mapstructure:"minify"`
 	CleanURLs bool `yaml:"cleanUrls" mapstructure:"cleanUrls"`
+
+	// RelativeURLs rewrites root-relative links in rendered HTML to paths
+	// relative to each page, for browsing the output from disk.
+	RelativeURLs bool `yaml:"relativeURLs" mapstructure:"relativeURLs"`
+
+	// Fingerprint lists output paths (path.Match patterns relative to the
+	// output directory) renamed to name.<hash>.ext. It is empty by default,
+	// so layouts that link assets by their plain names keep working; new
+	// sites turn it on for css/*.css and js/*.js.
+	Fingerprint []string `yaml:"fingerprint" mapstructure:"fingerprint"`
+
+	// CheckLinks verifies every internal link in the output after a build
+	// and reports broken ones as warnings.
+	CheckLinks bool `yaml:"checkLinks" mapstructure:"checkLinks"`
 }
 
 // DeployConfig holds deployment target configuration.
@@ -212,7 +242,9 @@ func Default() *SiteConfig {
 			Host:       "localhost",
 			LiveReload: true,
 		},
-		Build: BuildConfig{},
+		Build: BuildConfig{
+			CleanURLs: true,
+		},
 		Images: ImageConfig{
 			Enabled: true,
 			Quality: 70,
@@ -270,6 +302,7 @@ func Load(configPath string) (*SiteConfig, error) {
 // It returns a descriptive error if:
 //   - Title is empty
 //   - BaseURL has a trailing slash
+//   - A permalinks pattern is not root-relative or uses an unknown token
 func (c *SiteConfig) Validate() error {
 	if strings.TrimSpace(c.Title) == "" {
 		return fmt.Errorf("config: title is required")
@@ -279,6 +312,18 @@ func (c *SiteConfig) Validate() error {
 		return fmt.Errorf("config: baseURL must not have a trailing slash (got %q)", c.BaseURL)
 	}
 
+	for _, key := range slices.Sorted(maps.Keys(c.Permalinks)) {
+		pattern := c.Permalinks[key]
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("config: permalinks.%s must start with \"/\" (got %q)", key, pattern)
+		}
+		for _, m := range PermalinkTokenRe.FindAllStringSubmatch(pattern, -1) {
+			if !slices.Contains(PermalinkTokens, m[1]) {
+				return fmt.Errorf("config: permalinks.%s: unknown token %q (valid: :%s)", key, m[0], strings.Join(PermalinkTokens, ", :"))
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -320,6 +365,10 @@ func (c *SiteConfig) WithOverrides(overrides map[string]any) *SiteConfig {
 			if b, ok := val.(bool); ok {
 				c.Build.CleanURLs = b
 			}
+		case "relativeURLs":
+			if b, ok := val.(bool); ok {
+				c.Build.RelativeURLs = b
+			}
 		case "livereload":
 			if b, ok := val.(bool); ok {
 				c.Server.LiveReload = b
@@ -3,6 +3,7 @@ package config
 import (
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -373,6 +374,34 @@ func TestValidate(t *testing.T) {
 			t.Errorf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("valid permalinks", func(t *testing.T) {
+		cfg := Default()
+		cfg.Title = "Test"
+		cfg.Permalinks = map[string]string{"blog": "/:year/:month/:slug/", "tags": "/topics/:slug/"}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("relative permalink", func(t *testing.T) {
+		cfg := Default()
+		cfg.Title = "Test"
+		cfg.Permalinks = map[string]string{"blog": ":year/:slug/"}
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error for relative permalink, got nil")
+		}
+	})
+
+	t.Run("unknown permalink token", func(t *testing.T) {
+		cfg := Default()
+		cfg.Title = "Test"
+		cfg.Permalinks = map[string]string{"blog": "/:year/:author/:slug/"}
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), `":author"`) {
+			t.Errorf("expected error naming :author, got %v", err)
+		}
+	})
 }
 
 // ---------------------------------------------------------------------------
@@ -0,0 +1,223 @@
+package content
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"strings"
+)
+
+// Cascade is one block of a section's cascade frontmatter: values that every
+// page below the section inherits unless it sets them itself.
+type Cascade struct {
+	// Target restricts the block to pages whose content path matches this
+	// glob, e.g. "tutorials/advanced/**". Segments match as in path.Match,
+	// and "**" matches any number of directories. Empty matches every page
+	// below the section.
+	Target string
+
+	// Values holds the frontmatter keys and values to inherit.
+	Values map[string]any
+}
+
+// uncascadable lists the frontmatter keys that identify a single page and so
+// cannot be inherited.
+var uncascadable = map[string]bool{
+	"title":   true,
+	"slug":    true,
+	"url":     true,
+	"aliases": true,
+	"cascade": true,
+}
+
+// cascadeAliases maps frontmatter keys to another spelling of the same
+// setting; a page that sets one does not inherit the other.
+var cascadeAliases = map[string]string{
+	"unpublishDate": "expiryDate",
+	"expiryDate":    "unpublishDate",
+}
+
+// parseCascade parses the cascade frontmatter block: a map, or a list of
+// maps, of frontmatter values with an optional _target path glob, given as a
+// string or as {path: glob}.
+func parseCascade(v any) ([]Cascade, error) {
+	var blocks []map[string]any
+	switch val := v.(type) {
+	case map[string]any:
+		blocks = append(blocks, val)
+	case []map[string]any:
+		blocks = val
+	case []any:
+		for _, item := range val {
+			m, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected map, got %T", item)
+			}
+			blocks = append(blocks, m)
+		}
+	default:
+		return nil, fmt.Errorf("expected map or list of maps, got %T", v)
+	}
+
+	cascades := make([]Cascade, 0, len(blocks))
+	for _, block := range blocks {
+		c := Cascade{Values: make(map[string]any, len(block))}
+		for key, val := range block {
+			switch {
+			case key == "_target":
+				target, err := parseCascadeTarget(val)
+				if err != nil {
+					return nil, err
+				}
+				c.Target = target
+			case uncascadable[key]:
+				return nil, fmt.Errorf("%q cannot be cascaded", key)
+			default:
+				c.Values[key] = val
+			}
+		}
+		cascades = append(cascades, c)
+	}
+	return cascades, nil
+}
+
+// parseCascadeTarget parses a cascade block's _target and checks its glob.
+func parseCascadeTarget(v any) (string, error) {
+	target, ok := v.(string)
+	if m, isMap := v.(map[string]any); isMap {
+		target, ok = m["path"].(string)
+	}
+	if !ok {
+		return "", fmt.Errorf("_target must be a path glob, got %v", v)
+	}
+	target = strings.Trim(target, "/")
+	if _, err := path.Match(target, ""); err != nil {
+		return "", fmt.Errorf("_target %q: %w", target, err)
+	}
+	return target, nil
+}
+
+// matchTarget reports whether the slash-separated content path p matches
+// the cascade target glob.
+func matchTarget(target, p string) bool {
+	return matchSegments(strings.Split(target, "/"), strings.Split(p, "/"))
+}
+
+// matchSegments matches path segments against glob segments, where "**"
+// matches zero or more segments.
+func matchSegments(glob, segs []string) bool {
+	if len(glob) == 0 {
+		return len(segs) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(glob[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], segs[0]); !ok {
+		return false
+	}
+	return matchSegments(glob[1:], segs[1:])
+}
+
+// applyCascades gives every page the cascade values of the sections above
+// it, then repopulates it from its own frontmatter merged with them. Values
+// the page sets itself win, then those of nearer sections, then earlier
+// blocks of the same section. Params merge key by key. Each page records
+// where its inherited values came from in Cascaded. metadata holds each
+// page's own frontmatter, nil for a file without any, which inherits too
+// but keeps its empty title. It returns the pages whose merged frontmatter
+// is invalid.
+func applyCascades(pages []*Page, metadata map[*Page]map[string]any) map[*Page]error {
+	sections := make(map[string]*Page)
+	for _, p := range pages {
+		if len(p.Cascade) > 0 && (p.Type == PageTypeHome || p.Type == PageTypeList) {
+			sections[p.SourceDir] = p
+		}
+	}
+	if len(sections) == 0 {
+		return nil
+	}
+
+	errs := make(map[*Page]error)
+	for _, p := range pages {
+		if p.Type == PageTypeHome {
+			continue
+		}
+		own := metadata[p]
+		populate := PopulatePage
+		if own == nil {
+			own = map[string]any{}
+			populate = populateFields // there is no title to require
+		}
+
+		merged := maps.Clone(own)
+		params, _ := own["params"].(map[string]any)
+		params = maps.Clone(params)
+		sources := make(map[string]string)
+
+		// Walk up from the page's directory; a section's own _index.md
+		// starts at the section above.
+		dir := p.SourceDir
+		if p.Type == PageTypeList {
+			dir = parentDir(dir)
+		}
+		for {
+			if sec := sections[dir]; sec != nil {
+				for _, c := range sec.Cascade {
+					if c.Target != "" && !matchTarget(c.Target, p.SourcePath) {
+						continue
+					}
+					for key, val := range c.Values {
+						if key == "params" {
+							inherited, _ := val.(map[string]any)
+							for name, pv := range inherited {
+								if _, ok := params[name]; !ok {
+									if params == nil {
+										params = make(map[string]any)
+									}
+									params[name] = pv
+									sources["params."+name] = sec.SourcePath
+								}
+							}
+							continue
+						}
+						if _, ok := merged[key]; ok {
+							continue
+						}
+						if alias, ok := cascadeAliases[key]; ok {
+							if _, ok := merged[alias]; ok {
+								continue
+							}
+						}
+						merged[key] = val
+						sources[key] = sec.SourcePath
+					}
+				}
+			}
+			if dir == "" {
+				break
+			}
+			dir = parentDir(dir)
+		}
+
+		if len(sources) == 0 {
+			continue
+		}
+		if params != nil {
+			merged["params"] = params
+		}
+		if err := populate(p, merged); err != nil {
+			errs[p] = fmt.Errorf("applying cascade: %w", err)
+			continue
+		}
+		p.Cascaded = sources
+	}
+	return errs
+}
@@ -0,0 +1,192 @@
+package content
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/aellingwood/forge/internal/config"
+)
+
+func TestDiscoverCascade(t *testing.T) {
+	contentDir := t.TempDir()
+	files := map[string]string{
+		"_index.md": "---\ntitle: Home\ncascade:\n  params:\n    theme: light\n---\n",
+		"tutorials/_index.md": `---
+title: Tutorials
+cascade:
+  - layout: tutorial
+    author: Ada
+    tags: [learn]
+    params:
+      level: beginner
+  - _target: "tutorials/advanced/**"
+    draft: true
+    params:
+      level: expert
+---
+`,
+		"tutorials/basics.md":                "---\ntitle: Basics\n---\n",
+		"tutorials/custom.md":                "---\ntitle: Custom\nlayout: wide\nparams:\n  level: mixed\n---\n",
+		"tutorials/advanced/_index.md":       "---\ntitle: Advanced\ncascade:\n  author: Grace\n---\n",
+		"tutorials/advanced/deep.md":         "---\ntitle: Deep\n---\n",
+		"tutorials/advanced/bundle/index.md": "---\ntitle: Bundle\ndraft: false\n---\n",
+		"blog/post.md":                       "---\ntitle: Post\n---\n",
+		"tutorials/bare.md":                  "No frontmatter.\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(contentDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := Discover(contentDir, config.Default())
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	page := func(title string) *Page {
+		t.Helper()
+		p := findPageByTitle(pages, title)
+		if p == nil {
+			t.Fatalf("page %q not found", title)
+		}
+		return p
+	}
+
+	basics := page("Basics")
+	if basics.Layout != "tutorial" || basics.Author != "Ada" || basics.Draft ||
+		len(basics.Tags) != 1 || basics.Tags[0] != "learn" {
+		t.Errorf("Basics: layout %q, author %q, draft %v, tags %v", basics.Layout, basics.Author, basics.Draft, basics.Tags)
+	}
+	if basics.Params["level"] != "beginner" || basics.Params["theme"] != "light" {
+		t.Errorf("Basics params = %v", basics.Params)
+	}
+	want := map[string]string{
+		"layout":       "tutorials/_index.md",
+		"author":       "tutorials/_index.md",
+		"tags":         "tutorials/_index.md",
+		"params.level": "tutorials/_index.md",
+		"params.theme": "_index.md",
+	}
+	if !maps.Equal(basics.Cascaded, want) {
+		t.Errorf("Basics Cascaded = %v, want %v", basics.Cascaded, want)
+	}
+
+	// Values the page sets itself win.
+	custom := page("Custom")
+	if custom.Layout != "wide" || custom.Params["level"] != "mixed" || custom.Params["theme"] != "light" {
+		t.Errorf("Custom: layout %q, params %v", custom.Layout, custom.Params)
+	}
+	if _, ok := custom.Cascaded["layout"]; ok {
+		t.Errorf("Custom Cascaded = %v, should not include layout", custom.Cascaded)
+	}
+
+	// Nearer sections win over farther ones; targeted blocks apply only to
+	// matching pages, and earlier blocks win over later ones.
+	deep := page("Deep")
+	if deep.Author != "Grace" || !deep.Draft || deep.Params["level"] != "beginner" {
+		t.Errorf("Deep: author %q, draft %v, params %v", deep.Author, deep.Draft, deep.Params)
+	}
+	if deep.Cascaded["author"] != "tutorials/advanced/_index.md" {
+		t.Errorf("Deep author source = %q", deep.Cascaded["author"])
+	}
+	if bundle := page("Bundle"); bundle.Draft || bundle.Author != "Grace" {
+		t.Errorf("Bundle: draft %v, author %q", bundle.Draft, bundle.Author)
+	}
+	// The target glob covers the subsection's own _index.md.
+	if advanced := page("Advanced"); advanced.Layout != "tutorial" || !advanced.Draft {
+		t.Errorf("Advanced: layout %q, draft %v", advanced.Layout, advanced.Draft)
+	}
+
+	// A section does not inherit its own cascade.
+	if tutorials := page("Tutorials"); tutorials.Layout != "" || tutorials.Params["theme"] != "light" {
+		t.Errorf("Tutorials: layout %q, params %v", tutorials.Layout, tutorials.Params)
+	}
+	if post := page("Post"); post.Layout != "" || post.Cascaded["params.theme"] != "_index.md" {
+		t.Errorf("Post: layout %q, cascaded %v", post.Layout, post.Cascaded)
+	}
+
+	// A page without frontmatter inherits too, and keeps its empty title.
+	bare := slices.IndexFunc(pages, func(p *Page) bool { return p.SourcePath == "tutorials/bare.md" })
+	if bare < 0 {
+		t.Fatal("page without frontmatter not discovered")
+	}
+	if p := pages[bare]; p.Title != "" || p.Layout != "tutorial" || p.Author != "Ada" ||
+		p.Params["level"] != "beginner" || p.Cascaded["layout"] != "tutorials/_index.md" {
+		t.Errorf("bare page: title %q, layout %q, author %q, params %v, cascaded %v",
+			p.Title, p.Layout, p.Author, p.Params, p.Cascaded)
+	}
+}
+
+func TestDiscoverCascade_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		bad   string
+	}{
+		{
+			name:  "uncascadable key",
+			files: map[string]string{"docs/_index.md": "---\ntitle: Docs\ncascade:\n  slug: same\n---\n"},
+			bad:   "docs/_index.md",
+		},
+		{
+			name:  "bad target",
+			files: map[string]string{"docs/_index.md": "---\ntitle: Docs\ncascade:\n  _target: \"docs/[\"\n---\n"},
+			bad:   "docs/_index.md",
+		},
+		{
+			name: "invalid inherited value",
+			files: map[string]string{
+				"docs/_index.md": "---\ntitle: Docs\ncascade:\n  weight: heavy\n---\n",
+				"docs/page.md":   "---\ntitle: Page\n---\n",
+			},
+			bad: "docs/page.md",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentDir := t.TempDir()
+			for name, data := range tt.files {
+				path := filepath.Join(contentDir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := Discover(contentDir, config.Default())
+			var fileErr *FileError
+			if !errors.As(err, &fileErr) || fileErr.Path != tt.bad {
+				t.Errorf("Discover() error = %v, want a FileError for %s", err, tt.bad)
+			}
+		})
+	}
+}
+
+func TestMatchTarget(t *testing.T) {
+	tests := []struct {
+		target, path string
+		want         bool
+	}{
+		{"tutorials/advanced/**", "tutorials/advanced/deep.md", true},
+		{"tutorials/advanced/**", "tutorials/advanced/a/b/index.md", true},
+		{"tutorials/advanced/**", "tutorials/basics.md", false},
+		{"tutorials/*.md", "tutorials/basics.md", true},
+		{"tutorials/*.md", "tutorials/advanced/deep.md", false},
+		{"**/index.md", "tutorials/advanced/bundle/index.md", true},
+		{"**/index.md", "tutorials/basics.md", false},
+	}
+	for _, tt := range tests {
+		if got := matchTarget(tt.target, tt.path); got != tt.want {
+			t.Errorf("matchTarget(%q, %q) = %v, want %v", tt.target, tt.path, got, tt.want)
+		}
+	}
+}
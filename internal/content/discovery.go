@@ -1,6 +1,8 @@
 package content
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -20,15 +22,51 @@ var slugifyRe = regexp.MustCompile(`[^a-z0-9\-.]`)
 // multiHyphenRe collapses multiple consecutive hyphens into one.
 var multiHyphenRe = regexp.MustCompile(`-{2,}`)
 
+// FileError reports a content file that could not be read or parsed.
+type FileError struct {
+	Path string // relative to the content directory, e.g. "blog/my-post.md"
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
 // Discover walks the content directory and builds a slice of Page objects.
 // It reads each .md file, parses front matter, determines page type, section,
-// slug, URL, and collects bundle files. It does NOT render markdown or filter
-// drafts/future/expired pages.
+// slug, URL, and collects bundle files, then applies the cascade front
+// matter of sections to the pages below them. It does NOT render markdown
+// or filter drafts/future/expired pages. It stops at the first file that
+// cannot be read or parsed, returning a *FileError.
 func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
+	pages, _, err := discover(contentDir, cfg, false)
+	return pages, err
+}
+
+// DiscoverAll is like Discover but skips content files that cannot be read
+// or parsed instead of stopping at the first, returning one FileError per
+// skipped file alongside the pages that were discovered. The error is
+// non-nil only when the content directory itself cannot be walked.
+func DiscoverAll(contentDir string, cfg *config.SiteConfig) ([]*Page, []*FileError, error) {
+	return discover(contentDir, cfg, true)
+}
+
+// discover implements Discover and DiscoverAll. With keepGoing, per-file
+// errors are collected rather than returned.
+func discover(contentDir string, cfg *config.SiteConfig, keepGoing bool) ([]*Page, []*FileError, error) {
 	var pages []*Page
+	var fileErrs []*FileError
+	metadataOf := make(map[*Page]map[string]any)
 
-	// First pass: collect all index.md directories to identify page bundles.
+	// First pass: collect all index.md directories to identify page bundles,
+	// and all _index.md directories (relative, slash-separated) to identify
+	// sections.
 	bundleDirs := make(map[string]bool)
+	sectionDirs := make(map[string]bool)
 	err := filepath.WalkDir(contentDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -36,13 +74,18 @@ func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
 		if d.IsDir() {
 			return nil
 		}
-		if filepath.Base(path) == "index.md" {
+		switch filepath.Base(path) {
+		case "index.md":
 			bundleDirs[filepath.Dir(path)] = true
+		case "_index.md":
+			if rel, err := filepath.Rel(contentDir, filepath.Dir(path)); err == nil && rel != "." {
+				sectionDirs[filepath.ToSlash(rel)] = true
+			}
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("scanning for page bundles: %w", err)
+		return nil, nil, fmt.Errorf("scanning for page bundles: %w", err)
 	}
 
 	// Second pass: discover pages.
@@ -65,30 +108,41 @@ func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
 			return nil
 		}
 
+		// Set source path relative to contentDir.
+		relPath, err := filepath.Rel(contentDir, path)
+		if err != nil {
+			return fmt.Errorf("computing relative path for %s: %w", path, err)
+		}
+
+		// fail reports a problem with this file, skipping it with keepGoing.
+		fail := func(err error) error {
+			fileErr := &FileError{Path: filepath.ToSlash(relPath), Err: err}
+			if keepGoing {
+				fileErrs = append(fileErrs, fileErr)
+				return nil
+			}
+			return fileErr
+		}
+
 		raw, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("reading %s: %w", path, err)
+			return fail(err)
 		}
 
 		metadata, body, err := ParseFrontmatter(raw)
 		if err != nil {
-			return fmt.Errorf("parsing frontmatter in %s: %w", path, err)
+			return fail(fmt.Errorf("parsing frontmatter: %w", err))
 		}
 
 		page := &Page{}
 		if metadata != nil {
 			if err := PopulatePage(page, metadata); err != nil {
-				return fmt.Errorf("populating page from %s: %w", path, err)
+				return fail(fmt.Errorf("populating page: %w", err))
 			}
 		}
 
 		page.RawContent = string(body)
-
-		// Set source path relative to contentDir.
-		relPath, err := filepath.Rel(contentDir, path)
-		if err != nil {
-			return fmt.Errorf("computing relative path for %s: %w", path, err)
-		}
+		page.ContentLine = bytes.Count(raw[:len(raw)-len(body)], []byte("\n")) + 1
 		page.SourcePath = filepath.ToSlash(relPath)
 		page.SourceDir = filepath.ToSlash(filepath.Dir(relPath))
 		if page.SourceDir == "." {
@@ -102,6 +156,18 @@ func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
 		filename := filepath.Base(path)
 		isBundle := bundleDirs[dir]
 
+		// Determine the nested section: a section's own directory, or the
+		// nearest directory above the page (or its bundle) that has an
+		// _index.md.
+		switch {
+		case filename == "_index.md":
+			page.SectionPath = page.SourceDir
+		case isBundle:
+			page.SectionPath = sectionPath(parentDir(page.SourceDir), sectionDirs)
+		default:
+			page.SectionPath = sectionPath(page.SourceDir, sectionDirs)
+		}
+
 		switch {
 		case filename == "_index.md" && page.SourceDir == "":
 			// Root _index.md -> Home page.
@@ -132,9 +198,6 @@ func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
 			page.Slug = slugify(name)
 		}
 
-		// Generate URL.
-		page.URL = buildURL(page)
-
 		// Calculate word count and reading time.
 		page.WordCount = countWords(page.RawContent)
 		if page.WordCount > 0 {
@@ -142,13 +205,52 @@ func Discover(contentDir string, cfg *config.SiteConfig) ([]*Page, error) {
 		}
 
 		pages = append(pages, page)
+		metadataOf[page] = metadata
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("walking content directory: %w", err)
+		var fileErr *FileError
+		if errors.As(err, &fileErr) {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("walking content directory: %w", err)
+	}
+
+	// Apply cascade frontmatter from sections to the pages below them.
+	if errs := applyCascades(pages, metadataOf); len(errs) > 0 {
+		kept := pages[:0]
+		for _, p := range pages {
+			err, ok := errs[p]
+			if !ok {
+				kept = append(kept, p)
+				continue
+			}
+			fileErr := &FileError{Path: p.SourcePath, Err: err}
+			if !keepGoing {
+				return nil, nil, fileErr
+			}
+			fileErrs = append(fileErrs, fileErr)
+		}
+		pages = kept
+	}
+
+	// Generate URLs once cascaded values such as dates are in place. A url
+	// in frontmatter is used as is.
+	var permalinks map[string]string
+	if cfg != nil {
+		permalinks = cfg.Permalinks
+	}
+	for _, p := range pages {
+		if p.URL == "" {
+			p.URL = PageURL(p, permalinks)
+		}
+	}
+
+	if cfg != nil && !cfg.Build.CleanURLs {
+		UseUglyURLs(pages)
 	}
 
-	return pages, nil
+	return pages, fileErrs, nil
 }
 
 // slugify converts a name into a URL-safe slug.
@@ -177,18 +279,45 @@ func firstPathComponent(relPath string) string {
 	return parts[0]
 }
 
-// buildURL generates the relative URL for a page based on its type, section, and slug.
-func buildURL(p *Page) string {
+// sectionPath returns the section that a page in the slash-separated
+// directory dir belongs to: the nearest of dir and its ancestors that has an
+// _index.md, or else the top-level directory, which is always a section.
+func sectionPath(dir string, sectionDirs map[string]bool) string {
+	for d := dir; d != ""; d = parentDir(d) {
+		if sectionDirs[d] {
+			return d
+		}
+	}
+	return firstPathComponent(dir + "/")
+}
+
+// parentDir returns the parent of a slash-separated directory relative to
+// the content directory, or "" for a top-level directory.
+func parentDir(dir string) string {
+	i := strings.LastIndex(dir, "/")
+	if i < 0 {
+		return ""
+	}
+	return dir[:i]
+}
+
+// PageURL generates the relative URL for a page based on its type, section,
+// and slug, or for a single page in a section with a permalinks pattern, on
+// that pattern.
+func PageURL(p *Page, permalinks map[string]string) string {
 	switch p.Type {
 	case PageTypeHome:
 		return "/"
 	case PageTypeList:
-		return "/" + p.Section + "/"
+		return "/" + p.CurrentSection() + "/"
 	case PageTypeSingle:
-		if p.Section == "" {
+		if pattern, ok := permalinkPattern(permalinks, p.CurrentSection()); ok {
+			return expandPermalink(pattern, p)
+		}
+		if p.CurrentSection() == "" {
 			return "/" + p.Slug + "/"
 		}
-		return "/" + p.Section + "/" + p.Slug + "/"
+		return "/" + p.CurrentSection() + "/" + p.Slug + "/"
 	default:
 		return "/"
 	}
@@ -1,6 +1,8 @@
 package content
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
 	"runtime"
 	"slices"
@@ -252,3 +254,106 @@ func TestSlugify(t *testing.T) {
 		}
 	}
 }
+
+func TestDiscoverAll_CollectsFileErrors(t *testing.T) {
+	contentDir := t.TempDir()
+	files := map[string]string{
+		"blog/good.md":   "---\ntitle: Good\n---\nBody.\n",
+		"blog/bad-a.md":  "---\ntitle: [unclosed\n---\nBody.\n",
+		"blog/bad-b.md":  "---\ntitle: \"unterminated\n---\nBody.\n",
+		"about/index.md": "---\ntitle: About\n---\nBody.\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(contentDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Discover stops at the first broken file.
+	if _, err := Discover(contentDir, config.Default()); err == nil {
+		t.Fatal("Discover() error = nil, want a FileError")
+	} else {
+		var fileErr *FileError
+		if !errors.As(err, &fileErr) {
+			t.Fatalf("Discover() error = %v, want a *FileError", err)
+		}
+	}
+
+	pages, fileErrs, err := DiscoverAll(contentDir, config.Default())
+	if err != nil {
+		t.Fatalf("DiscoverAll() error: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("DiscoverAll() returned %d pages, want 2", len(pages))
+	}
+	var paths []string
+	for _, fe := range fileErrs {
+		paths = append(paths, fe.Path)
+	}
+	slices.Sort(paths)
+	if want := []string{"blog/bad-a.md", "blog/bad-b.md"}; !slices.Equal(paths, want) {
+		t.Errorf("DiscoverAll() file errors = %v, want %v", paths, want)
+	}
+}
+
+func TestDiscoverNestedSections(t *testing.T) {
+	contentDir := t.TempDir()
+	files := map[string]string{
+		"docs/_index.md":                   "---\ntitle: Docs\n---\n",
+		"docs/intro.md":                    "---\ntitle: Intro\n---\n",
+		"docs/guides/_index.md":            "---\ntitle: Guides\n---\n",
+		"docs/guides/install/_index.md":    "---\ntitle: Install\n---\n",
+		"docs/guides/install/linux.md":     "---\ntitle: Linux\n---\n",
+		"docs/guides/install/mac/index.md": "---\ntitle: Mac\n---\n",
+		"docs/guides/misc/faq.md":          "---\ntitle: FAQ\n---\n",
+		"blog/2024/post.md":                "---\ntitle: Post\n---\n",
+		"about.md":                         "---\ntitle: About\n---\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(contentDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := Discover(contentDir, config.Default())
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+
+	tests := []struct {
+		title       string
+		url         string
+		section     string
+		sectionPath string
+	}{
+		{"Docs", "/docs/", "docs", "docs"},
+		{"Intro", "/docs/intro/", "docs", "docs"},
+		{"Guides", "/docs/guides/", "docs", "docs/guides"},
+		{"Install", "/docs/guides/install/", "docs", "docs/guides/install"},
+		{"Linux", "/docs/guides/install/linux/", "docs", "docs/guides/install"},
+		{"Mac", "/docs/guides/install/mac/", "docs", "docs/guides/install"},
+		// Directories without an _index.md are not sections of their own.
+		{"FAQ", "/docs/guides/faq/", "docs", "docs/guides"},
+		{"Post", "/blog/post/", "blog", "blog"},
+		{"About", "/about/", "", ""},
+	}
+	for _, tt := range tests {
+		p := findPageByTitle(pages, tt.title)
+		if p == nil {
+			t.Errorf("page %q not found", tt.title)
+			continue
+		}
+		if p.URL != tt.url || p.Section != tt.section || p.SectionPath != tt.sectionPath {
+			t.Errorf("%s: URL, Section, SectionPath = %q, %q, %q; want %q, %q, %q",
+				tt.title, p.URL, p.Section, p.SectionPath, tt.url, tt.section, tt.sectionPath)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package content
 import (
 	"bytes"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -110,7 +113,11 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 		return fmt.Errorf("frontmatter: required field \"title\" must be a non-empty string")
 	}
 	page.Title = title
+	return populateFields(page, metadata)
+}
 
+// populateFields maps the frontmatter fields other than title into page.
+func populateFields(page *Page, metadata map[string]any) error {
 	// String fields.
 	if v, ok := metadata["slug"]; ok {
 		if s, ok := v.(string); ok {
@@ -127,6 +134,16 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 			page.Summary = s
 		}
 	}
+	if v, ok := metadata["url"]; ok {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			return fmt.Errorf("frontmatter: \"url\" must be a non-empty string")
+		}
+		if !strings.HasPrefix(s, "/") {
+			s = "/" + s
+		}
+		page.URL = s
+	}
 	if v, ok := metadata["layout"]; ok {
 		if s, ok := v.(string); ok {
 			page.Layout = s
@@ -170,12 +187,28 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 		}
 		page.Lastmod = t
 	}
-	if v, ok := metadata["expiryDate"]; ok {
+	page.PublishDate = page.Date
+	if v, ok := metadata["publishDate"]; ok {
 		t, err := parseDate(v)
 		if err != nil {
-			return fmt.Errorf("frontmatter: invalid \"expiryDate\": %w", err)
+			return fmt.Errorf("frontmatter: invalid \"publishDate\": %w", err)
+		}
+		page.PublishDate = t
+	}
+	// unpublishDate and expiryDate are the same setting.
+	if _, ok := metadata["unpublishDate"]; ok {
+		if _, ok := metadata["expiryDate"]; ok {
+			return fmt.Errorf("frontmatter: \"unpublishDate\" and \"expiryDate\" are the same setting; use one")
+		}
+	}
+	for _, key := range []string{"unpublishDate", "expiryDate"} {
+		if v, ok := metadata[key]; ok {
+			t, err := parseDate(v)
+			if err != nil {
+				return fmt.Errorf("frontmatter: invalid %q: %w", key, err)
+			}
+			page.ExpiryDate = t
 		}
-		page.ExpiryDate = t
 	}
 
 	// Weight (int or float64).
@@ -187,6 +220,15 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 		page.Weight = w
 	}
 
+	// Per-list page size override (int or float64).
+	if v, ok := metadata["paginate"]; ok {
+		n, err := toInt(v)
+		if err != nil {
+			return fmt.Errorf("frontmatter: invalid \"paginate\": %w", err)
+		}
+		page.Paginate = n
+	}
+
 	// String slice fields.
 	if v, ok := metadata["tags"]; ok {
 		s, err := toStringSlice(v)
@@ -210,6 +252,24 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 		page.Aliases = s
 	}
 
+	// Build options.
+	if v, ok := metadata["build"]; ok {
+		b, err := parsePageBuild(v)
+		if err != nil {
+			return fmt.Errorf("frontmatter: invalid \"build\": %w", err)
+		}
+		page.Build = b
+	}
+
+	// Cascade, applied to the pages below by Discover.
+	if v, ok := metadata["cascade"]; ok {
+		c, err := parseCascade(v)
+		if err != nil {
+			return fmt.Errorf("frontmatter: invalid \"cascade\": %w", err)
+		}
+		page.Cascade = c
+	}
+
 	// Cover image.
 	if v, ok := metadata["cover"]; ok {
 		cover, err := parseCoverImage(v)
@@ -229,6 +289,12 @@ func PopulatePage(page *Page, metadata map[string]any) error {
 	return nil
 }
 
+// ParseDate parses s in any of the date formats accepted in frontmatter,
+// e.g. "2024-01-15" or "2024-01-15T09:00:00Z".
+func ParseDate(s string) (time.Time, error) {
+	return parseDate(s)
+}
+
 // parseDate attempts to parse a date value that may be a string or a
 // time.Time (some YAML/TOML parsers auto-detect dates).
 func parseDate(v any) (time.Time, error) {
@@ -283,6 +349,42 @@ func toInt(v any) (int, error) {
 	}
 }
 
+// parsePageBuild parses the build frontmatter block, e.g.
+// {render: never, list: local, publish: false}.
+func parsePageBuild(v any) (PageBuild, error) {
+	var b PageBuild
+	m, ok := v.(map[string]any)
+	if !ok {
+		return b, fmt.Errorf("expected map, got %T", v)
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		val := m[key]
+		switch key {
+		case "render":
+			s, _ := val.(string)
+			if s != RenderAlways && s != RenderNever {
+				return b, fmt.Errorf("render must be %q or %q, got %v", RenderAlways, RenderNever, val)
+			}
+			b.Render = s
+		case "list":
+			s, _ := val.(string)
+			if s != ListAlways && s != ListLocal && s != ListNever {
+				return b, fmt.Errorf("list must be %q, %q, or %q, got %v", ListAlways, ListLocal, ListNever, val)
+			}
+			b.List = s
+		case "publish":
+			publish, ok := val.(bool)
+			if !ok {
+				return b, fmt.Errorf("publish must be a boolean, got %v", val)
+			}
+			b.NoPublish = !publish
+		default:
+			return b, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return b, nil
+}
+
 // parseCoverImage converts a map value into a CoverImage struct.
 func parseCoverImage(v any) (*CoverImage, error) {
 	m, ok := v.(map[string]any)
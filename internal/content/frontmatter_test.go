@@ -295,6 +295,108 @@ func TestPopulatePage(t *testing.T) {
 	}
 }
 
+func TestPopulatePagePublishDates(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name          string
+		metadata      map[string]any
+		publish, exp  time.Time
+		wantErrSubstr string
+	}{
+		{
+			name:     "publishDate defaults to date",
+			metadata: map[string]any{"date": "2025-01-02"},
+			publish:  day(2),
+		},
+		{
+			name:     "backdated post scheduled later",
+			metadata: map[string]any{"date": "2025-01-02", "publishDate": "2025-01-20", "unpublishDate": "2025-01-30"},
+			publish:  day(20),
+			exp:      day(30),
+		},
+		{
+			name:     "expiryDate alias",
+			metadata: map[string]any{"expiryDate": "2025-01-30"},
+			exp:      day(30),
+		},
+		{
+			name:          "both unpublish spellings",
+			metadata:      map[string]any{"unpublishDate": "2025-01-30", "expiryDate": "2025-01-30"},
+			wantErrSubstr: "same setting",
+		},
+		{
+			name:          "invalid publishDate",
+			metadata:      map[string]any{"publishDate": "soon"},
+			wantErrSubstr: `invalid "publishDate"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.metadata["title"] = "Post"
+			page := &Page{}
+			err := PopulatePage(page, tt.metadata)
+			if tt.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+					t.Fatalf("PopulatePage() error = %v, want %q", err, tt.wantErrSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PopulatePage() error: %v", err)
+			}
+			if !page.PublishDate.Equal(tt.publish) {
+				t.Errorf("PublishDate = %v, want %v", page.PublishDate, tt.publish)
+			}
+			if !page.ExpiryDate.Equal(tt.exp) {
+				t.Errorf("ExpiryDate = %v, want %v", page.ExpiryDate, tt.exp)
+			}
+		})
+	}
+}
+
+func TestPopulatePageBuild(t *testing.T) {
+	tests := []struct {
+		name          string
+		build         any
+		want          PageBuild
+		wantErrSubstr string
+	}{
+		{
+			name:  "headless",
+			build: map[string]any{"render": "never", "list": "never"},
+			want:  PageBuild{Render: RenderNever, List: ListNever},
+		},
+		{
+			name:  "local without resources",
+			build: map[string]any{"list": "local", "publish": false},
+			want:  PageBuild{List: ListLocal, NoPublish: true},
+		},
+		{name: "bad render", build: map[string]any{"render": "link"}, wantErrSubstr: "render must be"},
+		{name: "bad list", build: map[string]any{"list": true}, wantErrSubstr: "list must be"},
+		{name: "bad publish", build: map[string]any{"publish": "no"}, wantErrSubstr: "publish must be a boolean"},
+		{name: "unknown key", build: map[string]any{"rendr": "never"}, wantErrSubstr: `unknown key "rendr"`},
+		{name: "not a map", build: "never", wantErrSubstr: "expected map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &Page{}
+			err := PopulatePage(page, map[string]any{"title": "Page", "build": tt.build})
+			if tt.wantErrSubstr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSubstr) {
+					t.Fatalf("PopulatePage() error = %v, want %q", err, tt.wantErrSubstr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PopulatePage() error: %v", err)
+			}
+			if page.Build != tt.want {
+				t.Errorf("Build = %+v, want %+v", page.Build, tt.want)
+			}
+		})
+	}
+}
+
 func TestPopulatePageMissingTitle(t *testing.T) {
 	raw := mustReadTestdata(t, "missing_title.md")
 	metadata, _, err := ParseFrontmatter(raw)
@@ -454,6 +556,28 @@ func TestPopulatePageProject(t *testing.T) {
 	})
 }
 
+func TestPopulatePagePaginate(t *testing.T) {
+	metadata := map[string]any{
+		"title":    "Blog",
+		"paginate": 5,
+	}
+	page := &Page{}
+	if err := PopulatePage(page, metadata); err != nil {
+		t.Fatalf("PopulatePage() error = %v", err)
+	}
+	if page.Paginate != 5 {
+		t.Errorf("Paginate = %d, want 5", page.Paginate)
+	}
+
+	bad := map[string]any{
+		"title":    "Blog",
+		"paginate": "lots",
+	}
+	if err := PopulatePage(&Page{}, bad); err == nil {
+		t.Error("expected error for non-numeric paginate")
+	}
+}
+
 func TestPopulatePageTags(t *testing.T) {
 	// Test with []any (as YAML parser produces).
 	t.Run("[]any input", func(t *testing.T) {
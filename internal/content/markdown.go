@@ -8,6 +8,7 @@ import (
 	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	"github.com/yuin/goldmark/renderer/html"
@@ -20,7 +21,11 @@ import (
 // a rich set of extensions (GFM, footnotes, typographer, syntax highlighting,
 // auto heading IDs, and attributes).
 type MarkdownRenderer struct {
-	md goldmark.Markdown
+	md         goldmark.Markdown
+	extensions []goldmark.Extender
+
+	// hooked is md with render hooks, set by SetRenderHooks.
+	hooked goldmark.Markdown
 }
 
 // NewMarkdownRenderer creates a MarkdownRenderer configured with all
@@ -40,8 +45,14 @@ func NewMarkdownRenderer(extensions ...goldmark.Extender) *MarkdownRenderer {
 	}
 	exts = append(exts, extensions...)
 
-	md := goldmark.New(
-		goldmark.WithExtensions(exts...),
+	return &MarkdownRenderer{md: newGoldmark(exts), extensions: exts}
+}
+
+// newGoldmark creates a goldmark instance with the given extensions and
+// Forge's parser and renderer options.
+func newGoldmark(extensions []goldmark.Extender) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 			parser.WithAttribute(),
@@ -50,8 +61,6 @@ func NewMarkdownRenderer(extensions ...goldmark.Extender) *MarkdownRenderer {
 			html.WithUnsafe(),
 		),
 	)
-
-	return &MarkdownRenderer{md: md}
 }
 
 // Render converts Markdown source bytes into HTML.
@@ -68,22 +77,11 @@ func (r *MarkdownRenderer) Render(source []byte) ([]byte, error) {
 // HTML and the TOC HTML separately.
 func (r *MarkdownRenderer) RenderWithTOC(source []byte) (htmlOut []byte, tocOut []byte, err error) {
 	// Parse the markdown into an AST.
-	doc := r.md.Parser().Parse(text.NewReader(source))
+	doc := r.md.Parser().Parse(text.NewReader(source), parser.WithContext(newParserContext()))
 
-	// Extract the TOC tree from the AST.
-	tocTree, err := toc.Inspect(doc, source)
+	tocOut, err = r.renderTOC(doc, source)
 	if err != nil {
-		return nil, nil, fmt.Errorf("toc inspect: %w", err)
-	}
-
-	// Render the TOC as an HTML list.
-	tocList := toc.RenderList(tocTree)
-	if tocList != nil {
-		var tocBuf bytes.Buffer
-		if err := r.md.Renderer().Render(&tocBuf, source, tocList); err != nil {
-			return nil, nil, fmt.Errorf("toc render: %w", err)
-		}
-		tocOut = tocBuf.Bytes()
+		return nil, nil, err
 	}
 
 	// Render the full document.
@@ -95,6 +93,27 @@ func (r *MarkdownRenderer) RenderWithTOC(source []byte) (htmlOut []byte, tocOut
 	return contentBuf.Bytes(), tocOut, nil
 }
 
+// renderTOC renders the table of contents of the parsed document doc as a
+// nested HTML list, or returns nil if it has no headings.
+func (r *MarkdownRenderer) renderTOC(doc ast.Node, source []byte) ([]byte, error) {
+	// Extract the TOC tree from the AST.
+	tocTree, err := toc.Inspect(doc, source)
+	if err != nil {
+		return nil, fmt.Errorf("toc inspect: %w", err)
+	}
+
+	// Render the TOC as an HTML list.
+	tocList := toc.RenderList(tocTree)
+	if tocList == nil {
+		return nil, nil
+	}
+	var tocBuf bytes.Buffer
+	if err := r.md.Renderer().Render(&tocBuf, source, tocList); err != nil {
+		return nil, fmt.Errorf("toc render: %w", err)
+	}
+	return tocBuf.Bytes(), nil
+}
+
 // GenerateChromaCSS produces CSS for syntax-highlighted code blocks.
 // It returns separate CSS strings for light and dark themes. The dark CSS
 // has all .chroma selectors prefixed with .dark so it can be scoped to a
@@ -43,6 +43,30 @@ type CoverImage struct {
 	Caption string
 }
 
+// Values of the build.render frontmatter key.
+const (
+	RenderAlways = "always" // the page gets its own HTML file (default)
+	RenderNever  = "never"  // the page exists only as data for templates
+)
+
+// Values of the build.list frontmatter key.
+const (
+	ListAlways = "always" // listed everywhere (default)
+	ListLocal  = "local"  // listed only in its own section
+	ListNever  = "never"  // listed nowhere
+)
+
+// PageBuild holds a page's build frontmatter block. The zero value is the
+// default: rendered, listed everywhere, and resources published.
+type PageBuild struct {
+	Render string // RenderAlways or RenderNever; empty means RenderAlways
+	List   string // ListAlways, ListLocal, or ListNever; empty means ListAlways
+
+	// NoPublish is set by publish: false; the page's bundle resources are
+	// then not copied to the output.
+	NoPublish bool
+}
+
 // Page is the central content model in Forge. It represents a single piece of
 // content (typically a Markdown file) along with all its associated metadata,
 // rendered output, and relationships to other pages.
@@ -56,17 +80,25 @@ type Page struct {
 	Summary     string
 
 	// Dates
-	Date       time.Time
-	Lastmod    time.Time
-	ExpiryDate time.Time
+	Date        time.Time // sorts and dates the page; may be backdated
+	Lastmod     time.Time
+	PublishDate time.Time // when the page becomes visible; defaults to Date
+	ExpiryDate  time.Time // when the page is removed (unpublishDate or expiryDate)
 
 	// Content
 	RawContent      string // Raw markdown
+	ContentLine     int    // Line of the source file RawContent starts on
 	Content         string // Rendered HTML
 	TableOfContents string // Rendered TOC HTML
 	WordCount       int
 	ReadingTime     int // Minutes
 
+	// Shortcodes holds the sorted names of the shortcodes the content
+	// calls, and RenderHooks the sorted kinds of render hooks it used,
+	// both set when it is rendered.
+	Shortcodes  []string
+	RenderHooks []string
+
 	// Classification
 	Draft   bool
 	Type    PageType
@@ -74,6 +106,30 @@ type Page struct {
 	Layout  string // Explicit layout override
 	Weight  int
 
+	// SectionPath is the nested section the page belongs to, or that a list
+	// page lists, e.g. "docs/guides/install". It is the nearest content
+	// directory with an _index.md, falling back to Section.
+	SectionPath string
+
+	// Paginate overrides the site-wide page size for this page's list
+	// (only meaningful on _index.md). Zero means use the site default.
+	Paginate int
+
+	// PagerOf is set on the virtual copies of a list page that render its
+	// pages 2..N, and points to the list page itself.
+	PagerOf *Page
+
+	// Build controls whether the page is rendered, listed, and has its
+	// bundle resources published (the build frontmatter block).
+	Build PageBuild
+
+	// Cascade holds the frontmatter a section's _index.md passes down to
+	// the pages below it. Cascaded maps each frontmatter key the page
+	// inherited (params as "params.<name>") to the SourcePath of the
+	// _index.md it came from.
+	Cascade  []Cascade
+	Cascaded map[string]string
+
 	// Taxonomies
 	Tags       []string
 	Categories []string
@@ -83,6 +139,7 @@ type Page struct {
 	// Navigation
 	PrevPage *Page
 	NextPage *Page
+	Parent   *Page // enclosing section list page, or the home page
 	Aliases  []string
 
 	// Media
@@ -149,18 +206,57 @@ func FilterDrafts(pages []*Page) []*Page {
 	})
 }
 
-// FilterFuture returns a new slice with pages whose Date is in the future removed.
-func FilterFuture(pages []*Page) []*Page {
-	now := time.Now()
+// CurrentSection returns the section path of the page: its SectionPath, or
+// Section for pages that have none, such as taxonomy pages.
+func (p *Page) CurrentSection() string {
+	if p.SectionPath != "" {
+		return p.SectionPath
+	}
+	return p.Section
+}
+
+// Rendered reports whether the page gets its own output file.
+func (p *Page) Rendered() bool {
+	return p.Build.Render != RenderNever
+}
+
+// ListedInSite reports whether the page appears in site-wide lists: the
+// site's pages, the home page, taxonomies, feeds, the sitemap, and search.
+// A page that is not rendered is listed nowhere, whatever its build.list,
+// so no list links to a URL without a file; templates reach it with
+// .Site.GetPage.
+func (p *Page) ListedInSite() bool {
+	return p.Rendered() && (p.Build.List == "" || p.Build.List == ListAlways)
+}
+
+// ListedInSection reports whether the page appears in its section's list
+// and section navigation. Like ListedInSite, it is false for a page that is
+// not rendered.
+func (p *Page) ListedInSection() bool {
+	return p.Rendered() && p.Build.List != ListNever
+}
+
+// FilterFuture returns a new slice with pages not yet published at now
+// removed.
+func FilterFuture(pages []*Page, now time.Time) []*Page {
 	return slices.DeleteFunc(slices.Clone(pages), func(p *Page) bool {
-		return p.Date.After(now)
+		return p.IsFuture(now)
 	})
 }
 
+// IsFuture reports whether p is not yet published at now: its PublishDate,
+// or its Date if it has none, is after now.
+func (p *Page) IsFuture(now time.Time) bool {
+	publish := p.PublishDate
+	if publish.IsZero() {
+		publish = p.Date
+	}
+	return publish.After(now)
+}
+
 // FilterExpired returns a new slice with pages whose ExpiryDate is non-zero and
-// in the past removed.
-func FilterExpired(pages []*Page) []*Page {
-	now := time.Now()
+// before now removed.
+func FilterExpired(pages []*Page, now time.Time) []*Page {
 	return slices.DeleteFunc(slices.Clone(pages), func(p *Page) bool {
 		return !p.ExpiryDate.IsZero() && p.ExpiryDate.Before(now)
 	})
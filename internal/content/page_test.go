@@ -201,7 +201,7 @@ func TestFilterFuture(t *testing.T) {
 		newPage("AnotherPast", withDate(past)),
 	}
 
-	filtered := FilterFuture(pages)
+	filtered := FilterFuture(pages, time.Now())
 	got := titles(filtered)
 	want := []string{"PastPost", "AnotherPast"}
 	if !equalStrings(got, want) {
@@ -214,6 +214,19 @@ func TestFilterFuture(t *testing.T) {
 	}
 }
 
+func TestFilterFuture_PublishDate(t *testing.T) {
+	now := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	backdated := newPage("Backdated", withDate(now.AddDate(0, 0, -10)))
+	backdated.PublishDate = now.AddDate(0, 0, 5)
+	early := newPage("Early", withDate(now.AddDate(0, 0, 10)))
+	early.PublishDate = now.AddDate(0, 0, -1)
+
+	got := titles(FilterFuture([]*Page{backdated, early}, now))
+	if want := []string{"Early"}; !equalStrings(got, want) {
+		t.Errorf("FilterFuture() = %v, want %v", got, want)
+	}
+}
+
 func TestFilterExpired(t *testing.T) {
 	past := time.Now().Add(-24 * time.Hour)
 	future := time.Now().Add(24 * time.Hour)
@@ -225,7 +238,7 @@ func TestFilterExpired(t *testing.T) {
 		newPage("AlsoNoExpiry"),                              // zero ExpiryDate, keep
 	}
 
-	filtered := FilterExpired(pages)
+	filtered := FilterExpired(pages, time.Now())
 	got := titles(filtered)
 	want := []string{"NoExpiry", "FutureExpiry", "AlsoNoExpiry"}
 	if !equalStrings(got, want) {
@@ -1,6 +1,9 @@
 package content
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Pager represents a single page of paginated results.
 type Pager struct {
@@ -9,6 +12,7 @@ type Pager struct {
 	TotalPages int
 	HasPrev    bool
 	HasNext    bool
+	URL        string // URL of this page
 	PrevURL    string
 	NextURL    string
 	First      string // URL of first page
@@ -34,10 +38,7 @@ func Paginate(pages []*Page, pageSize int, baseURL string) []*Pager {
 	totalPages := (len(pages) + pageSize - 1) / pageSize
 
 	// Determine the URL of the last page.
-	lastURL := baseURL
-	if totalPages > 1 {
-		lastURL = fmt.Sprintf("%spage/%d/", baseURL, totalPages)
-	}
+	lastURL := PagerURL(baseURL, totalPages)
 
 	pagers := make([]*Pager, 0, totalPages)
 
@@ -53,22 +54,19 @@ func Paginate(pages []*Page, pageSize int, baseURL string) []*Pager {
 			TotalPages: totalPages,
 			HasPrev:    pageNum > 1,
 			HasNext:    pageNum < totalPages,
+			URL:        PagerURL(baseURL, pageNum),
 			First:      baseURL,
 			Last:       lastURL,
 		}
 
 		// Set PrevURL.
 		if pager.HasPrev {
-			if pageNum == 2 {
-				pager.PrevURL = baseURL
-			} else {
-				pager.PrevURL = fmt.Sprintf("%spage/%d/", baseURL, pageNum-1)
-			}
+			pager.PrevURL = PagerURL(baseURL, pageNum-1)
 		}
 
 		// Set NextURL.
 		if pager.HasNext {
-			pager.NextURL = fmt.Sprintf("%spage/%d/", baseURL, pageNum+1)
+			pager.NextURL = PagerURL(baseURL, pageNum+1)
 		}
 
 		pagers = append(pagers, pager)
@@ -76,3 +74,16 @@ func Paginate(pages []*Page, pageSize int, baseURL string) []*Pager {
 
 	return pagers
 }
+
+// PagerURL returns the URL of page number n of a list rooted at baseURL.
+// Page 1 is the list URL itself; later pages live under baseURL + "page/N/",
+// or "page/N.html" when the list uses ugly URLs (e.g. "/blog/index.html").
+func PagerURL(baseURL string, n int) string {
+	if n <= 1 {
+		return baseURL
+	}
+	if strings.HasSuffix(baseURL, ".html") {
+		return fmt.Sprintf("%spage/%d.html", DirURL(baseURL), n)
+	}
+	return fmt.Sprintf("%spage/%d/", baseURL, n)
+}
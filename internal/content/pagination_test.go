@@ -121,19 +121,23 @@ func TestPaginate_URLs(t *testing.T) {
 
 	tests := []struct {
 		index   int
+		url     string
 		prevURL string
 		nextURL string
 		first   string
 		last    string
 	}{
-		{0, "", "/blog/page/2/", "/blog/", "/blog/page/4/"},
-		{1, "/blog/", "/blog/page/3/", "/blog/", "/blog/page/4/"},
-		{2, "/blog/page/2/", "/blog/page/4/", "/blog/", "/blog/page/4/"},
-		{3, "/blog/page/3/", "", "/blog/", "/blog/page/4/"},
+		{0, "/blog/", "", "/blog/page/2/", "/blog/", "/blog/page/4/"},
+		{1, "/blog/page/2/", "/blog/", "/blog/page/3/", "/blog/", "/blog/page/4/"},
+		{2, "/blog/page/3/", "/blog/page/2/", "/blog/page/4/", "/blog/", "/blog/page/4/"},
+		{3, "/blog/page/4/", "/blog/page/3/", "", "/blog/", "/blog/page/4/"},
 	}
 
 	for _, tt := range tests {
 		p := pagers[tt.index]
+		if p.URL != tt.url {
+			t.Errorf("pager[%d].URL = %q, want %q", tt.index, p.URL, tt.url)
+		}
 		if p.PrevURL != tt.prevURL {
 			t.Errorf("pager[%d].PrevURL = %q, want %q", tt.index, p.PrevURL, tt.prevURL)
 		}
@@ -235,3 +239,22 @@ func TestPaginate_SinglePageLastURLEqualsBaseURL(t *testing.T) {
 		t.Errorf("single page First = %q, want %q", pagers[0].First, "/blog/")
 	}
 }
+
+func TestPagerURL(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		n       int
+		want    string
+	}{
+		{"/blog/", 1, "/blog/"},
+		{"/blog/", 0, "/blog/"},
+		{"/blog/", 2, "/blog/page/2/"},
+		{"/", 3, "/page/3/"},
+		{"/tags/go/", 2, "/tags/go/page/2/"},
+	}
+	for _, tt := range tests {
+		if got := PagerURL(tt.baseURL, tt.n); got != tt.want {
+			t.Errorf("PagerURL(%q, %d) = %q, want %q", tt.baseURL, tt.n, got, tt.want)
+		}
+	}
+}
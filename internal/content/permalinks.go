@@ -0,0 +1,81 @@
+package content
+
+import (
+	"cmp"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aellingwood/forge/internal/config"
+)
+
+// multiSlashRe collapses the empty path segments left by empty tokens.
+var multiSlashRe = regexp.MustCompile(`/{2,}`)
+
+// permalinkPattern returns the permalinks pattern configured for section,
+// or for the nearest section above it: "blog" also applies to "blog/2024".
+func permalinkPattern(patterns map[string]string, section string) (string, bool) {
+	for s := section; s != ""; s = parentDir(s) {
+		if pattern, ok := patterns[s]; ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// expandPermalink replaces the tokens in a permalinks pattern with values
+// from p (see config.PermalinkTokens):
+//
+//	:year, :month, :day  the page date, as 2006, 01, and 02
+//	:section             the top-level section, e.g. "docs"
+//	:sections            the nested section path, e.g. "docs/guides"
+//	:slug                the slug, or the slugified title
+//	:title               the slugified title
+//	:filename            the content file name without .md ("index" for bundles)
+//	:contentbasename     the bundle directory name for bundles, else :filename
+func expandPermalink(pattern string, p *Page) string {
+	filename := p.Slug
+	if p.SourcePath != "" {
+		filename = strings.TrimSuffix(path.Base(p.SourcePath), ".md")
+	}
+	url := config.PermalinkTokenRe.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token[1:] {
+		case "year":
+			return p.Date.Format("2006")
+		case "month":
+			return p.Date.Format("01")
+		case "day":
+			return p.Date.Format("02")
+		case "section":
+			return p.Section
+		case "sections":
+			return p.CurrentSection()
+		case "slug":
+			return cmp.Or(p.Slug, slugify(p.Title))
+		case "title":
+			return slugify(p.Title)
+		case "filename":
+			return filename
+		case "contentbasename":
+			if p.IsBundle {
+				return path.Base(p.SourceDir)
+			}
+			return filename
+		default:
+			return token
+		}
+	})
+	return multiSlashRe.ReplaceAllString(url, "/")
+}
+
+// TermPermalink returns the clean URL of the page for term in taxonomy: the
+// taxonomy's permalinks pattern, with :slug and :title the term's TermSlug
+// and :section the taxonomy, or TaxonomyTermURL of the slug if it has none.
+// Terms with the same slug share one page (see BuildTaxonomies).
+func TermPermalink(patterns map[string]string, taxonomy, term string) string {
+	slug := termSegment(term)
+	if pattern, ok := patterns[taxonomy]; ok {
+		return expandPermalink(pattern, &Page{Type: PageTypeTaxonomy, Title: slug, Slug: slug, Section: taxonomy})
+	}
+	return TaxonomyTermURL(taxonomy, slug)
+}
@@ -0,0 +1,123 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aellingwood/forge/internal/config"
+)
+
+func TestExpandPermalink(t *testing.T) {
+	post := &Page{
+		Title:       "Hello, World",
+		Slug:        "hello",
+		Date:        time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC),
+		Section:     "blog",
+		SectionPath: "blog/2024",
+		SourcePath:  "blog/2024/2024-03-07-hello.md",
+		SourceDir:   "blog/2024",
+	}
+	bundle := &Page{
+		Title:      "Widget",
+		Slug:       "widget",
+		Section:    "projects",
+		SourcePath: "projects/widget/index.md",
+		SourceDir:  "projects/widget",
+		IsBundle:   true,
+	}
+	tests := []struct {
+		pattern string
+		page    *Page
+		want    string
+	}{
+		{"/:year/:month/:day/:slug/", post, "/2024/03/07/hello/"},
+		{"/:section/:title/", post, "/blog/hello-world/"},
+		{"/:sections/:slug/", post, "/blog/2024/hello/"},
+		{"/posts/:filename/", post, "/posts/2024-03-07-hello/"},
+		{"/:contentbasename/", post, "/2024-03-07-hello/"},
+		{"/work/:contentbasename/", bundle, "/work/widget/"},
+		{"/work/:filename/", bundle, "/work/index/"},
+		{"/:section/:unknown/", post, "/blog/:unknown/"},
+		{"/archive/:slug/", &Page{Title: "No Slug"}, "/archive/no-slug/"},
+	}
+	for _, tt := range tests {
+		if got := expandPermalink(tt.pattern, tt.page); got != tt.want {
+			t.Errorf("expandPermalink(%q, %s) = %q, want %q", tt.pattern, tt.page.Title, got, tt.want)
+		}
+	}
+}
+
+func TestTermPermalink(t *testing.T) {
+	patterns := map[string]string{"tags": "/topics/:slug/"}
+	if got := TermPermalink(patterns, "tags", "go"); got != "/topics/go/" {
+		t.Errorf("TermPermalink(tags, go) = %q, want %q", got, "/topics/go/")
+	}
+	if got := TermPermalink(patterns, "categories", "dev"); got != "/categories/dev/" {
+		t.Errorf("TermPermalink(categories, dev) = %q, want %q", got, "/categories/dev/")
+	}
+	// Terms are slugified with or without a pattern.
+	if got := TermPermalink(patterns, "tags", "Machine Learning"); got != "/topics/machine-learning/" {
+		t.Errorf("TermPermalink(tags, Machine Learning) = %q, want %q", got, "/topics/machine-learning/")
+	}
+	if got := TermPermalink(patterns, "categories", "machine learning"); got != "/categories/machine-learning/" {
+		t.Errorf("TermPermalink(categories, machine learning) = %q, want %q", got, "/categories/machine-learning/")
+	}
+}
+
+func TestDiscoverPermalinks(t *testing.T) {
+	contentDir := t.TempDir()
+	files := map[string]string{
+		"blog/_index.md":           "---\ntitle: Blog\n---\n",
+		"blog/2024-03-07-hello.md": "---\ntitle: Hello\ndate: 2024-03-07\n---\n",
+		"blog/moved.md":            "---\ntitle: Moved\ndate: 2024-01-02\nurl: elsewhere/moved/\n---\n",
+		"docs/_index.md":           "---\ntitle: Docs\ncascade:\n  date: 2023-05-01\n---\n",
+		"docs/guides/_index.md":    "---\ntitle: Guides\n---\n",
+		"docs/guides/setup.md":     "---\ntitle: Setup\n---\n",
+		"projects/widget/index.md": "---\ntitle: Widget\n---\n",
+		"about.md":                 "---\ntitle: About\n---\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(contentDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Permalinks = map[string]string{
+		"blog":     "/:year/:month/:slug/",
+		"docs":     "/manual/:sections/:slug/",
+		"projects": "/work/:contentbasename/",
+	}
+	pages, err := Discover(contentDir, cfg)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+
+	tests := map[string]string{
+		"Blog":   "/blog/",
+		"Hello":  "/2024/03/hello/",
+		"Moved":  "/elsewhere/moved/",
+		"Guides": "/docs/guides/",
+		// The pattern of "docs" applies to its subsections too, and the
+		// date is cascaded before URLs are built.
+		"Setup":  "/manual/docs/guides/setup/",
+		"Widget": "/work/widget/",
+		"About":  "/about/",
+	}
+	for title, want := range tests {
+		p := findPageByTitle(pages, title)
+		if p == nil {
+			t.Errorf("page %q not found", title)
+			continue
+		}
+		if p.URL != want {
+			t.Errorf("%s URL = %q, want %q", title, p.URL, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// RenderHookKinds are the Markdown elements a render hook can take over,
+// each with a template at layouts/_markup/render-<kind>.html.
+var RenderHookKinds = []string{"link", "image", "heading", "codeblock"}
+
+// renderHookNodes maps each render hook kind to the goldmark node it renders.
+var renderHookNodes = map[string]ast.NodeKind{
+	"link":      ast.KindLink,
+	"image":     ast.KindImage,
+	"heading":   ast.KindHeading,
+	"codeblock": ast.KindFencedCodeBlock,
+}
+
+// RenderHook is a Markdown element handed to a render hook.
+type RenderHook struct {
+	Kind string // one of RenderHookKinds
+
+	Destination string // URL of a link or image
+	Title       string // title of a link or image
+
+	// Text is the rendered content of a link or heading, or the alt text
+	// of an image, as HTML. PlainText is the same without HTML tags.
+	Text      string
+	PlainText string
+
+	Level  int    // level of a heading, 1 to 6
+	Anchor string // ID of a heading
+
+	Type  string // language of a code block
+	Inner string // code of a code block
+
+	// Attributes holds the {.class #id key=value} attributes of a heading
+	// or code block.
+	Attributes map[string]any
+}
+
+// RenderHookFunc renders h as HTML, in place of goldmark's own output for
+// the element.
+type RenderHookFunc func(h *RenderHook) (string, error)
+
+// renderHookMeta is the document metadata key under which RenderPage passes
+// the page's render hooks to the node renderer.
+const renderHookMeta = "forge.renderHooks"
+
+// pageRenderHooks are the render hooks of the page being rendered.
+type pageRenderHooks struct {
+	render RenderHookFunc
+	used   []string // the kinds the page used
+}
+
+// SetRenderHooks makes RenderPage hand the given kinds of elements (see
+// RenderHookKinds) to its RenderHookFunc. Other elements, and the pages
+// rendered without a RenderHookFunc, are rendered by goldmark as usual.
+func (r *MarkdownRenderer) SetRenderHooks(kinds ...string) {
+	kinds = slices.DeleteFunc(slices.Clone(kinds), func(k string) bool {
+		_, ok := renderHookNodes[k]
+		return !ok
+	})
+	if len(kinds) == 0 {
+		r.hooked = nil
+		return
+	}
+	hr := &renderHookRenderer{kinds: kinds}
+	r.hooked = newGoldmark(append(slices.Clone(r.extensions), hr))
+	hr.md = r.hooked
+}
+
+// renderWithHooks renders source like RenderWithTOC, handing the elements
+// the renderer has hooks for to render. It returns the kinds of hooks used.
+func (r *MarkdownRenderer) renderWithHooks(source []byte, render RenderHookFunc) (htmlOut, tocOut []byte, used []string, err error) {
+	if render == nil || r.hooked == nil {
+		htmlOut, tocOut, err = r.RenderWithTOC(source)
+		return htmlOut, tocOut, nil, err
+	}
+
+	doc := r.md.Parser().Parse(text.NewReader(source), parser.WithContext(newParserContext()))
+	tocOut, err = r.renderTOC(doc, source)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	hooks := &pageRenderHooks{render: render}
+	doc.OwnerDocument().AddMeta(renderHookMeta, hooks)
+	var contentBuf bytes.Buffer
+	if err := r.hooked.Renderer().Render(&contentBuf, source, doc); err != nil {
+		return nil, nil, nil, fmt.Errorf("markdown render: %w", err)
+	}
+	slices.Sort(hooks.used)
+	return contentBuf.Bytes(), tocOut, slices.Compact(hooks.used), nil
+}
+
+// renderHookRenderer is a goldmark extension and node renderer that renders
+// elements of its kinds through the render hooks of the page.
+type renderHookRenderer struct {
+	kinds []string
+	md    goldmark.Markdown // renders the content of links and headings
+}
+
+// Extend registers the renderer ahead of goldmark's own and those of other
+// extensions.
+func (hr *renderHookRenderer) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(
+		renderer.WithNodeRenderers(util.Prioritized(hr, 10)),
+	)
+}
+
+// RegisterFuncs registers the renderer for the node kinds of its hooks.
+func (hr *renderHookRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	for _, kind := range hr.kinds {
+		reg.Register(renderHookNodes[kind], hr.render)
+	}
+}
+
+// render builds the RenderHook for node and writes the hook's output.
+func (hr *renderHookRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	hooks, _ := node.OwnerDocument().Meta()[renderHookMeta].(*pageRenderHooks)
+	if hooks == nil {
+		return ast.WalkStop, fmt.Errorf("render hooks missing for %s", node.Kind())
+	}
+
+	var h *RenderHook
+	var err error
+	switch n := node.(type) {
+	case *ast.Link:
+		h = &RenderHook{Kind: "link", Destination: string(n.Destination), Title: string(n.Title)}
+		err = hr.setText(h, source, n)
+	case *ast.Image:
+		h = &RenderHook{Kind: "image", Destination: string(n.Destination), Title: string(n.Title)}
+		err = hr.setText(h, source, n)
+	case *ast.Heading:
+		h = &RenderHook{Kind: "heading", Level: n.Level, Attributes: nodeAttributes(n.Attributes())}
+		h.Anchor, _ = h.Attributes["id"].(string)
+		err = hr.setText(h, source, n)
+	case *ast.FencedCodeBlock:
+		h = &RenderHook{Kind: "codeblock", Type: string(n.Language(source)), Attributes: infoAttributes(n, source)}
+		var code bytes.Buffer
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			code.Write(line.Value(source))
+		}
+		h.Inner = code.String()
+	default:
+		return ast.WalkContinue, nil
+	}
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	out, err := hooks.render(h)
+	if err != nil {
+		return ast.WalkStop, fmt.Errorf("render-%s hook: %w", h.Kind, err)
+	}
+	hooks.used = append(hooks.used, h.Kind)
+	_, _ = w.WriteString(out)
+	return ast.WalkSkipChildren, nil
+}
+
+// setText renders the children of n into h.Text and h.PlainText.
+func (hr *renderHookRenderer) setText(h *RenderHook, source []byte, n ast.Node) error {
+	var buf bytes.Buffer
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if err := hr.md.Renderer().Render(&buf, source, c); err != nil {
+			return err
+		}
+	}
+	h.Text = buf.String()
+	h.PlainText = StripHTMLTags(h.Text)
+	return nil
+}
+
+// infoAttributes returns the attributes in the info string of a fenced code
+// block, as in ```go {title="main.go"}.
+func infoAttributes(n *ast.FencedCodeBlock, source []byte) map[string]any {
+	if n.Info == nil {
+		return map[string]any{}
+	}
+	info := n.Info.Segment.Value(source)
+	i := bytes.IndexByte(info, '{')
+	if i < 0 {
+		return map[string]any{}
+	}
+	attrs, _ := parser.ParseAttributes(text.NewReader(info[i:]))
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[string(a.Name)] = attributeValue(a.Value)
+	}
+	return m
+}
+
+// nodeAttributes converts the attributes of a node to a map.
+func nodeAttributes(attrs []ast.Attribute) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[string(a.Name)] = attributeValue(a.Value)
+	}
+	return m
+}
+
+// attributeValue returns an attribute value with strings in place of the
+// byte slices goldmark parses them into.
+func attributeValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = attributeValue(e)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// echoRenderHook renders an element as kind[fields], listing the fields
+// that apply to its kind.
+func echoRenderHook(h *RenderHook) (string, error) {
+	switch h.Kind {
+	case "link", "image":
+		return fmt.Sprintf("%s[%s|%s|%s|%s]", h.Kind, h.Destination, h.Title, h.Text, h.PlainText), nil
+	case "heading":
+		var attrs []string
+		for _, k := range slices.Sorted(maps.Keys(h.Attributes)) {
+			attrs = append(attrs, fmt.Sprintf("%s=%v", k, h.Attributes[k]))
+		}
+		return fmt.Sprintf("heading[%d|%s|%s|%s]\n", h.Level, h.Anchor, h.Text, strings.Join(attrs, ",")), nil
+	case "codeblock":
+		return fmt.Sprintf("codeblock[%s|%q|%v]\n", h.Type, h.Inner, h.Attributes), nil
+	}
+	return "", fmt.Errorf("unexpected kind %q", h.Kind)
+}
+
+func TestRenderPage_RenderHooks(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			name: "link",
+			src:  `See [the *docs*](https://example.com "Docs").`,
+			want: "<p>See link[https://example.com|Docs|the <em>docs</em>|the docs].</p>\n",
+		},
+		{
+			name: "image",
+			src:  `![A cat](/cat.png)`,
+			want: "<p>image[/cat.png||A cat|A cat]</p>\n",
+		},
+		{
+			name: "heading with a link",
+			src:  "## Go [home](/) {.big}",
+			want: "heading[2|go-home|Go link[/||home|home]|class=big,id=go-home]\n",
+		},
+		{
+			name: "codeblock",
+			src:  "```go {title=\"main.go\"}\nx := 1\n```",
+			want: "codeblock[go|\"x := 1\\n\"|map[title:main.go]]\n",
+		},
+		{
+			name: "indented code is not a codeblock",
+			src:  "    x := 1",
+			want: "<pre><code>x := 1\n</code></pre>\n",
+		},
+	}
+	r := NewMarkdownRenderer()
+	r.SetRenderHooks(RenderHookKinds...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Page{RawContent: tt.src}
+			got, _, err := r.RenderPage(p, nil, echoRenderHook)
+			if err != nil {
+				t.Fatalf("RenderPage() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("RenderPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPage_RenderHookKinds(t *testing.T) {
+	src := "# Title\n\nA [link](/a/) and ![img](/b.png).\n"
+	r := NewMarkdownRenderer()
+	r.SetRenderHooks("link", "unknown")
+
+	p := &Page{RawContent: src}
+	got, toc, err := r.RenderPage(p, nil, echoRenderHook)
+	if err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	want := "<h1 id=\"title\">Title</h1>\n<p>A link[/a/||link|link] and <img src=\"/b.png\" alt=\"img\">.</p>\n"
+	if string(got) != want {
+		t.Errorf("RenderPage() = %q, want %q", got, want)
+	}
+	if !strings.Contains(string(toc), `<a href="#title">Title</a>`) {
+		t.Errorf("TOC should not go through the link hook: %q", toc)
+	}
+	if want := []string{"link"}; !slices.Equal(p.RenderHooks, want) {
+		t.Errorf("RenderHooks = %v, want %v", p.RenderHooks, want)
+	}
+
+	// Without a RenderHookFunc goldmark renders every element.
+	got, _, err = r.RenderPage(p, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	if !strings.Contains(string(got), `<a href="/a/">link</a>`) || p.RenderHooks != nil {
+		t.Errorf("RenderPage(nil) = %q, RenderHooks %v", got, p.RenderHooks)
+	}
+}
+
+func TestRenderPage_RenderHookWithShortcode(t *testing.T) {
+	r := NewMarkdownRenderer()
+	r.SetRenderHooks("link")
+	p := &Page{RawContent: `[{{< tag />}}](/x/)`}
+	got, _, err := r.RenderPage(p, echoShortcode, echoRenderHook)
+	if err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	// The shortcode's output is swapped in after the hook has run.
+	if want := "<p>link[/x/||<span>tag()[]</span>|<span>tag()[]</span>]</p>\n"; string(got) != want {
+		t.Errorf("RenderPage() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderPage_RenderHookError(t *testing.T) {
+	r := NewMarkdownRenderer()
+	r.SetRenderHooks("image")
+	fail := func(*RenderHook) (string, error) { return "", errors.New("template failed") }
+	_, _, err := r.RenderPage(&Page{RawContent: "![x](/x.png)"}, nil, fail)
+	if err == nil || !strings.Contains(err.Error(), "render-image hook: template failed") {
+		t.Errorf("RenderPage() error = %v, want the hook's error", err)
+	}
+}
@@ -0,0 +1,535 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+)
+
+// Shortcode is one shortcode call in a page's Markdown: {{< name args >}},
+// whose output is HTML, or {{% name args %}}, whose output is Markdown
+// rendered with the rest of the page. A call followed by a matching closing
+// tag ({{< /name >}}) encloses inner content; {{< name />}} never does.
+type Shortcode struct {
+	Name string
+
+	// Params holds the arguments: named ones (key="value") by name,
+	// positional ones by index ("0", "1", ...). A call uses one kind only.
+	Params map[string]string
+
+	// Inner is the content between the opening and closing tags, without
+	// the line breaks right after and before them, and with nested
+	// shortcodes already expanded. It is empty for a call without one.
+	Inner string
+
+	// Markdown is set for a {{% %}} call.
+	Markdown bool
+
+	// Line is the line of the source file the call starts on.
+	Line int
+}
+
+// ShortcodeFunc renders a shortcode call, returning HTML for a {{< >}}
+// call and Markdown for a {{% %}} call. It returns an error wrapping
+// ErrUnknownShortcode if there is no shortcode of that name.
+type ShortcodeFunc func(sc *Shortcode) (string, error)
+
+// ErrUnknownShortcode is wrapped by the errors of a ShortcodeFunc for a
+// call to a shortcode that does not exist.
+var ErrUnknownShortcode = errors.New("unknown shortcode")
+
+// ShortcodeError reports a shortcode call that could not be parsed or
+// rendered, at its position in the page's source file.
+type ShortcodeError struct {
+	Path string // relative to the content directory, e.g. "blog/my-post.md"
+	Line int
+	Name string // empty if the tag could not be parsed
+	Err  error
+}
+
+func (e *ShortcodeError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%s:%d: shortcode: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: shortcode %q: %v", e.Path, e.Line, e.Name, e.Err)
+}
+
+func (e *ShortcodeError) Unwrap() error {
+	return e.Err
+}
+
+// shortcodePlaceholder stands in for the HTML output of a {{< >}} call in
+// Markdown until goldmark has run, so that goldmark leaves it untouched.
+const shortcodePlaceholder = "FORGESHORTCODE%dX"
+
+var (
+	shortcodeOpenRe        = regexp.MustCompile(`\{\{[<%]`)
+	shortcodeNameRe        = regexp.MustCompile(`^[A-Za-z0-9_-]+(?:/[A-Za-z0-9_-]+)*$`)
+	shortcodePlaceholderRe = regexp.MustCompile(`FORGESHORTCODE[0-9]+X`)
+)
+
+// RenderPage renders p's Markdown like RenderWithTOC, first expanding its
+// shortcode calls with render, and hands the elements that have render
+// hooks (see SetRenderHooks) to hook. It records the names of the
+// shortcodes and the kinds of render hooks used in p.Shortcodes and
+// p.RenderHooks. With a nil render, shortcode calls are left as text, and
+// with a nil hook goldmark renders every element. Errors in a shortcode
+// call are returned as a *ShortcodeError, except in code spans and fenced
+// code blocks, where text that does not parse as a call to a known
+// shortcode, such as a template sample, is left as it is.
+func (r *MarkdownRenderer) RenderPage(p *Page, render ShortcodeFunc, hook RenderHookFunc) (htmlOut []byte, tocOut []byte, err error) {
+	p.Shortcodes = nil
+	p.RenderHooks = nil
+	if render == nil || !shortcodeOpenRe.MatchString(p.RawContent) {
+		htmlOut, tocOut, p.RenderHooks, err = r.renderWithHooks([]byte(p.RawContent), hook)
+		return htmlOut, tocOut, err
+	}
+
+	x := &shortcodeExpander{src: p.RawContent, path: p.SourcePath, firstLine: max(p.ContentLine, 1), render: render}
+	x.code = codeRanges(x.src)
+	x.pairTags()
+	source, _, err := x.expand(0, nil, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	slices.Sort(x.names)
+	p.Shortcodes = slices.Compact(x.names)
+
+	htmlOut, tocOut, p.RenderHooks, err = r.renderWithHooks([]byte(source), hook)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x.restore(htmlOut, false), x.restore(tocOut, true), nil
+}
+
+// shortcodeTag is an opening or closing shortcode tag in the source.
+type shortcodeTag struct {
+	name        string
+	params      map[string]string
+	markdown    bool
+	closing     bool // {{< /name >}}
+	selfClosing bool // {{< name />}}
+
+	// literal is the text a commented-out call, {{</* name */>}}, stands
+	// for: the call itself, {{< name >}}.
+	literal string
+
+	start, end int // byte offsets of the tag in the source
+}
+
+// shortcodeExpander expands the shortcode calls in one page's Markdown.
+type shortcodeExpander struct {
+	src       string
+	path      string
+	firstLine int // the source file line src starts on
+	render    ShortcodeFunc
+
+	code   [][2]int     // code spans and fenced code blocks, see codeRanges
+	paired map[int]bool // offsets of the opening tags that have a closing tag
+	html   []string     // output of the {{< >}} calls, by placeholder number
+	names  []string     // the shortcodes called
+}
+
+// expand expands src from offset from up to the closing tag of open, or to
+// the end of src if open is nil, and returns the expanded text and the
+// offset after the closing tag. inMarkdown reports whether the text is
+// Markdown for goldmark, in which {{< >}} output is held back behind a
+// placeholder; inside a {{< >}} call it is not.
+func (x *shortcodeExpander) expand(from int, open *shortcodeTag, inMarkdown bool) (string, int, error) {
+	var sb strings.Builder
+	pos := from
+	for {
+		loc := shortcodeOpenRe.FindStringIndex(x.src[pos:])
+		if loc == nil {
+			if open != nil {
+				return "", 0, x.errorf(open.start, open.name, "missing closing tag")
+			}
+			sb.WriteString(x.src[pos:])
+			return sb.String(), len(x.src), nil
+		}
+		start := pos + loc[0]
+		sb.WriteString(x.src[pos:start])
+
+		tag, err := x.parseTag(start)
+		if err != nil {
+			if x.inCode(start) {
+				sb.WriteString(x.src[start : start+3]) // not a shortcode call
+				pos = start + 3
+				continue
+			}
+			return "", 0, err
+		}
+		pos = tag.end
+		if tag.literal != "" {
+			sb.WriteString(tag.literal)
+			continue
+		}
+		if tag.closing {
+			switch {
+			case open != nil && tag.name == open.name:
+				return sb.String(), pos, nil
+			case x.inCode(start):
+				sb.WriteString(x.src[tag.start:tag.end])
+				continue
+			case open == nil:
+				return "", 0, x.errorf(tag.start, tag.name, "closing tag without an opening tag")
+			default:
+				return "", 0, x.errorf(tag.start, tag.name, "unexpected closing tag: %q is still open", open.name)
+			}
+		}
+
+		sc := &Shortcode{Name: tag.name, Params: tag.params, Markdown: tag.markdown, Line: x.line(tag.start)}
+		if x.paired[tag.start] {
+			inner, end, err := x.expand(pos, tag, inMarkdown && tag.markdown)
+			if err != nil {
+				return "", 0, err
+			}
+			sc.Inner = trimLineBreak(inner)
+			pos = end
+		}
+
+		out, err := x.render(sc)
+		if errors.Is(err, ErrUnknownShortcode) && x.inCode(tag.start) {
+			// Leave the call and whatever it encloses as text.
+			sb.WriteString(x.src[tag.start:tag.end])
+			pos = tag.end
+			continue
+		}
+		if err != nil {
+			return "", 0, &ShortcodeError{Path: x.path, Line: sc.Line, Name: sc.Name, Err: err}
+		}
+		x.names = append(x.names, sc.Name)
+		if inMarkdown && !sc.Markdown {
+			fmt.Fprintf(&sb, shortcodePlaceholder, len(x.html))
+			x.html = append(x.html, out)
+		} else {
+			sb.WriteString(out)
+		}
+	}
+}
+
+// parseTag parses the shortcode tag starting at offset start.
+func (x *shortcodeExpander) parseTag(start int) (*shortcodeTag, error) {
+	tag := &shortcodeTag{start: start, markdown: x.src[start+2] == '%'}
+	openDelim, closeDelim := "{{<", ">}}"
+	if tag.markdown {
+		openDelim, closeDelim = "{{%", "%}}"
+	}
+	bodyStart := start + len(openDelim)
+	rest := x.src[bodyStart:]
+
+	if trimmed := strings.TrimLeft(rest, " \t"); strings.HasPrefix(trimmed, "/*") {
+		end := strings.Index(trimmed, "*/"+closeDelim)
+		if end < 0 {
+			return nil, x.errorf(start, "", "unterminated comment, missing %q", "*/"+closeDelim)
+		}
+		tag.literal = openDelim + trimmed[2:end] + closeDelim
+		tag.end = bodyStart + len(rest) - len(trimmed) + end + 2 + len(closeDelim)
+		return tag, nil
+	}
+
+	end := strings.Index(rest, closeDelim)
+	if end < 0 {
+		return nil, x.errorf(start, "", "missing %q", closeDelim)
+	}
+	tag.end = bodyStart + end + len(closeDelim)
+	body := strings.TrimSpace(rest[:end])
+
+	if name, ok := strings.CutPrefix(body, "/"); ok {
+		tag.closing = true
+		tag.name = strings.TrimSpace(name)
+		if !shortcodeNameRe.MatchString(tag.name) {
+			return nil, x.errorf(start, "", "invalid name %q", tag.name)
+		}
+		return tag, nil
+	}
+	if b, ok := strings.CutSuffix(body, "/"); ok {
+		tag.selfClosing = true
+		body = strings.TrimSpace(b)
+	}
+
+	name, args := body, ""
+	if i := strings.IndexAny(body, " \t\r\n"); i >= 0 {
+		name, args = body[:i], body[i:]
+	}
+	if !shortcodeNameRe.MatchString(name) {
+		return nil, x.errorf(start, "", "invalid name %q", name)
+	}
+	tag.name = name
+	params, err := parseShortcodeArgs(args)
+	if err != nil {
+		return nil, x.errorf(start, name, "%v", err)
+	}
+	tag.params = params
+	return tag, nil
+}
+
+// pairTags scans the tags of src once and records in x.paired the opening
+// tags that have a closing tag. A closing tag pairs with the nearest
+// unpaired opening tag of the same name before it, so in
+// "{{< note >}} a {{< note >}} b {{< /note >}}" the first call stands alone
+// and only the second encloses b. Tags that do not parse and mismatched
+// tags are left for expand to report.
+func (x *shortcodeExpander) pairTags() {
+	x.paired = make(map[int]bool)
+	open := make(map[string][]int) // offsets of unpaired opening tags, by name
+	pos := 0
+	for {
+		loc := shortcodeOpenRe.FindStringIndex(x.src[pos:])
+		if loc == nil {
+			return
+		}
+		tag, err := x.parseTag(pos + loc[0])
+		if err != nil {
+			pos += loc[0] + 3
+			continue
+		}
+		pos = tag.end
+		switch {
+		case tag.literal != "" || tag.selfClosing:
+		case tag.closing:
+			if starts := open[tag.name]; len(starts) > 0 {
+				x.paired[starts[len(starts)-1]] = true
+				open[tag.name] = starts[:len(starts)-1]
+			}
+		default:
+			open[tag.name] = append(open[tag.name], tag.start)
+		}
+	}
+}
+
+// restore swaps the HTML output of {{< >}} calls back in for their
+// placeholders, dropping the paragraph goldmark wraps around a placeholder
+// that stands on a line of its own. With textOnly, as for the table of
+// contents, whose entries are links, only the text of the output is kept.
+func (x *shortcodeExpander) restore(b []byte, textOnly bool) []byte {
+	if len(x.html) == 0 || len(b) == 0 {
+		return b
+	}
+	// Later placeholders first, so that FORGESHORTCODE1X does not match
+	// the start of FORGESHORTCODE10X.
+	for i := len(x.html) - 1; i >= 0; i-- {
+		ph := []byte(fmt.Sprintf(shortcodePlaceholder, i))
+		out := []byte(x.html[i])
+		if textOnly {
+			out = []byte(strings.TrimSpace(StripHTMLTags(x.html[i])))
+		}
+		b = bytes.ReplaceAll(b, slices.Concat([]byte("<p>"), ph, []byte("</p>")), out)
+		b = bytes.ReplaceAll(b, ph, out)
+	}
+	return b
+}
+
+// inCode reports whether offset off in src is in a code span or fenced
+// code block.
+func (x *shortcodeExpander) inCode(off int) bool {
+	i := sort.Search(len(x.code), func(i int) bool { return x.code[i][1] > off })
+	return i < len(x.code) && x.code[i][0] <= off
+}
+
+// line returns the source file line of offset off in src.
+func (x *shortcodeExpander) line(off int) int {
+	return x.firstLine + strings.Count(x.src[:off], "\n")
+}
+
+// errorf returns a *ShortcodeError for the tag at offset off.
+func (x *shortcodeExpander) errorf(off int, name, format string, args ...any) error {
+	return &ShortcodeError{Path: x.path, Line: x.line(off), Name: name, Err: fmt.Errorf(format, args...)}
+}
+
+// parseShortcodeArgs parses the arguments of a shortcode tag: either all
+// named, key=value, or all positional. Values are bare words or quoted
+// with "..." (which understands Go escapes) or `...`.
+func parseShortcodeArgs(s string) (map[string]string, error) {
+	params := make(map[string]string)
+	named, positional := false, 0
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		if s == "" {
+			return params, nil
+		}
+
+		key := ""
+		if i := strings.IndexAny(s, "= \t\r\n\"`"); i > 0 && s[i] == '=' {
+			key, s = s[:i], s[i+1:]
+		}
+		value, rest, err := cutShortcodeValue(s)
+		if err != nil {
+			return nil, err
+		}
+		s = rest
+
+		if key != "" {
+			if positional > 0 {
+				return nil, fmt.Errorf("cannot mix named and positional arguments")
+			}
+			named = true
+			params[key] = value
+			continue
+		}
+		if named {
+			return nil, fmt.Errorf("cannot mix named and positional arguments")
+		}
+		params[strconv.Itoa(positional)] = value
+		positional++
+	}
+}
+
+// cutShortcodeValue reads one argument value from the start of s and
+// returns it with the rest of s.
+func cutShortcodeValue(s string) (value, rest string, err error) {
+	if s == "" {
+		return "", "", nil
+	}
+	switch s[0] {
+	case '"':
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				value, err := strconv.Unquote(s[:i+1])
+				if err != nil {
+					return "", "", fmt.Errorf("invalid quoted value %s", s[:i+1])
+				}
+				return value, s[i+1:], nil
+			}
+		}
+		return "", "", fmt.Errorf("unterminated quoted value %s", s)
+	case '`':
+		end := strings.IndexByte(s[1:], '`')
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted value %s", s)
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	end := strings.IndexAny(s, " \t\r\n")
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[end:], nil
+}
+
+// trimLineBreak removes one line break from each end of s.
+func trimLineBreak(s string) string {
+	if t, ok := strings.CutPrefix(s, "\n"); ok {
+		s = t
+	} else {
+		s = strings.TrimPrefix(s, "\r\n")
+	}
+	if t, ok := strings.CutSuffix(s, "\r\n"); ok {
+		return t
+	}
+	return strings.TrimSuffix(s, "\n")
+}
+
+// codeRanges returns the fenced code blocks and code spans of the Markdown
+// src as [start, end) byte ranges, in order. A fence is a line of three or
+// more backticks or tildes, indented by at most three spaces, and runs to a
+// line of at least as many of the same character, or to the end of src; a
+// code span runs from a run of backticks to the next run of the same length.
+func codeRanges(src string) [][2]int {
+	var ranges [][2]int
+	text := 0 // start of the text after the last fenced block
+	var fence byte
+	var fenceLen, fenceStart int
+	for off := 0; off < len(src); {
+		end := len(src)
+		if i := strings.IndexByte(src[off:], '\n'); i >= 0 {
+			end = off + i + 1
+		}
+		line := strings.TrimLeft(src[off:end], " ")
+		if c, n := fenceRun(line); n >= 3 && len(src[off:end])-len(line) <= 3 {
+			switch {
+			case fence == 0 && !(c == '`' && strings.ContainsRune(line[n:], '`')):
+				ranges = append(ranges, codeSpans(src, text, off)...)
+				fence, fenceLen, fenceStart = c, n, off
+			case c == fence && n >= fenceLen && strings.TrimSpace(line[n:]) == "":
+				ranges = append(ranges, [2]int{fenceStart, end})
+				fence, text = 0, end
+			}
+		}
+		off = end
+	}
+	if fence != 0 {
+		return append(ranges, [2]int{fenceStart, len(src)})
+	}
+	return append(ranges, codeSpans(src, text, len(src))...)
+}
+
+// fenceRun returns the character and length of the run of backticks or
+// tildes s starts with.
+func fenceRun(s string) (byte, int) {
+	if s == "" || (s[0] != '`' && s[0] != '~') {
+		return 0, 0
+	}
+	n := 1
+	for n < len(s) && s[n] == s[0] {
+		n++
+	}
+	return s[0], n
+}
+
+// codeSpans returns the code spans in src[from:to].
+func codeSpans(src string, from, to int) [][2]int {
+	var spans [][2]int
+	run := func(i int) int {
+		n := 1
+		for i+n < to && src[i+n] == '`' {
+			n++
+		}
+		return n
+	}
+	for i := from; i < to; {
+		if src[i] != '`' {
+			i++
+			continue
+		}
+		n := run(i)
+		closing := -1
+		for j := i + n; j < to; {
+			if src[j] != '`' {
+				j++
+				continue
+			}
+			m := run(j)
+			if m == n {
+				closing = j
+				break
+			}
+			j += m
+		}
+		if closing < 0 {
+			i += n
+			continue
+		}
+		spans = append(spans, [2]int{i, closing + n})
+		i = closing + n
+	}
+	return spans
+}
+
+// shortcodeIDs generates auto heading IDs without the placeholders that
+// stand in for shortcode output, so that "## Setup {{< badge >}}" gets the
+// ID "setup".
+type shortcodeIDs struct {
+	parser.IDs
+}
+
+func (ids shortcodeIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	return ids.IDs.Generate(shortcodePlaceholderRe.ReplaceAll(value, nil), kind)
+}
+
+// newParserContext returns the context Markdown is parsed with, which
+// keeps shortcode placeholders out of auto heading IDs.
+func newParserContext() parser.Context {
+	return parser.NewContext(parser.WithIDs(shortcodeIDs{parser.NewContext().IDs()}))
+}
@@ -0,0 +1,218 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// echoShortcode renders a call as name(params)[inner], or as **name** for a
+// {{% %}} call without inner content.
+func echoShortcode(sc *Shortcode) (string, error) {
+	switch sc.Name {
+	case "fail":
+		return "", errors.New("template failed")
+	case "unknown":
+		return "", fmt.Errorf("%w: no template", ErrUnknownShortcode)
+	}
+	if sc.Markdown && sc.Inner == "" {
+		return "**" + sc.Name + "**", nil
+	}
+	var args []string
+	for _, k := range slices.Sorted(maps.Keys(sc.Params)) {
+		args = append(args, k+"="+sc.Params[k])
+	}
+	return fmt.Sprintf("<span>%s(%s)[%s]</span>", sc.Name, strings.Join(args, ","), sc.Inner), nil
+}
+
+func TestRenderPage_Shortcodes(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{
+			name: "positional",
+			src:  `Watch {{< video abc "two words" >}} now.`,
+			want: "<p>Watch <span>video(0=abc,1=two words)[]</span> now.</p>\n",
+		},
+		{
+			name: "named",
+			src:  "{{< figure src=\"/a.png\" alt=`An \"image\"` >}}",
+			want: "<span>figure(alt=An \"image\",src=/a.png)[]</span>\n",
+		},
+		{
+			name: "paired",
+			src:  "{{< box >}}\n*raw*\n{{< /box >}}",
+			want: "<span>box()[*raw*]</span>\n",
+		},
+		{
+			name: "self-closing",
+			src:  "{{< box />}} and {{< box >}}x{{< /box >}}",
+			want: "<p><span>box()[]</span> and <span>box()[x]</span></p>\n",
+		},
+		{
+			name: "standalone before a paired call of the same name",
+			src:  "{{< note >}} a {{< note >}}b{{< /note >}}",
+			want: "<p><span>note()[]</span> a <span>note()[b]</span></p>\n",
+		},
+		{
+			name: "paired calls of the same name nested",
+			src:  "{{< box >}}{{< box >}}x{{< /box >}}{{< /box >}}",
+			want: "<span>box()[<span>box()[x]</span>]</span>\n",
+		},
+		{
+			name: "markdown output",
+			src:  "A {{% strong %}} word.",
+			want: "<p>A <strong>strong</strong> word.</p>\n",
+		},
+		{
+			name: "markdown inner with nested html call",
+			src:  "{{% box %}}\n*em* {{< tag />}}\n{{% /box %}}",
+			want: "<p><span>box()[<em>em</em> <span>tag()[]</span>]</span></p>\n",
+		},
+		{
+			name: "nested",
+			src:  "{{< outer >}}{{< inner x >}}{{< /outer >}}",
+			want: "<span>outer()[<span>inner(0=x)[]</span>]</span>\n",
+		},
+		{
+			name: "commented out",
+			src:  "`{{</* video abc */>}}`",
+			want: "<p><code>{{&lt; video abc &gt;}}</code></p>\n",
+		},
+		{
+			name: "template sample in a code span",
+			src:  "Write `{{< .Title >}}` or `{{%` here.",
+			want: "<p>Write <code>{{&lt; .Title &gt;}}</code> or <code>{{%</code> here.</p>\n",
+		},
+		{
+			name: "unknown shortcode in a fenced code block",
+			src:  "```\n{{< unknown x >}}y{{< /unknown >}}\n```\n\n{{< tag />}}",
+			want: "<pre><code>{{&lt; unknown x &gt;}}y{{&lt; /unknown &gt;}}\n</code></pre>\n<span>tag()[]</span>\n",
+		},
+		{
+			name: "known shortcode in a code span",
+			src:  "`{{< tag >}}`",
+			want: "<p><code><span>tag()[]</span></code></p>\n",
+		},
+	}
+	r := NewMarkdownRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Page{RawContent: tt.src}
+			got, _, err := r.RenderPage(p, echoShortcode, nil)
+			if err != nil {
+				t.Fatalf("RenderPage() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("RenderPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPage_ShortcodeNames(t *testing.T) {
+	p := &Page{RawContent: "{{< b >}} {{< a >}} {{< b >}}"}
+	if _, _, err := NewMarkdownRenderer().RenderPage(p, echoShortcode, nil); err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	if want := []string{"a", "b"}; !slices.Equal(p.Shortcodes, want) {
+		t.Errorf("Shortcodes = %v, want %v", p.Shortcodes, want)
+	}
+
+	// Without a ShortcodeFunc calls stay text.
+	got, _, err := NewMarkdownRenderer().RenderPage(p, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	if !strings.Contains(string(got), "{{&lt; a &gt;}}") || p.Shortcodes != nil {
+		t.Errorf("RenderPage(nil) = %q, Shortcodes %v", got, p.Shortcodes)
+	}
+}
+
+func TestRenderPage_ShortcodeErrors(t *testing.T) {
+	tests := []struct {
+		name, src string
+		line      int
+		want      string
+	}{
+		{"render error", "text\n\n{{< fail >}}", 7, `post.md:7: shortcode "fail": template failed`},
+		{"unterminated tag", "{{< video abc", 5, `post.md:5: shortcode: missing ">}}"`},
+		{"missing closing tag", "{{< a >}}\n{{< b >}}{{< /a >}}{{< /b >}}", 6, `post.md:6: shortcode "a": unexpected closing tag: "b" is still open`},
+		{"stray closing tag", "x\n{{< /box >}}", 6, `post.md:6: shortcode "box": closing tag without an opening tag`},
+		{"mixed arguments", `{{< video abc start=10 >}}`, 5, `post.md:5: shortcode "video": cannot mix named and positional arguments`},
+		{"invalid name", `{{< "video" >}}`, 5, `post.md:5: shortcode: invalid name "\"video\""`},
+		{"unterminated quote", `{{< video id="abc >}}`, 5, `post.md:5: shortcode "video": unterminated quoted value "abc`},
+		{"unknown shortcode", "{{< unknown >}}", 5, `post.md:5: shortcode "unknown": unknown shortcode: no template`},
+		{"unknown shortcode after a code span", "`code` {{< unknown >}}", 5, `post.md:5: shortcode "unknown": unknown shortcode: no template`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Page{RawContent: tt.src, SourcePath: "post.md", ContentLine: 5}
+			_, _, err := NewMarkdownRenderer().RenderPage(p, echoShortcode, nil)
+			var scErr *ShortcodeError
+			if !errors.As(err, &scErr) {
+				t.Fatalf("RenderPage() error = %v, want a *ShortcodeError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("error = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPage_ShortcodeInHeading(t *testing.T) {
+	p := &Page{RawContent: "## Setup {{< badge >}}\n\nText.\n"}
+	badge := func(sc *Shortcode) (string, error) { return `<span class="badge">new</span>`, nil }
+	htmlOut, tocOut, err := NewMarkdownRenderer().RenderPage(p, badge, nil)
+	if err != nil {
+		t.Fatalf("RenderPage() error: %v", err)
+	}
+	if want := `<h2 id="setup">Setup <span class="badge">new</span></h2>`; !strings.Contains(string(htmlOut), want) {
+		t.Errorf("content = %q, want it to contain %q", htmlOut, want)
+	}
+	if want := `<a href="#setup">Setup new</a>`; !strings.Contains(string(tocOut), want) {
+		t.Errorf("TOC = %q, want it to contain %q", tocOut, want)
+	}
+	for _, out := range [][]byte{htmlOut, tocOut} {
+		if bytes.Contains(bytes.ToLower(out), []byte("forgeshortcode")) {
+			t.Errorf("placeholder leaked into %q", out)
+		}
+	}
+}
+
+func TestCodeRanges(t *testing.T) {
+	src := "a `b` c\n```go\nx ``` y\n~~~\n```\n``d`` `e\n~~~~\nf\n"
+	var got []string
+	for _, r := range codeRanges(src) {
+		got = append(got, src[r[0]:r[1]])
+	}
+	want := []string{"`b`", "```go\nx ``` y\n~~~\n```\n", "``d``", "~~~~\nf\n"}
+	if !slices.Equal(got, want) {
+		t.Errorf("codeRanges() = %q, want %q", got, want)
+	}
+}
+
+func TestParseShortcodeArgs(t *testing.T) {
+	tests := []struct {
+		args string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{` a "b c" ` + "`d`", map[string]string{"0": "a", "1": "b c", "2": "d"}},
+		{`id=x title="Say \"hi\"" empty=""`, map[string]string{"id": "x", "title": `Say "hi"`, "empty": ""}},
+	}
+	for _, tt := range tests {
+		got, err := parseShortcodeArgs(tt.args)
+		if err != nil {
+			t.Errorf("parseShortcodeArgs(%q) error: %v", tt.args, err)
+			continue
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("parseShortcodeArgs(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package content
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 // Taxonomy holds all terms and their associated pages for a taxonomy type.
@@ -11,11 +14,17 @@ type Taxonomy struct {
 	Name     string             // e.g., "tags"
 	Singular string             // e.g., "tag"
 	Terms    map[string][]*Page // term -> pages
+
+	// Merged maps a term to the other terms folded into it because they
+	// share its URL, e.g. "c" -> ["c++"] (see TermSlug).
+	Merged map[string][]string
 }
 
 // BuildTaxonomies creates taxonomy maps from all pages based on config.
 // The taxonomies parameter maps plural names to singular names,
-// e.g., {"tags": "tag", "categories": "category"}.
+// e.g., {"tags": "tag", "categories": "category"}. Terms are lowercased,
+// and terms that would share a URL, such as "c" and "c++", are merged into
+// the one that sorts first (see Taxonomy.Merged).
 func BuildTaxonomies(pages []*Page, taxonomies map[string]string) map[string]*Taxonomy {
 	result := make(map[string]*Taxonomy, len(taxonomies))
 
@@ -53,6 +62,8 @@ func BuildTaxonomies(pages []*Page, taxonomies map[string]string) map[string]*Ta
 			}
 		}
 
+		mergeSlugCollisions(tax)
+
 		// Sort pages within each term by date, newest first.
 		for term := range tax.Terms {
 			SortByDate(tax.Terms[term], false)
@@ -64,10 +75,41 @@ func BuildTaxonomies(pages []*Page, taxonomies map[string]string) map[string]*Ta
 	return result
 }
 
+// mergeSlugCollisions folds each term whose URL segment (termSegment) is
+// already taken by a term that sorts before it into that term, so both are
+// listed on one term page instead of claiming the same URL, and records the
+// fold in tax.Merged.
+func mergeSlugCollisions(tax *Taxonomy) {
+	kept := make(map[string]string) // segment -> term that owns it
+	for _, term := range slices.Sorted(maps.Keys(tax.Terms)) {
+		seg := termSegment(term)
+		owner, ok := kept[seg]
+		if !ok {
+			kept[seg] = term
+			continue
+		}
+		for _, p := range tax.Terms[term] {
+			if !slices.Contains(tax.Terms[owner], p) {
+				tax.Terms[owner] = append(tax.Terms[owner], p)
+			}
+		}
+		delete(tax.Terms, term)
+		if tax.Merged == nil {
+			tax.Merged = make(map[string][]string)
+		}
+		tax.Merged[owner] = append(tax.Merged[owner], term)
+	}
+}
+
 // GenerateTaxonomyPages creates virtual pages for taxonomy listings.
 // For each taxonomy (e.g., tags), it creates:
 //   - A terms page at /tags/ (lists all tags) with PageTypeTaxonomyList
 //   - A term page at /tags/go/ (lists pages with tag "go") with PageTypeTaxonomy
+//
+// Term pages live at the term's slug. When that differs from the term as
+// written (e.g. /tags/machine-learning/ for "machine learning"), or other
+// terms were merged into it, the unslugged paths become aliases so links to
+// them keep working.
 func GenerateTaxonomyPages(taxonomies map[string]*Taxonomy) []*Page {
 	var pages []*Page
 
@@ -101,9 +143,17 @@ func GenerateTaxonomyPages(taxonomies map[string]*Taxonomy) []*Page {
 		// Create a page for each term (e.g., /tags/go/).
 		for _, term := range termNames {
 			termPages := tax.Terms[term]
+			url := TermPermalink(nil, name, term)
+			var aliases []string
+			for _, t := range append([]string{term}, tax.Merged[term]...) {
+				if old := TaxonomyTermURL(name, t); old != url {
+					aliases = append(aliases, old)
+				}
+			}
 			termPage := &Page{
 				Title:   term,
-				URL:     fmt.Sprintf("/%s/%s/", name, term),
+				URL:     url,
+				Aliases: aliases,
 				Type:    PageTypeTaxonomy,
 				Section: name,
 				Params: map[string]any{
@@ -119,6 +169,33 @@ func GenerateTaxonomyPages(taxonomies map[string]*Taxonomy) []*Page {
 	return pages
 }
 
+// TermSlug returns the URL path segment of a taxonomy term: lowercase, with
+// each run of characters other than letters and digits turned into one
+// hyphen, e.g. "machine-learning" for "Machine Learning".
+func TermSlug(term string) string {
+	var b strings.Builder
+	prevHyphen := false
+	for _, r := range strings.ToLower(term) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			prevHyphen = false
+		} else if !prevHyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			prevHyphen = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
+}
+
+// termSegment returns the URL path segment of the page for term: its
+// TermSlug, or the term itself if it has no letters or digits to slug.
+func termSegment(term string) string {
+	if slug := TermSlug(term); slug != "" {
+		return slug
+	}
+	return term
+}
+
 // capitalizeFirst returns s with the first letter uppercased.
 func capitalizeFirst(s string) string {
 	if s == "" {
@@ -184,6 +184,49 @@ func TestBuildTaxonomies_SkipsEmptyTerms(t *testing.T) {
 	}
 }
 
+func TestBuildTaxonomies_MergesTermsWithSameSlug(t *testing.T) {
+	both := newPage("Both", withTags("c", "c++"))
+	pages := []*Page{
+		newPage("C", withTags("C")),
+		newPage("C++", withTags("C++")),
+		both,
+		newPage("Node", withTags("node.js")),
+		newPage("Node Too", withTags("node-js")),
+	}
+
+	result := BuildTaxonomies(pages, defaultTaxonomies())
+	tags := result["tags"]
+
+	if got := len(tags.Terms); got != 2 {
+		t.Fatalf("tags taxonomy has %d terms, want 2 (c and node-js)", got)
+	}
+	if got := len(tags.Terms["c"]); got != 3 {
+		t.Errorf("tags['c'] has %d pages, want 3 (a page tagged both is listed once)", got)
+	}
+	if got := len(tags.Terms["node-js"]); got != 2 {
+		t.Errorf("tags['node-js'] has %d pages, want 2", got)
+	}
+	if got := tags.Merged["c"]; !equalStrings(got, []string{"c++"}) {
+		t.Errorf("Merged['c'] = %v, want [c++]", got)
+	}
+	if got := tags.Merged["node-js"]; !equalStrings(got, []string{"node.js"}) {
+		t.Errorf("Merged['node-js'] = %v, want [node.js]", got)
+	}
+
+	var urls []string
+	for _, p := range GenerateTaxonomyPages(result) {
+		if p.Type == PageTypeTaxonomy && p.Section == "tags" {
+			urls = append(urls, p.URL)
+			if p.Title == "c" && !equalStrings(p.Aliases, []string{"/tags/c++/"}) {
+				t.Errorf("c term page aliases = %v, want [/tags/c++/]", p.Aliases)
+			}
+		}
+	}
+	if want := []string{"/tags/c/", "/tags/node-js/"}; !equalStrings(urls, want) {
+		t.Errorf("term page URLs = %v, want %v", urls, want)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Tests: GenerateTaxonomyPages
 // ---------------------------------------------------------------------------
@@ -378,3 +421,31 @@ func TestGenerateTaxonomyPages_TaxonomyWithNoTerms(t *testing.T) {
 		t.Errorf("page Title = %q, want %q", pages[0].Title, "Tags")
 	}
 }
+
+func TestGenerateTaxonomyPages_AliasesUnsluggedTerm(t *testing.T) {
+	taxonomies := map[string]*Taxonomy{
+		"tags": {
+			Name:     "tags",
+			Singular: "tag",
+			Terms: map[string][]*Page{
+				"go":               {newPage("Post A")},
+				"machine learning": {newPage("Post B")},
+			},
+		},
+	}
+
+	pages := GenerateTaxonomyPages(taxonomies)
+
+	for _, p := range pages[1:] {
+		switch p.Title {
+		case "go":
+			if p.URL != "/tags/go/" || len(p.Aliases) != 0 {
+				t.Errorf("go term page: URL %q, aliases %v; want /tags/go/ and none", p.URL, p.Aliases)
+			}
+		case "machine learning":
+			if p.URL != "/tags/machine-learning/" || !equalStrings(p.Aliases, []string{"/tags/machine learning/"}) {
+				t.Errorf("machine learning term page: URL %q, aliases %v; want /tags/machine-learning/ and [/tags/machine learning/]", p.URL, p.Aliases)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package content
+
+import (
+	neturl "net/url"
+	"strings"
+)
+
+// UglyURL converts a clean, trailing-slash URL to the form used when
+// build.cleanUrls is false, for sites hosted on plain file servers. Pages
+// that list other pages (list is true) keep their directory and link to its
+// index.html; other pages become an .html file named after their last path
+// segment.
+//
+//	UglyURL("/blog/my-post/", false) -> "/blog/my-post.html"
+//	UglyURL("/blog/", true)          -> "/blog/index.html"
+//	UglyURL("/", true)               -> "/index.html"
+//
+// URLs that already end in ".html" are returned unchanged.
+func UglyURL(url string, list bool) string {
+	if strings.HasSuffix(url, ".html") {
+		return url
+	}
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	if list || url == "/" {
+		return url + "index.html"
+	}
+	return strings.TrimSuffix(url, "/") + ".html"
+}
+
+// DirURL returns the directory a page's URL resolves to, where its bundle
+// resources and child pages live. Clean URLs are returned unchanged.
+//
+//	DirURL("/blog/my-post.html")  -> "/blog/my-post/"
+//	DirURL("/blog/index.html")    -> "/blog/"
+//	DirURL("/blog/my-post/")      -> "/blog/my-post/"
+func DirURL(url string) string {
+	if dir, ok := strings.CutSuffix(url, "/index.html"); ok {
+		return dir + "/"
+	}
+	if dir, ok := strings.CutSuffix(url, ".html"); ok {
+		return dir + "/"
+	}
+	return url
+}
+
+// TaxonomyTermURL returns the clean URL of a taxonomy term page,
+// e.g. "/tags/go/".
+func TaxonomyTermURL(taxonomy, term string) string {
+	return "/" + taxonomy + "/" + term + "/"
+}
+
+// UseUglyURLs rewrites the URL of each page to its UglyURL form. Home,
+// section, and taxonomy pages are treated as lists.
+func UseUglyURLs(pages []*Page) {
+	for _, p := range pages {
+		p.URL = UglyURL(p.URL, p.Type != PageTypeSingle)
+	}
+}
+
+// BasePath returns the path a site is hosted under, derived from its base
+// URL without a trailing slash: "/docs" for "https://example.com/docs/", and
+// "" for a site served from the root.
+func BasePath(baseURL string) string {
+	u, err := neturl.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(u.Path, "/")
+}
+
+// WithBasePath prefixes the root-relative URL u with basePath, e.g.
+// "/blog/" -> "/docs/blog/". Absolute, protocol-relative, and relative URLs
+// are returned unchanged.
+func WithBasePath(basePath, u string) string {
+	if basePath == "" || !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") {
+		return u
+	}
+	return basePath + u
+}
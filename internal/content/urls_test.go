@@ -0,0 +1,87 @@
+package content
+
+import "testing"
+
+func TestUglyURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		list bool
+		want string
+	}{
+		{"/blog/my-post/", false, "/blog/my-post.html"},
+		{"/about/", false, "/about.html"},
+		{"/blog/", true, "/blog/index.html"},
+		{"/tags/go/", true, "/tags/go/index.html"},
+		{"/", true, "/index.html"},
+		{"/", false, "/index.html"},
+		{"/old-post", false, "/old-post.html"},
+		{"/blog/my-post.html", false, "/blog/my-post.html"},
+	}
+	for _, tt := range tests {
+		if got := UglyURL(tt.url, tt.list); got != tt.want {
+			t.Errorf("UglyURL(%q, %v) = %q, want %q", tt.url, tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestDirURL(t *testing.T) {
+	tests := map[string]string{
+		"/blog/my-post.html": "/blog/my-post/",
+		"/blog/index.html":   "/blog/",
+		"/index.html":        "/",
+		"/blog/my-post/":     "/blog/my-post/",
+		"/":                  "/",
+	}
+	for url, want := range tests {
+		if got := DirURL(url); got != want {
+			t.Errorf("DirURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestPagerURL_Ugly(t *testing.T) {
+	if got := PagerURL("/blog/index.html", 1); got != "/blog/index.html" {
+		t.Errorf("PagerURL(page 1) = %q", got)
+	}
+	if got := PagerURL("/blog/index.html", 3); got != "/blog/page/3.html" {
+		t.Errorf("PagerURL(page 3) = %q, want /blog/page/3.html", got)
+	}
+	if got := PagerURL("/index.html", 2); got != "/page/2.html" {
+		t.Errorf("PagerURL(home page 2) = %q, want /page/2.html", got)
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com":       "",
+		"https://example.com/":      "",
+		"https://example.com/docs":  "/docs",
+		"https://example.com/docs/": "/docs",
+		"https://example.com/a/b/":  "/a/b",
+		"":                          "",
+	}
+	for baseURL, want := range tests {
+		if got := BasePath(baseURL); got != want {
+			t.Errorf("BasePath(%q) = %q, want %q", baseURL, got, want)
+		}
+	}
+}
+
+func TestWithBasePath(t *testing.T) {
+	tests := []struct {
+		base, url, want string
+	}{
+		{"/docs", "/blog/", "/docs/blog/"},
+		{"/docs", "/", "/docs/"},
+		{"", "/blog/", "/blog/"},
+		{"/docs", "https://example.com/x", "https://example.com/x"},
+		{"/docs", "//cdn.example.com/x.js", "//cdn.example.com/x.js"},
+		{"/docs", "hero.jpg", "hero.jpg"},
+		{"/docs", "#top", "#top"},
+	}
+	for _, tt := range tests {
+		if got := WithBasePath(tt.base, tt.url); got != tt.want {
+			t.Errorf("WithBasePath(%q, %q) = %q, want %q", tt.base, tt.url, got, tt.want)
+		}
+	}
+}
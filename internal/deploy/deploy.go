@@ -6,10 +6,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"mime"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/aellingwood/forge/internal/buildmanifest"
 )
 
 // URLRewriteFunctionCode is the CloudFront Function (cloudfront-js-2.0) source
@@ -43,6 +46,7 @@ type DeployConfig struct {
 	URLRewrite       bool                  // whether to manage a CloudFront URL rewrite function
 	SecurityHeaders  bool                  // whether to manage a CloudFront response headers policy
 	SecurityHeadersCfg ResponseHeadersConfig // security header values (used when SecurityHeaders is true)
+	Manifest         *buildmanifest.Manifest  // build manifest of the public dir (optional; supplies provenance)
 	DryRun           bool
 	Verbose          bool
 }
@@ -61,6 +65,7 @@ type FileEntry struct {
 	ContentType  string // MIME type
 	CacheControl string // Cache-Control header value
 	Hash         string // hex-encoded SHA-256 hash
+	Producer     string // what built the file, from the build manifest (empty if unknown)
 }
 
 // S3Client is an interface for S3 operations used during deployment.
@@ -94,66 +99,10 @@ type CloudFrontHeadersPolicyClient interface {
 }
 
 // ContentTypeForExt returns the MIME type for a file extension.
-// The ext parameter should include the leading dot (e.g. ".html").
+// The ext parameter should include the leading dot (e.g. ".html"). It is
+// the type the build manifest records (see buildmanifest.ContentTypeForExt).
 func ContentTypeForExt(ext string) string {
-	ext = strings.ToLower(ext)
-
-	// Well-known types that we want to be explicit about
-	switch ext {
-	case ".html", ".htm":
-		return "text/html; charset=utf-8"
-	case ".css":
-		return "text/css; charset=utf-8"
-	case ".js", ".mjs":
-		return "application/javascript; charset=utf-8"
-	case ".json":
-		return "application/json; charset=utf-8"
-	case ".xml":
-		return "application/xml; charset=utf-8"
-	case ".svg":
-		return "image/svg+xml"
-	case ".png":
-		return "image/png"
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".gif":
-		return "image/gif"
-	case ".webp":
-		return "image/webp"
-	case ".avif":
-		return "image/avif"
-	case ".ico":
-		return "image/x-icon"
-	case ".woff":
-		return "font/woff"
-	case ".woff2":
-		return "font/woff2"
-	case ".ttf":
-		return "font/ttf"
-	case ".otf":
-		return "font/otf"
-	case ".pdf":
-		return "application/pdf"
-	case ".txt":
-		return "text/plain; charset=utf-8"
-	case ".csv":
-		return "text/csv; charset=utf-8"
-	case ".mp4":
-		return "video/mp4"
-	case ".webm":
-		return "video/webm"
-	case ".mp3":
-		return "audio/mpeg"
-	case ".wasm":
-		return "application/wasm"
-	}
-
-	// Fall back to the standard library
-	ct := mime.TypeByExtension(ext)
-	if ct != "" {
-		return ct
-	}
-	return "application/octet-stream"
+	return buildmanifest.ContentTypeForExt(ext)
 }
 
 // CacheControlForExt returns the Cache-Control header for a file extension.
@@ -161,16 +110,15 @@ func ContentTypeForExt(ext string) string {
 //
 // Policy:
 //   - HTML files: "public, max-age=0, must-revalidate"
-//   - CSS/JS files: "public, max-age=31536000, immutable" (fingerprinted)
+//   - CSS/JS files: "public, max-age=0, must-revalidate" (see CacheControlForPath
+//     for fingerprinted names)
 //   - Image files: "public, max-age=86400"
 //   - Other files: "public, max-age=3600"
 func CacheControlForExt(ext string) string {
 	ext = strings.ToLower(ext)
 	switch ext {
-	case ".html", ".htm":
+	case ".html", ".htm", ".css", ".js", ".mjs":
 		return "public, max-age=0, must-revalidate"
-	case ".css", ".js", ".mjs":
-		return "public, max-age=31536000, immutable"
 	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico":
 		return "public, max-age=86400"
 	default:
@@ -178,6 +126,26 @@ func CacheControlForExt(ext string) string {
 	}
 }
 
+// fingerprintedNameRe matches file names carrying the content hash added by
+// asset fingerprinting, e.g. "css/style.3f9a1c2b7d4e.css".
+var fingerprintedNameRe = regexp.MustCompile(`\.[0-9a-f]{12}\.[a-z]+$`)
+
+// CacheControlForPath returns the Cache-Control header for a file in the
+// output directory, given its slash-separated relative path. CSS and JS files
+// whose name carries a content hash never change in place, so they are cached
+// as immutable; without a hash they revalidate so a deploy takes effect
+// immediately. Other files follow CacheControlForExt.
+func CacheControlForPath(relPath string) string {
+	ext := strings.ToLower(path.Ext(relPath))
+	switch ext {
+	case ".css", ".js", ".mjs":
+		if fingerprintedNameRe.MatchString(strings.ToLower(relPath)) {
+			return "public, max-age=31536000, immutable"
+		}
+	}
+	return CacheControlForExt(ext)
+}
+
 // HashFile computes the SHA-256 hash of a file and returns it as a hex string.
 func HashFile(path string) (string, error) {
 	f, err := os.Open(path)
@@ -195,8 +163,10 @@ func HashFile(path string) (string, error) {
 
 // ScanFiles walks the public directory and returns a list of FileEntry.
 // It determines Content-Type from file extension and sets Cache-Control
-// based on the file type.
-func ScanFiles(publicDir string) ([]FileEntry, error) {
+// based on the file type. Every file is hashed; producers are taken from
+// manifest, if non-nil, for files whose content is what the build that
+// wrote it recorded.
+func ScanFiles(publicDir string, manifest *buildmanifest.Manifest) ([]FileEntry, error) {
 	var entries []FileEntry
 
 	err := filepath.Walk(publicDir, func(path string, info os.FileInfo, err error) error {
@@ -219,12 +189,17 @@ func ScanFiles(publicDir string) ([]FileEntry, error) {
 		if err != nil {
 			return err
 		}
+		var producer string
+		if mf, ok := manifest.Lookup(relPath, hash); ok {
+			producer = mf.Producer.String()
+		}
 
 		entries = append(entries, FileEntry{
 			Path:         relPath,
 			ContentType:  ContentTypeForExt(ext),
-			CacheControl: CacheControlForExt(ext),
+			CacheControl: CacheControlForPath(relPath),
 			Hash:         hash,
+			Producer:     producer,
 		})
 		return nil
 	})
@@ -280,7 +255,7 @@ func Deploy(ctx context.Context, cfg DeployConfig, publicDir string, s3 S3Client
 	result := &DeployResult{}
 
 	// 1. Scan local files
-	localFiles, err := ScanFiles(publicDir)
+	localFiles, err := ScanFiles(publicDir, cfg.Manifest)
 	if err != nil {
 		return nil, fmt.Errorf("scanning local files: %w", err)
 	}
@@ -295,12 +270,17 @@ func Deploy(ctx context.Context, cfg DeployConfig, publicDir string, s3 S3Client
 	toUpload, toDelete := DiffFiles(localFiles, remoteHashes)
 	result.Skipped = len(localFiles) - len(toUpload)
 
-	// 4. Dry run
+	// 4. Dry run. Uploads are listed with the producer the build manifest
+	// records for them.
 	if cfg.DryRun {
-		if cfg.Verbose {
-			for _, f := range toUpload {
+		for _, f := range toUpload {
+			if f.Producer != "" {
+				fmt.Printf("[dry-run] upload: %s (%s) from %s\n", f.Path, f.ContentType, f.Producer)
+			} else {
 				fmt.Printf("[dry-run] upload: %s (%s)\n", f.Path, f.ContentType)
 			}
+		}
+		if cfg.Verbose {
 			for _, key := range toDelete {
 				fmt.Printf("[dry-run] delete: %s\n", key)
 			}
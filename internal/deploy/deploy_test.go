@@ -10,6 +10,9 @@ import (
 	"path/filepath"
 	"sort"
 	"testing"
+	"time"
+
+	"github.com/aellingwood/forge/internal/buildmanifest"
 )
 
 // mockS3Client for testing
@@ -135,7 +138,7 @@ func TestScanFiles(t *testing.T) {
 	createTempFile(t, dir, "images/logo.png", "fakepngdata")
 	createTempFile(t, dir, "blog/post.html", "<html>post</html>")
 
-	entries, err := ScanFiles(dir)
+	entries, err := ScanFiles(dir, nil)
 	if err != nil {
 		t.Fatalf("ScanFiles failed: %v", err)
 	}
@@ -173,7 +176,7 @@ func TestScanFiles(t *testing.T) {
 	if e.ContentType != "text/css; charset=utf-8" {
 		t.Errorf("expected text/css; charset=utf-8, got %s", e.ContentType)
 	}
-	if e.CacheControl != "public, max-age=31536000, immutable" {
+	if e.CacheControl != "public, max-age=0, must-revalidate" {
 		t.Errorf("expected CSS cache control, got %s", e.CacheControl)
 	}
 
@@ -185,7 +188,7 @@ func TestScanFiles(t *testing.T) {
 	if e.ContentType != "application/javascript; charset=utf-8" {
 		t.Errorf("expected application/javascript; charset=utf-8, got %s", e.ContentType)
 	}
-	if e.CacheControl != "public, max-age=31536000, immutable" {
+	if e.CacheControl != "public, max-age=0, must-revalidate" {
 		t.Errorf("expected JS cache control, got %s", e.CacheControl)
 	}
 
@@ -255,9 +258,9 @@ func TestCacheControlForExt(t *testing.T) {
 	}{
 		{".html", "public, max-age=0, must-revalidate"},
 		{".htm", "public, max-age=0, must-revalidate"},
-		{".css", "public, max-age=31536000, immutable"},
-		{".js", "public, max-age=31536000, immutable"},
-		{".mjs", "public, max-age=31536000, immutable"},
+		{".css", "public, max-age=0, must-revalidate"},
+		{".js", "public, max-age=0, must-revalidate"},
+		{".mjs", "public, max-age=0, must-revalidate"},
 		{".png", "public, max-age=86400"},
 		{".jpg", "public, max-age=86400"},
 		{".jpeg", "public, max-age=86400"},
@@ -282,6 +285,32 @@ func TestCacheControlForExt(t *testing.T) {
 	}
 }
 
+func TestCacheControlForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"css/style.3f9a1c2b7d4e.css", "public, max-age=31536000, immutable"},
+		{"js/theme.0123456789ab.js", "public, max-age=31536000, immutable"},
+		{"js/app.0123456789AB.mjs", "public, max-age=31536000, immutable"},
+		{"css/style.css", "public, max-age=0, must-revalidate"},
+		{"js/theme.js", "public, max-age=0, must-revalidate"},
+		{"js/vendor.min.js", "public, max-age=0, must-revalidate"},
+		{"index.html", "public, max-age=0, must-revalidate"},
+		{"images/logo.3f9a1c2b7d4e.png", "public, max-age=86400"},
+		{"feed.xml", "public, max-age=3600"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			got := CacheControlForPath(tc.path)
+			if got != tc.expected {
+				t.Errorf("CacheControlForPath(%q) = %q, want %q", tc.path, got, tc.expected)
+			}
+		})
+	}
+}
+
 func TestHashFile(t *testing.T) {
 	dir := t.TempDir()
 	content := "hello world\n"
@@ -708,7 +737,7 @@ func TestDeploy_NilFunctionClientNoPanic(t *testing.T) {
 func TestScanFiles_EmptyDir(t *testing.T) {
 	dir := t.TempDir()
 
-	entries, err := ScanFiles(dir)
+	entries, err := ScanFiles(dir, nil)
 	if err != nil {
 		t.Fatalf("ScanFiles failed: %v", err)
 	}
@@ -717,8 +746,52 @@ func TestScanFiles_EmptyDir(t *testing.T) {
 	}
 }
 
+func TestScanFiles_Manifest(t *testing.T) {
+	dir := t.TempDir()
+	createTempFile(t, dir, "index.html", "<html>hello</html>")
+	createTempFile(t, dir, "style.css", "body { color: red; }")
+
+	manifest := &buildmanifest.Manifest{Files: []buildmanifest.File{
+		// A copy of the build output: same bytes, different modification
+		// time.
+		{
+			Path:     "index.html",
+			Size:     int64(len("<html>hello</html>")),
+			ModTime:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			SHA256:   sha256Hex("<html>hello</html>"),
+			Producer: buildmanifest.Producer{Kind: buildmanifest.ProducerPage, Source: "content/_index.md", Template: "index.html"},
+		},
+		// Changed since the build.
+		{Path: "style.css", Size: 20, SHA256: "stale", Producer: buildmanifest.Producer{Kind: buildmanifest.ProducerStatic}},
+	}}
+
+	entries, err := ScanFiles(dir, manifest)
+	if err != nil {
+		t.Fatalf("ScanFiles failed: %v", err)
+	}
+	entryMap := make(map[string]FileEntry)
+	for _, e := range entries {
+		entryMap[e.Path] = e
+	}
+
+	index := entryMap["index.html"]
+	if want := sha256Hex("<html>hello</html>"); index.Hash != want {
+		t.Errorf("index.html hash = %q, want %q", index.Hash, want)
+	}
+	if index.Producer != "page content/_index.md via index.html" {
+		t.Errorf("index.html producer = %q", index.Producer)
+	}
+	style := entryMap["style.css"]
+	if want := sha256Hex("body { color: red; }"); style.Hash != want {
+		t.Errorf("style.css hash = %q, want %q (re-hashed)", style.Hash, want)
+	}
+	if style.Producer != "" {
+		t.Errorf("style.css producer = %q, want empty for an unmatched entry", style.Producer)
+	}
+}
+
 func TestScanFiles_NonExistentDir(t *testing.T) {
-	_, err := ScanFiles("/nonexistent/dir/that/does/not/exist")
+	_, err := ScanFiles("/nonexistent/dir/that/does/not/exist", nil)
 	if err == nil {
 		t.Error("expected error for non-existent directory")
 	}
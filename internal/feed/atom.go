@@ -74,10 +74,13 @@ func GenerateAtom(items []FeedItem, opts FeedOptions) ([]byte, error) {
 		sorted = sorted[:opts.MaxItems]
 	}
 
-	// Determine feed-level updated time: most recent item's PubDate, or now.
+	// Determine feed-level updated time: most recent item's PubDate, or
+	// opts.Updated, or now.
 	var updatedTime time.Time
 	if len(sorted) > 0 {
 		updatedTime = sorted[0].PubDate
+	} else if !opts.Updated.IsZero() {
+		updatedTime = opts.Updated.UTC()
 	} else {
 		updatedTime = time.Now().UTC()
 	}
@@ -259,6 +259,19 @@ func TestGenerateAtom_EmptyItems(t *testing.T) {
 	}
 }
 
+func TestGenerateAtom_EmptyItemsUpdated(t *testing.T) {
+	opts := atomOpts()
+	opts.Updated = time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("CET", 3600))
+
+	data, err := GenerateAtom(nil, opts)
+	if err != nil {
+		t.Fatalf("GenerateAtom returned error: %v", err)
+	}
+	if !strings.Contains(string(data), "<updated>2001-02-03T03:05:06Z</updated>") {
+		t.Errorf("empty feed should be updated at opts.Updated in UTC, got:\n%s", data)
+	}
+}
+
 func TestGenerateAtom_Categories(t *testing.T) {
 	opts := atomOpts()
 	items := []FeedItem{
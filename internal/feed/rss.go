@@ -16,6 +16,10 @@ type FeedOptions struct {
 	Author      string
 	MaxItems    int  // 0 means no limit
 	FullContent bool // true = include full content, false = summary only
+
+	// Updated is the feed's updated time when it has no items; zero means
+	// now.
+	Updated time.Time
 }
 
 // FeedItem represents a single item in a feed.
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aellingwood/forge/internal/content"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/renderer"
@@ -19,19 +20,22 @@ const DefaultSizes = "(max-width: 768px) 100vw, 768px"
 // processed by the image Processor.
 type ResponsiveImageExtension struct {
 	processor *Processor
+	basePath  string
 }
 
 // NewResponsiveImageExtension creates a goldmark extension that renders
-// processed images as responsive <picture> elements.
-func NewResponsiveImageExtension(proc *Processor) *ResponsiveImageExtension {
-	return &ResponsiveImageExtension{processor: proc}
+// processed images as responsive <picture> elements. Root-relative image
+// URLs are prefixed with basePath, the path the site is hosted under
+// (e.g. "/docs"; "" for the root).
+func NewResponsiveImageExtension(proc *Processor, basePath string) *ResponsiveImageExtension {
+	return &ResponsiveImageExtension{processor: proc, basePath: basePath}
 }
 
 // Extend registers the responsive image renderer with the goldmark instance.
 func (e *ResponsiveImageExtension) Extend(m goldmark.Markdown) {
 	m.Renderer().AddOptions(
 		renderer.WithNodeRenderers(
-			util.Prioritized(&responsiveImageRenderer{processor: e.processor}, 100),
+			util.Prioritized(&responsiveImageRenderer{processor: e.processor, basePath: e.basePath}, 100),
 		),
 	)
 }
@@ -40,6 +44,7 @@ func (e *ResponsiveImageExtension) Extend(m goldmark.Markdown) {
 // elements when the image has been processed, or as plain <img> tags otherwise.
 type responsiveImageRenderer struct {
 	processor *Processor
+	basePath  string
 }
 
 // RegisterFuncs registers the image node renderer.
@@ -63,11 +68,12 @@ func (r *responsiveImageRenderer) renderImage(
 
 	// Check if this image was processed.
 	pi := r.processor.GetImage(src)
+	publicSrc := content.WithBasePath(r.basePath, src)
 
 	if pi == nil || isExternalURL(src) || isSVG(src) {
 		// Render a plain <img> with lazy loading.
 		_, _ = fmt.Fprintf(w, `<img src="%s" alt="%s" loading="lazy" decoding="async"`,
-			util.EscapeHTML([]byte(src)), util.EscapeHTML([]byte(alt)))
+			util.EscapeHTML([]byte(publicSrc)), util.EscapeHTML([]byte(alt)))
 		if n.Title != nil {
 			_, _ = fmt.Fprintf(w, ` title="%s"`, util.EscapeHTML(n.Title))
 		}
@@ -86,14 +92,14 @@ func (r *responsiveImageRenderer) renderImage(
 	// WebP <source> if we have WebP variants.
 	if len(webpVariants) > 0 {
 		_, _ = fmt.Fprintf(w, `  <source type="image/webp" srcset="%s" sizes="%s">`+"\n",
-			buildSrcsetFromVariants(webpVariants), sizes)
+			buildSrcsetFromVariants(webpVariants, r.basePath), sizes)
 	}
 
 	// Fallback <img> with original format srcset.
-	_, _ = fmt.Fprintf(w, `  <img src="%s"`, util.EscapeHTML([]byte(src)))
+	_, _ = fmt.Fprintf(w, `  <img src="%s"`, util.EscapeHTML([]byte(publicSrc)))
 	if len(origVariants) > 0 {
 		_, _ = fmt.Fprintf(w, ` srcset="%s" sizes="%s"`,
-			buildSrcsetFromVariants(origVariants), sizes)
+			buildSrcsetFromVariants(origVariants, r.basePath), sizes)
 	}
 	_, _ = fmt.Fprintf(w, ` alt="%s" width="%d" height="%d" loading="lazy" decoding="async"`,
 		util.EscapeHTML([]byte(alt)), pi.Width, pi.Height)
@@ -140,12 +146,13 @@ func filterVariantsExcluding(variants []Variant, excludeFormat string) []Variant
 	return result
 }
 
-// buildSrcsetFromVariants builds a srcset string from a slice of Variant.
+// buildSrcsetFromVariants builds a srcset string from a slice of Variant,
+// prefixing each URL with basePath.
 // Format: "url1 320w, url2 640w, ..."
-func buildSrcsetFromVariants(variants []Variant) string {
+func buildSrcsetFromVariants(variants []Variant, basePath string) string {
 	parts := make([]string, 0, len(variants))
 	for _, v := range variants {
-		parts = append(parts, fmt.Sprintf("%s %dw", v.URL, v.Width))
+		parts = append(parts, fmt.Sprintf("%s %dw", content.WithBasePath(basePath, v.URL), v.Width))
 	}
 	return strings.Join(parts, ", ")
 }
@@ -163,8 +170,9 @@ func isSVG(u string) bool {
 
 // BuildSrcset builds a srcset string from a ProcessedImage, filtering
 // variants by format. If format is empty, all non-webp variants are included.
+// Variant URLs are prefixed with basePath, the path the site is hosted under.
 // This is exported for use by the build pipeline.
-func BuildSrcset(pi *ProcessedImage, format string) string {
+func BuildSrcset(pi *ProcessedImage, format, basePath string) string {
 	if pi == nil {
 		return ""
 	}
@@ -174,5 +182,5 @@ func BuildSrcset(pi *ProcessedImage, format string) string {
 	} else {
 		variants = filterVariants(pi.Variants, format)
 	}
-	return buildSrcsetFromVariants(variants)
+	return buildSrcsetFromVariants(variants, basePath)
 }
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
@@ -29,6 +30,7 @@ type Processor struct {
 // variants.
 type ProcessedImage struct {
 	OriginalURL string
+	Source      string // filesystem path of the source image
 	Width       int
 	Height      int
 	Variants    []Variant
@@ -99,6 +101,7 @@ func (p *Processor) Process(srcPath, srcURL, outputDir string) (*ProcessedImage,
 				if cpErr == nil {
 					pi := &ProcessedImage{
 						OriginalURL: srcURL,
+						Source:      srcPath,
 						Width:       srcWidth,
 						Height:      srcHeight,
 						Variants:    variants,
@@ -168,6 +171,7 @@ func (p *Processor) Process(srcPath, srcURL, outputDir string) (*ProcessedImage,
 
 	pi := &ProcessedImage{
 		OriginalURL: srcURL,
+		Source:      srcPath,
 		Width:       srcWidth,
 		Height:      srcHeight,
 		Variants:    variants,
@@ -254,6 +258,21 @@ func (p *Processor) GetImage(srcURL string) *ProcessedImage {
 	return p.registry[srcURL]
 }
 
+// Images returns every processed image, sorted by source URL. This method
+// is safe for concurrent use.
+func (p *Processor) Images() []*ProcessedImage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	images := make([]*ProcessedImage, 0, len(p.registry))
+	for _, pi := range p.registry {
+		images = append(images, pi)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].OriginalURL < images[j].OriginalURL
+	})
+	return images
+}
+
 // register stores a ProcessedImage in the registry under the given URL.
 func (p *Processor) register(srcURL string, pi *ProcessedImage) {
 	p.mu.Lock()
@@ -19,7 +19,7 @@ func (fs *ForgeServer) startWatcher(ctx context.Context) error {
 		filepath.Join(fs.siteDir, "data"),
 	}
 
-	watcher := server.NewWatcher(watchPaths, 500*time.Millisecond, func() {
+	watcher := server.NewWatcher(watchPaths, 500*time.Millisecond, func(_ []string) {
 		fs.ctx.MarkDirty()
 		// Notify clients that resources have changed
 		_ = fs.server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aellingwood/forge/internal/build"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -41,6 +43,11 @@ func (fs *ForgeServer) registerPrompts() {
 		Name:        "site_overview",
 		Description: "Generate a summary of the site's current state for context",
 	}, fs.handleSiteOverviewPrompt)
+
+	fs.server.AddPrompt(&mcp.Prompt{
+		Name:        "fix_build_errors",
+		Description: "Fix the errors and warnings reported by the last build",
+	}, fs.handleFixBuildErrorsPrompt)
 }
 
 func (fs *ForgeServer) handleNewBlogPostPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
@@ -226,3 +233,85 @@ What would you like to do?`, siteTitle, sections, taxonomy, buildInfo)
 		},
 	}, nil
 }
+
+func (fs *ForgeServer) handleFixBuildErrorsPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	last := fs.lastBuild
+	if last == nil {
+		return &mcp.GetPromptResult{
+			Description: "Fix build errors and warnings",
+			Messages: []*mcp.PromptMessage{
+				{
+					Role:    mcp.Role("user"),
+					Content: &mcp.TextContent{Text: "The site has not been built yet. Run the build_site tool, then fix any errors and warnings it reports."},
+				},
+			},
+		}, nil
+	}
+	if len(last.Errors) == 0 && len(last.Warnings) == 0 {
+		return &mcp.GetPromptResult{
+			Description: "Fix build errors and warnings",
+			Messages: []*mcp.PromptMessage{
+				{
+					Role:    mcp.Role("user"),
+					Content: &mcp.TextContent{Text: "The last build succeeded with no errors or warnings. Nothing needs fixing."},
+				},
+			},
+		}, nil
+	}
+
+	text := fmt.Sprintf(`The last Forge build (%s) reported these problems:
+
+Errors:
+%s
+Warnings:
+%s
+For each problem:
+1. Open the file it names (paths are relative to the site root) and go to the line, if given
+2. Fix the cause rather than silencing it; warning codes mean:
+%s3. Run build_site again and confirm the problem is gone from forge://build/status`,
+		last.Timestamp.Format(time.RFC3339), formatBuildIssues(last.Errors), formatBuildIssues(last.Warnings), formatWarningCodes())
+
+	return &mcp.GetPromptResult{
+		Description: fmt.Sprintf("Fix %d build errors and %d warnings", len(last.Errors), len(last.Warnings)),
+		Messages: []*mcp.PromptMessage{
+			{
+				Role:    mcp.Role("user"),
+				Content: &mcp.TextContent{Text: text},
+			},
+		},
+	}, nil
+}
+
+// formatWarningCodes renders build.WarningCodes as an indented bulleted
+// list, one code per line.
+func formatWarningCodes() string {
+	var sb strings.Builder
+	for _, c := range build.WarningCodes {
+		fmt.Fprintf(&sb, "   - %s: %s\n", c.Code, c.Meaning)
+	}
+	return sb.String()
+}
+
+// formatBuildIssues renders issues as a bulleted list, one per line.
+func formatBuildIssues(issues []BuildIssue) string {
+	if len(issues) == 0 {
+		return "- (none)\n"
+	}
+	var sb strings.Builder
+	for _, issue := range issues {
+		sb.WriteString("- ")
+		if issue.File != "" {
+			sb.WriteString(issue.File)
+			if issue.Line > 0 {
+				fmt.Fprintf(&sb, ":%d", issue.Line)
+			}
+			sb.WriteString(": ")
+		}
+		sb.WriteString(issue.Message)
+		if issue.Code != "" {
+			sb.WriteString(" [" + issue.Code + "]")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
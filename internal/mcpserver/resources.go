@@ -55,7 +55,7 @@ func (fs *ForgeServer) registerResources() {
 	fs.server.AddResource(&mcp.Resource{
 		URI:         "forge://build/status",
 		Name:        "Build Status",
-		Description: "Last build result — timestamp, duration, errors, warnings",
+		Description: "Last build result — timestamp, duration, and errors and warnings with file, line, and code",
 		MIMEType:    "application/json",
 	}, fs.handleBuildStatusResource)
 
@@ -224,9 +224,10 @@ func (fs *ForgeServer) handleTaxonomyDetailResource(ctx context.Context, req *mc
 	}
 	sc.mu.RLock()
 	pages := sc.pages
+	permalinks := sc.cfg.Permalinks
 	sc.mu.RUnlock()
 
-	detail, ok := buildTaxonomyDetail(name, pages)
+	detail, ok := buildTaxonomyDetail(name, pages, permalinks)
 	if !ok {
 		return nil, mcp.ResourceNotFoundError(uri)
 	}
@@ -259,7 +260,11 @@ func toPageBrief(p *content.Page) PageBrief {
 		Title:       p.Title,
 		Date:        p.Date,
 		Lastmod:     p.Lastmod,
+		PublishDate: p.PublishDate,
+		ExpiryDate:  p.ExpiryDate,
 		Draft:       p.Draft,
+		Render:      p.Build.Render,
+		List:        p.Build.List,
 		Section:     p.Section,
 		Tags:        p.Tags,
 		Categories:  p.Categories,
@@ -294,6 +299,7 @@ func toPageDetail(p *content.Page) PageDetail {
 		RenderedHTML:    p.Content,
 		TableOfContents: p.TableOfContents,
 		BundleAssets:    p.BundleFiles,
+		Cascaded:        p.Cascaded,
 	}
 	if p.Cover != nil {
 		d.Cover = &CoverImageDetail{
@@ -423,7 +429,7 @@ func buildTaxonomyOverview(pages []*content.Page, cfg *config.SiteConfig) Taxono
 	return TaxonomyOverview{Taxonomies: taxos}
 }
 
-func buildTaxonomyDetail(name string, pages []*content.Page) (TaxonomyDetail, bool) {
+func buildTaxonomyDetail(name string, pages []*content.Page, permalinks map[string]string) (TaxonomyDetail, bool) {
 	var getTerms func(*content.Page) []string
 	var singular, urlBase string
 
@@ -453,7 +459,7 @@ func buildTaxonomyDetail(name string, pages []*content.Page) (TaxonomyDetail, bo
 		terms = append(terms, TermDetail{
 			Name:  termName,
 			Slug:  slug,
-			URL:   fmt.Sprintf("%s%s/", urlBase, slug),
+			URL:   content.TermPermalink(permalinks, strings.Trim(urlBase, "/"), slug),
 			Count: len(refs),
 			Pages: refs,
 		})
@@ -524,9 +530,19 @@ func buildFrontmatterSchema(tags, cats, series, projects []string) FrontmatterSc
 			},
 			"date": {
 				Type:        "datetime",
-				Description: "Publish date (ISO 8601)",
+				Description: "Content date (ISO 8601), used for sorting and display; may be backdated",
 				Default:     "now",
 			},
+			"publishDate": {
+				Type:        "datetime",
+				Description: "When the page becomes visible (ISO 8601); builds before this date leave it out",
+				Default:     "date",
+			},
+			"unpublishDate": {
+				Type:        "datetime",
+				Description: "When the page is removed (ISO 8601); expiryDate is accepted as an alias",
+				Default:     nil,
+			},
 			"draft": {
 				Type:        "boolean",
 				Description: "Exclude from production builds",
@@ -569,6 +585,10 @@ func buildFrontmatterSchema(tags, cats, series, projects []string) FrontmatterSc
 				Type:        "string",
 				Description: "URL slug override (default: derived from filename)",
 			},
+			"url": {
+				Type:        "string",
+				Description: "Full URL path override, e.g. /about-us/ (takes precedence over slug and permalinks)",
+			},
 			"description": {
 				Type:        "string",
 				Description: "Meta description / OpenGraph description",
@@ -582,6 +602,11 @@ func buildFrontmatterSchema(tags, cats, series, projects []string) FrontmatterSc
 				Description: "Sort order for non-date ordering",
 				Default:     0,
 			},
+			"paginate": {
+				Type:        "integer",
+				Description: "Page size override for this section's list (_index.md only; default: pagination.pageSize). The home page is paginated only when content/_index.md sets it",
+				Default:     0,
+			},
 			"layout": {
 				Type:        "string",
 				Description: "Explicit layout override",
@@ -591,6 +616,19 @@ func buildFrontmatterSchema(tags, cats, series, projects []string) FrontmatterSc
 				Type:        "[]string",
 				Description: "Redirect old URLs to this page",
 			},
+			"build": {
+				Type:        "object",
+				Description: "Per-page build options",
+				Fields: map[string]any{
+					"render":  map[string]any{"type": "string", "validValues": []string{"always", "never"}, "default": "always", "description": "never: no HTML file; the page exists only as data (.Site.GetPage)"},
+					"list":    map[string]any{"type": "string", "validValues": []string{"always", "local", "never"}, "default": "always", "description": "local: only in its section's list; never: in no list, feed, sitemap, or search index"},
+					"publish": map[string]any{"type": "boolean", "default": true, "description": "false: do not copy the page bundle's resources"},
+				},
+			},
+			"cascade": {
+				Type:        "object or []object",
+				Description: "On _index.md: frontmatter inherited by every page below the section unless the page sets it; nearer sections win, params merge by key. An optional _target path glob (e.g. \"tutorials/advanced/**\") limits a block to matching content paths. title, slug, and aliases cannot be cascaded",
+			},
 			"params": {
 				Type:        "map[string]any",
 				Description: "Arbitrary key-value pairs accessible in templates",
@@ -598,6 +598,23 @@ func TestIntegration_BuildStatus_Initial(t *testing.T) {
 	}
 }
 
+func TestIntegration_BuildSite_InvalidAsOf(t *testing.T) {
+	session, cleanup := newTestClient(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	result, err := session.CallTool(ctx, &mcp.CallToolParams{
+		Name:      "build_site",
+		Arguments: map[string]any{"asOf": "next monday"},
+	})
+	if err != nil {
+		t.Fatalf("CallTool: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error for unparseable asOf")
+	}
+}
+
 func TestIntegration_ListTools(t *testing.T) {
 	session, cleanup := newTestClient(t)
 	defer cleanup()
@@ -640,7 +657,7 @@ func TestIntegration_ListPrompts(t *testing.T) {
 		prompts[p.Name] = true
 	}
 
-	expectedPrompts := []string{"new_blog_post", "new_project", "content_review", "site_overview"}
+	expectedPrompts := []string{"new_blog_post", "new_project", "content_review", "site_overview", "fix_build_errors"}
 	for _, name := range expectedPrompts {
 		if !prompts[name] {
 			t.Errorf("expected prompt %q not found", name)
@@ -665,6 +682,24 @@ func TestIntegration_GetPrompt_NewBlogPost(t *testing.T) {
 	}
 }
 
+func TestIntegration_GetPrompt_FixBuildErrors(t *testing.T) {
+	session, cleanup := newTestClient(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	result, err := session.GetPrompt(ctx, &mcp.GetPromptParams{Name: "fix_build_errors"})
+	if err != nil {
+		t.Fatalf("GetPrompt fix_build_errors: %v", err)
+	}
+	if len(result.Messages) == 0 {
+		t.Fatal("expected non-empty messages")
+	}
+	text := result.Messages[0].Content.(*mcp.TextContent).Text
+	if !strings.Contains(text, "build_site") {
+		t.Errorf("prompt before any build should ask for build_site, got:\n%s", text)
+	}
+}
+
 func TestIntegration_GetPage(t *testing.T) {
 	session, cleanup := newTestClient(t)
 	defer cleanup()
@@ -1,7 +1,13 @@
 package mcpserver
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/aellingwood/forge/internal/content"
 )
 
 // Integration tests are in server_integration_test.go
@@ -115,4 +121,104 @@ project: "forge"
 			}
 		}
 	})
+
+	t.Run("publishing window", func(t *testing.T) {
+		fm := `title: "My Post"
+date: 2025-01-15
+publishDate: 2025-03-01T09:00:00Z
+unpublishDate: 2025-02-01
+`
+		result := validateFrontmatter(fm, tags, cats, projects)
+		if !result.Valid {
+			t.Errorf("expected valid, got errors: %v", result.Errors)
+		}
+		if len(result.Warnings) != 1 || result.Warnings[0].Field != "unpublishDate" {
+			t.Errorf("expected an unpublishDate warning, got %v", result.Warnings)
+		}
+		if !strings.Contains(result.NormalizedFrontmatter, "publishDate: ") {
+			t.Errorf("normalized frontmatter dropped publishDate:\n%s", result.NormalizedFrontmatter)
+		}
+	})
+
+	t.Run("invalid publishDate and duplicate unpublish", func(t *testing.T) {
+		fm := `title: "My Post"
+publishDate: "next week"
+unpublishDate: 2025-02-01
+expiryDate: 2025-02-01
+`
+		result := validateFrontmatter(fm, tags, cats, projects)
+		fields := make(map[string]bool)
+		for _, e := range result.Errors {
+			fields[e.Field] = true
+		}
+		if result.Valid || !fields["publishDate"] || !fields["expiryDate"] {
+			t.Errorf("expected publishDate and expiryDate errors, got %v", result.Errors)
+		}
+	})
+}
+
+func TestFormatBuildIssues(t *testing.T) {
+	got := formatBuildIssues([]BuildIssue{
+		{File: "content/blog/post.md", Line: 4, Code: "missing-cover", Message: `cover image "a.png" not found`},
+		{File: "themes/default/static/css/globals.css", Message: "no Tailwind"},
+		{Message: "plain"},
+	})
+	want := `- content/blog/post.md:4: cover image "a.png" not found [missing-cover]
+- themes/default/static/css/globals.css: no Tailwind
+- plain
+`
+	if got != want {
+		t.Errorf("formatBuildIssues() =\n%s\nwant\n%s", got, want)
+	}
+	if got := formatBuildIssues(nil); got != "- (none)\n" {
+		t.Errorf("formatBuildIssues(nil) = %q", got)
+	}
+}
+
+func TestFormatWarningCodes(t *testing.T) {
+	got := formatWarningCodes()
+	for _, code := range []string{"missing-cover", "broken-link", "tailwind-unavailable"} {
+		if !strings.Contains(got, "   - "+code+": ") {
+			t.Errorf("formatWarningCodes() is missing %s:\n%s", code, got)
+		}
+	}
+}
+
+func TestToPageDetail_Cascaded(t *testing.T) {
+	p := &content.Page{
+		Title:    "Basics",
+		Layout:   "tutorial",
+		Cascaded: map[string]string{"layout": "tutorials/_index.md"},
+	}
+	d := toPageDetail(p)
+	if d.Cascaded["layout"] != "tutorials/_index.md" {
+		t.Errorf("Cascaded = %v, want layout from tutorials/_index.md", d.Cascaded)
+	}
+	data, err := json.Marshal(toPageDetail(&content.Page{Title: "Own"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "cascaded") {
+		t.Errorf("page without inherited values should omit cascaded: %s", data)
+	}
+}
+
+func TestResolveLayout_NestedSection(t *testing.T) {
+	siteDir := t.TempDir()
+	layout := filepath.Join(siteDir, "layouts", "docs", "single.html")
+	if err := os.MkdirAll(filepath.Dir(layout), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(layout, []byte("doc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &content.Page{Title: "Linux", Section: "docs", SectionPath: "docs/guides/install", Type: content.PageTypeSingle}
+	out := resolveLayout(p, siteDir)
+	if want := filepath.Join("layouts", "docs", "single.html"); out.Resolved != want {
+		t.Errorf("Resolved = %q, want %q", out.Resolved, want)
+	}
+	if first := out.LookupOrder[0].Path; first != filepath.Join("layouts", "docs", "guides", "install", "single.html") {
+		t.Errorf("first candidate = %q, want the deepest section", first)
+	}
 }
@@ -3,8 +3,10 @@ package mcpserver
 import (
 	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -272,7 +274,7 @@ func (fs *ForgeServer) handleGetTemplateContext(ctx context.Context, req *mcp.Ca
 			"Section":     target.Section,
 		},
 		AvailableFunctions: []string{
-			"markdownify", "plainify", "truncate", "slugify", "highlight",
+			"markdownify", "plainify", "truncate", "slugify", "hasPrefix", "highlight",
 			"safeHTML", "where", "sort", "first", "last", "shuffle", "group",
 			"dateFormat", "now", "readingTime", "relURL", "absURL", "ref",
 		},
@@ -321,6 +323,7 @@ func (fs *ForgeServer) handleCreateContent(ctx context.Context, req *mcp.CallToo
 	existingTags := sc.AllTags()
 	existingCats := sc.AllCategories()
 	projectSlugs := sc.AllProjectSlugs()
+	permalinks := sc.cfg.Permalinks
 	sc.mu.RUnlock()
 
 	slug := input.Slug
@@ -345,17 +348,14 @@ func (fs *ForgeServer) handleCreateContent(ctx context.Context, req *mcp.CallToo
 		} else {
 			relPath = fmt.Sprintf("content/blog/%s-%s.md", datePrefix, slug)
 		}
-		url = fmt.Sprintf("/blog/%s/", slug)
 	case "page":
 		relPath = fmt.Sprintf("content/%s.md", slug)
-		url = fmt.Sprintf("/%s/", slug)
 	case "project":
 		if input.PageBundle {
 			relPath = fmt.Sprintf("content/projects/%s/index.md", slug)
 		} else {
 			relPath = fmt.Sprintf("content/projects/%s.md", slug)
 		}
-		url = fmt.Sprintf("/projects/%s/", slug)
 	default:
 		return &mcp.CallToolResult{
 			IsError: true,
@@ -363,6 +363,27 @@ func (fs *ForgeServer) handleCreateContent(ctx context.Context, req *mcp.CallToo
 		}, CreateContentOutput{}, nil
 	}
 
+	// The URL the new page will get, following the site's permalinks.
+	sourcePath := strings.TrimPrefix(relPath, "content/")
+	sourceDir := path.Dir(sourcePath)
+	isBundle := input.PageBundle && input.Type != "page"
+	section := sourceDir
+	if isBundle {
+		section = path.Dir(sourceDir)
+	}
+	section = strings.TrimPrefix(section, ".")
+	url = content.PageURL(&content.Page{
+		Type:        content.PageTypeSingle,
+		Title:       input.Title,
+		Slug:        slug,
+		Date:        now,
+		Section:     section,
+		SectionPath: section,
+		SourcePath:  sourcePath,
+		SourceDir:   strings.TrimPrefix(sourceDir, "."),
+		IsBundle:    isBundle,
+	}, permalinks)
+
 	absPath := filepath.Join(fs.siteDir, relPath)
 
 	// Check if file already exists
@@ -453,12 +474,22 @@ func (fs *ForgeServer) handleBuildSite(ctx context.Context, req *mcp.CallToolReq
 		outputDir = "public"
 	}
 
+	var asOf time.Time
+	if input.AsOf != "" {
+		asOf, err = content.ParseDate(input.AsOf)
+		if err != nil {
+			return &mcp.CallToolResult{IsError: true, Content: []mcp.Content{&mcp.TextContent{Text: "invalid asOf: " + err.Error()}}}, BuildSiteOutput{}, nil
+		}
+	}
+
 	opts := build.BuildOptions{
 		IncludeDrafts: input.Drafts,
 		IncludeFuture: input.Future,
 		OutputDir:     outputDir,
 		Verbose:       input.Verbose,
 		ProjectRoot:   fs.siteDir,
+		KeepGoing:     true,
+		BuildDate:     asOf,
 	}
 	if input.BaseURL != "" {
 		opts.BaseURL = input.BaseURL
@@ -469,7 +500,35 @@ func (fs *ForgeServer) handleBuildSite(ctx context.Context, req *mcp.CallToolReq
 	result, buildErr := builder.Build()
 
 	var out BuildSiteOutput
-	if buildErr != nil {
+	var pageErrs *build.BuildErrors
+	var lockErr *build.LockError
+	if errors.As(buildErr, &lockErr) {
+		// Another forge process is building this site; leave its output alone.
+		out = BuildSiteOutput{
+			Success: false,
+			Errors:  []BuildIssue{{Code: "build-locked", Message: lockErr.Error(), Level: "error"}},
+		}
+	} else if errors.As(buildErr, &pageErrs) {
+		// The rest of the site built; report every failed page.
+		issues := make([]BuildIssue, len(pageErrs.Errors))
+		for i, pe := range pageErrs.Errors {
+			file := pe.Path
+			if !strings.HasPrefix(file, "/") { // generated pages are reported by URL
+				file = "content/" + file
+			}
+			issues[i] = BuildIssue{File: file, Message: pe.Err.Error(), Level: "error"}
+		}
+		out = BuildSiteOutput{
+			Success:           false,
+			DurationMs:        time.Since(start).Milliseconds(),
+			PagesRendered:     result.PagesRendered,
+			StaticFilesCopied: result.StaticFiles,
+			OutputDir:         outputDir + "/",
+			OutputSizeBytes:   result.OutputSize,
+			Errors:            issues,
+			Warnings:          warningIssues(result.Warnings),
+		}
+	} else if buildErr != nil {
 		out = BuildSiteOutput{
 			Success: false,
 			Errors:  []BuildIssue{{Message: buildErr.Error()}},
@@ -483,7 +542,7 @@ func (fs *ForgeServer) handleBuildSite(ctx context.Context, req *mcp.CallToolReq
 			OutputDir:         outputDir + "/",
 			OutputSizeBytes:   result.OutputSize,
 			Errors:            []BuildIssue{},
-			Warnings:          []BuildIssue{},
+			Warnings:          warningIssues(result.Warnings),
 		}
 	}
 
@@ -505,6 +564,15 @@ func (fs *ForgeServer) handleBuildSite(ctx context.Context, req *mcp.CallToolReq
 	return nil, out, nil
 }
 
+// warningIssues converts build warnings to BuildIssues.
+func warningIssues(warnings []build.Warning) []BuildIssue {
+	issues := make([]BuildIssue, len(warnings))
+	for i, w := range warnings {
+		issues[i] = BuildIssue{File: w.File, Line: w.Line, Code: w.Code, Message: w.Message, Level: "warning"}
+	}
+	return issues
+}
+
 func (fs *ForgeServer) handleDeploySite(ctx context.Context, req *mcp.CallToolRequest, input DeploySiteInput) (*mcp.CallToolResult, DeploySiteOutput, error) {
 	sc, err := fs.ctx.Load()
 	if err != nil {
@@ -538,6 +606,9 @@ func (fs *ForgeServer) handleDeploySite(ctx context.Context, req *mcp.CallToolRe
 func filterPages(pages []*content.Page, input QueryContentInput) []*content.Page {
 	var result []*content.Page
 	for _, p := range pages {
+		if !input.Unlisted && !p.ListedInSite() {
+			continue
+		}
 		if input.Section != "" && p.Section != input.Section {
 			continue
 		}
@@ -642,7 +713,7 @@ func paginatePages(pages []*content.Page, offset, limit int) []*content.Page {
 }
 
 func resolveLayout(p *content.Page, siteDir string) ResolveLayoutOutput {
-	section := p.Section
+	section := p.CurrentSection()
 	layout := p.Layout
 	if layout == "" {
 		layout = "single"
@@ -656,13 +727,15 @@ func resolveLayout(p *content.Page, siteDir string) ResolveLayoutOutput {
 	themePath := filepath.Join(siteDir, "embedded", "themes", "default", "layouts")
 	userPath := filepath.Join(siteDir, "layouts")
 
+	// A nested section falls back through its parents, deepest first.
 	candidates := []candidate{}
-	if section != "" {
+	for dir := section; dir != "" && dir != "."; dir = path.Dir(dir) {
+		dir := filepath.FromSlash(dir)
 		candidates = append(candidates,
-			candidate{filepath.Join(userPath, section, layout+".html"), "user"},
-			candidate{filepath.Join(themePath, section, layout+".html"), "theme"},
-			candidate{filepath.Join(userPath, section, "single.html"), "user"},
-			candidate{filepath.Join(themePath, section, "single.html"), "theme"},
+			candidate{filepath.Join(userPath, dir, layout+".html"), "user"},
+			candidate{filepath.Join(themePath, dir, layout+".html"), "theme"},
+			candidate{filepath.Join(userPath, dir, "single.html"), "user"},
+			candidate{filepath.Join(themePath, dir, "single.html"), "theme"},
 		)
 	}
 	candidates = append(candidates,
@@ -12,6 +12,8 @@ type PageBrief struct {
 	Title       string    `json:"title"`
 	Date        time.Time `json:"date"`
 	Lastmod     time.Time `json:"lastmod,omitempty"`
+	PublishDate time.Time `json:"publishDate,omitzero"`
+	ExpiryDate  time.Time `json:"unpublishDate,omitzero"`
 	Draft       bool      `json:"draft"`
 	Section     string    `json:"section"`
 	Tags        []string  `json:"tags,omitempty"`
@@ -24,6 +26,8 @@ type PageBrief struct {
 	WordCount   int       `json:"wordCount"`
 	HasCover    bool      `json:"hasCover"`
 	IsBundle    bool      `json:"isPageBundle"`
+	Render      string    `json:"render,omitempty"` // build.render, if set
+	List        string    `json:"list,omitempty"`   // build.list, if set
 }
 
 // PageDetail is a full page representation including rendered content.
@@ -42,6 +46,11 @@ type PageDetail struct {
 	BundleAssets    []string          `json:"bundleAssets,omitempty"`
 	PrevPage        *PageRef          `json:"prevPage,omitempty"`
 	NextPage        *PageRef          `json:"nextPage,omitempty"`
+
+	// Cascaded maps each frontmatter key the page inherits from a section's
+	// cascade (params as "params.<name>") to the _index.md that sets it.
+	// Every other value comes from the page's own frontmatter.
+	Cascaded map[string]string `json:"cascaded,omitempty"`
 }
 
 // PageRef is a minimal reference to a related page.
@@ -143,6 +152,8 @@ type BuildResultDetail struct {
 // BuildIssue describes a build error or warning.
 type BuildIssue struct {
 	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
 	Level   string `json:"level,omitempty"`
 }
@@ -175,6 +186,7 @@ type QueryContentInput struct {
 	Series     string   `json:"series,omitempty"     jsonschema:"Filter by series name"`
 	Project    string   `json:"project,omitempty"    jsonschema:"Filter by project slug"`
 	Search     string   `json:"search,omitempty"     jsonschema:"Full-text search across title, summary, and content"`
+	Unlisted   bool     `json:"unlisted,omitempty"   jsonschema:"Include pages hidden from site-wide lists by build.list: local or never (default: false)"`
 	SortBy     string   `json:"sortBy,omitempty"     jsonschema:"Sort field: date, title, weight, readingTime, wordCount (default: date)"`
 	SortOrder  string   `json:"sortOrder,omitempty"  jsonschema:"Sort order: asc or desc (default: desc)"`
 	Limit      int      `json:"limit,omitempty"      jsonschema:"Max results to return, 1-100 (default: 20)"`
@@ -302,6 +314,7 @@ type BuildSiteInput struct {
 	BaseURL   string `json:"baseURL,omitempty"   jsonschema:"Override base URL"`
 	OutputDir string `json:"outputDir,omitempty" jsonschema:"Override output directory (default: public/)"`
 	Verbose   bool   `json:"verbose,omitempty"   jsonschema:"Include per-page timing in output (default: false)"`
+	AsOf      string `json:"asOf,omitempty"      jsonschema:"Build the site as it will be on this date (YYYY-MM-DD or RFC 3339): future content up to it is published and content expired by it is removed (default: now)"`
 }
 
 // BuildSiteOutput is the output from the build_site tool.
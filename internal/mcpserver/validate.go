@@ -68,18 +68,31 @@ func findSimilarTerms(input string, existing []string, threshold int) []string {
 
 // frontmatterData is a partial parse of YAML frontmatter.
 type frontmatterData struct {
-	Title       string   `yaml:"title"`
-	Date        string   `yaml:"date"`
-	Draft       *bool    `yaml:"draft"`
-	Tags        []string `yaml:"tags"`
-	Categories  []string `yaml:"categories"`
-	Series      string   `yaml:"series"`
-	Project     string   `yaml:"project"`
-	Description string   `yaml:"description"`
-	Summary     string   `yaml:"summary"`
-	Slug        string   `yaml:"slug"`
-	Weight      int      `yaml:"weight"`
-	Layout      string   `yaml:"layout"`
+	Title         string   `yaml:"title"`
+	Date          string   `yaml:"date"`
+	PublishDate   string   `yaml:"publishDate,omitempty"`
+	UnpublishDate string   `yaml:"unpublishDate,omitempty"`
+	ExpiryDate    string   `yaml:"expiryDate,omitempty"`
+	Draft         *bool    `yaml:"draft"`
+	Tags          []string `yaml:"tags"`
+	Categories    []string `yaml:"categories"`
+	Series        string   `yaml:"series"`
+	Project       string   `yaml:"project"`
+	Description   string   `yaml:"description"`
+	Summary       string   `yaml:"summary"`
+	Slug          string   `yaml:"slug"`
+	Weight        int      `yaml:"weight"`
+	Layout        string   `yaml:"layout"`
+}
+
+// parseFrontmatterDate parses an ISO 8601 date as accepted in frontmatter.
+func parseFrontmatterDate(s string) (time.Time, bool) {
+	for _, f := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(f, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
 }
 
 // validateFrontmatter validates YAML frontmatter against the Forge schema.
@@ -105,26 +118,51 @@ func validateFrontmatter(raw string, existingTags, existingCats, projectSlugs []
 	}
 
 	// Date format validation
-	if data.Date != "" {
-		validFormats := []string{
-			time.RFC3339,
-			"2006-01-02T15:04:05",
-			"2006-01-02",
+	dates := make(map[string]time.Time)
+	for _, f := range []struct{ field, value string }{
+		{"date", data.Date},
+		{"publishDate", data.PublishDate},
+		{"unpublishDate", data.UnpublishDate},
+		{"expiryDate", data.ExpiryDate},
+	} {
+		if f.value == "" {
+			continue
 		}
-		valid := false
-		for _, f := range validFormats {
-			if _, err := time.Parse(f, data.Date); err == nil {
-				valid = true
-				break
-			}
-		}
-		if !valid {
+		t, ok := parseFrontmatterDate(f.value)
+		if !ok {
 			errs = append(errs, ValidationError{
-				Field:   "date",
+				Field:   f.field,
 				Message: "Invalid date format: expected ISO 8601 (e.g. 2025-01-15 or 2025-01-15T10:00:00Z)",
-				Value:   data.Date,
+				Value:   f.value,
 			})
+			continue
 		}
+		dates[f.field] = t
+	}
+
+	// Publishing window validation
+	if data.UnpublishDate != "" && data.ExpiryDate != "" {
+		errs = append(errs, ValidationError{
+			Field:   "expiryDate",
+			Message: "unpublishDate and expiryDate are the same setting; use unpublishDate",
+			Value:   data.ExpiryDate,
+		})
+	}
+	publish, hasPublish := dates["publishDate"]
+	if !hasPublish {
+		publish, hasPublish = dates["date"]
+	}
+	unpublishField := "unpublishDate"
+	unpublish, hasUnpublish := dates[unpublishField]
+	if !hasUnpublish {
+		unpublishField = "expiryDate"
+		unpublish, hasUnpublish = dates[unpublishField]
+	}
+	if hasPublish && hasUnpublish && !unpublish.After(publish) {
+		warns = append(warns, ValidationWarning{
+			Field:   unpublishField,
+			Message: fmt.Sprintf("%s is not after the publish date, so the page will never be visible", unpublishField),
+		})
 	}
 
 	// Layout validation
@@ -24,8 +24,10 @@ type Renderer struct {
 }
 
 // NewRenderer creates a Renderer with the given template engine, markdown
-// renderer, and site configuration.
+// renderer, and site configuration. The markdown renderer is set up for the
+// engine's render hooks.
 func NewRenderer(engine *tmpl.Engine, markdown *content.MarkdownRenderer, cfg *config.SiteConfig) *Renderer {
+	markdown.SetRenderHooks(engine.RenderHooks()...)
 	return &Renderer{
 		engine:   engine,
 		markdown: markdown,
@@ -44,6 +46,7 @@ func (r *Renderer) BuildPageContext(page *content.Page, allPages []*content.Page
 // buildPageContext is the internal implementation that tracks recursion depth
 // for PrevPage/NextPage conversion.
 func (r *Renderer) buildPageContext(page *content.Page, allPages []*content.Page, includeNav bool) *tmpl.PageContext {
+	basePath := content.BasePath(r.config.BaseURL)
 	ctx := &tmpl.PageContext{
 		Title:           page.Title,
 		Description:     page.Description,
@@ -51,9 +54,11 @@ func (r *Renderer) buildPageContext(page *content.Page, allPages []*content.Page
 		Summary:         template.HTML(page.Summary),
 		Date:            page.Date,
 		Lastmod:         page.Lastmod,
+		PublishDate:     page.PublishDate,
+		ExpiryDate:      page.ExpiryDate,
 		Draft:           page.Draft,
 		Slug:            page.Slug,
-		URL:             page.URL,
+		URL:             content.WithBasePath(basePath, page.URL),
 		Permalink:       page.Permalink,
 		ReadingTime:     page.ReadingTime,
 		WordCount:       page.WordCount,
@@ -71,16 +76,17 @@ func (r *Renderer) buildPageContext(page *content.Page, allPages []*content.Page
 	if page.Cover != nil {
 		image := page.Cover.Image
 		if image != "" && !strings.HasPrefix(image, "/") && !strings.HasPrefix(image, "http") {
-			image = strings.TrimSuffix(page.URL, "/") + "/" + image
+			image = content.DirURL(page.URL) + image
 		}
 		ctx.Cover = &tmpl.CoverImage{
-			Image:   image,
+			Image:   content.WithBasePath(basePath, image),
 			Alt:     page.Cover.Alt,
 			Caption: page.Cover.Caption,
 		}
 	}
 
-	// Convert PrevPage/NextPage one level deep to avoid infinite recursion.
+	// Convert PrevPage/NextPage and the ancestors one level deep to avoid
+	// infinite recursion.
 	if includeNav {
 		if page.PrevPage != nil {
 			ctx.PrevPage = r.buildPageContext(page.PrevPage, nil, false)
@@ -88,6 +94,13 @@ func (r *Renderer) buildPageContext(page *content.Page, allPages []*content.Page
 		if page.NextPage != nil {
 			ctx.NextPage = r.buildPageContext(page.NextPage, nil, false)
 		}
+		for a := page.Parent; a != nil; a = a.Parent {
+			actx := r.buildPageContext(a, nil, false)
+			if ctx.Parent == nil {
+				ctx.Parent = actx
+			}
+			ctx.Ancestors = append([]*tmpl.PageContext{actx}, ctx.Ancestors...)
+		}
 	}
 
 	return ctx
@@ -138,10 +151,11 @@ func (r *Renderer) BuildSiteContext(allPages []*content.Page) *tmpl.SiteContext
 
 	// Build menu items from config.
 	menuItems := make([]tmpl.MenuItemContext, len(r.config.Menu.Main))
+	basePath := content.BasePath(r.config.BaseURL)
 	for i, item := range r.config.Menu.Main {
 		menuItems[i] = tmpl.MenuItemContext{
 			Name:   item.Name,
-			URL:    item.URL,
+			URL:    content.WithBasePath(basePath, item.URL),
 			Weight: item.Weight,
 		}
 	}
@@ -175,13 +189,26 @@ func (r *Renderer) BuildSiteContext(allPages []*content.Page) *tmpl.SiteContext
 }
 
 // RenderPage performs the full rendering pipeline for a single page:
-//  1. Renders the page's markdown content to HTML (with TOC).
+//  1. Renders the page's markdown content to HTML (with TOC), expanding
+//     its shortcodes and applying render hooks.
 //  2. Builds PageContext and SiteContext.
 //  3. Resolves the appropriate template.
 //  4. Executes the template and returns the rendered HTML bytes.
 func (r *Renderer) RenderPage(page *content.Page, allPages []*content.Page) ([]byte, error) {
-	// Render markdown content to HTML.
-	htmlContent, tocHTML, err := r.markdown.RenderWithTOC([]byte(page.RawContent))
+	// Render markdown content to HTML, with its shortcodes and render hooks.
+	var shortcodes content.ShortcodeFunc
+	var hooks content.RenderHookFunc
+	if r.engine.HasShortcodes() || len(r.engine.RenderHooks()) > 0 {
+		markupPage := r.BuildPageContext(page, allPages)
+		markupSite := r.BuildSiteContext(allPages)
+		shortcodes = func(sc *content.Shortcode) (string, error) {
+			return r.engine.RenderShortcode(sc, markupPage, markupSite)
+		}
+		hooks = func(h *content.RenderHook) (string, error) {
+			return r.engine.RenderHook(h, markupPage)
+		}
+	}
+	htmlContent, tocHTML, err := r.markdown.RenderPage(page, shortcodes, hooks)
 	if err != nil {
 		return nil, fmt.Errorf("rendering markdown for %q: %w", page.Title, err)
 	}
@@ -197,10 +224,10 @@ func (r *Renderer) RenderPage(page *content.Page, allPages []*content.Page) ([]b
 
 	// Resolve the template.
 	pageType := page.Type.String()
-	templateName := r.engine.Resolve(pageType, page.Section, page.Layout)
+	templateName := r.engine.Resolve(pageType, page.CurrentSection(), page.Layout)
 	if templateName == "" {
 		return nil, fmt.Errorf("no template found for page %q (type=%s, section=%s, layout=%s)",
-			page.Title, pageType, page.Section, page.Layout)
+			page.Title, pageType, page.CurrentSection(), page.Layout)
 	}
 
 	// Execute the template.
@@ -91,6 +91,11 @@ author:
   name: "Your Name"
   email: ""
 
+build:
+  fingerprint:
+    - "css/*.css"
+    - "js/*.js"
+
 menu:
   main:
     - name: "Home"
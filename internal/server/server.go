@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/aellingwood/forge/internal/config"
+	"github.com/aellingwood/forge/internal/content"
 	"github.com/aellingwood/forge/internal/security"
 )
 
@@ -74,7 +75,7 @@ func (s *Server) Start(ctx context.Context) error {
 		}()
 	}
 
-	fmt.Printf("Serving at http://%s:%d\n", s.options.Bind, s.options.Port)
+	fmt.Printf("Serving at http://%s:%d%s/\n", s.options.Bind, s.options.Port, content.BasePath(s.config.BaseURL))
 
 	// Listen for context cancellation to trigger graceful shutdown.
 	go func() {
@@ -120,10 +121,25 @@ func (s *Server) NotifyReload() {
 }
 
 // handleRequest serves static files from the output directory with support
-// for clean URLs and optional live reload script injection.
+// for clean URLs and optional live reload script injection. A site whose
+// baseURL has a path (e.g. https://example.com/docs) is served under that
+// prefix, as it will be in production.
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	urlPath := r.URL.Path
 
+	if basePath := content.BasePath(s.config.BaseURL); basePath != "" {
+		rest, ok := strings.CutPrefix(urlPath, basePath)
+		switch {
+		case urlPath == "/" || (ok && rest == ""):
+			http.Redirect(w, r, basePath+"/", http.StatusFound)
+			return
+		case !ok || !strings.HasPrefix(rest, "/"):
+			s.handle404(w, r)
+			return
+		}
+		urlPath = rest
+	}
+
 	// Try to resolve the file path.
 	filePath := s.resolveFilePath(urlPath)
 	if filePath == "" {
@@ -182,7 +198,10 @@ func (s *Server) setSecurityHeaders(w http.ResponseWriter, nonce string) {
 }
 
 // resolveFilePath maps a URL path to an actual file in the output directory.
-// It handles clean URLs by checking for index.html in directories.
+// It handles clean URLs by checking for index.html in directories, and ugly
+// URLs (build.cleanUrls=false) by falling back to the path plus ".html", so
+// "/blog/my-post/" finds blog/my-post.html even when a blog/my-post/
+// directory holds the page's bundle resources.
 func (s *Server) resolveFilePath(urlPath string) string {
 	outputDir := s.options.OutputDir
 
@@ -205,7 +224,9 @@ func (s *Server) resolveFilePath(urlPath string) string {
 		if _, err := os.Stat(indexPath); err == nil {
 			return indexPath
 		}
-		return ""
+		if fullPath == filepath.Clean(outputDir) {
+			return ""
+		}
 	}
 
 	// Try appending .html for extensionless URLs.
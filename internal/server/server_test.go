@@ -264,6 +264,81 @@ func TestHandleRequest_CleanURLs(t *testing.T) {
 	}
 }
 
+func TestHandleRequest_UglyURLs(t *testing.T) {
+	outputDir := t.TempDir()
+	writeTestFile(t, outputDir, "blog/my-post.html", "<html><body><h1>Post</h1></body></html>")
+	// Bundle resources live in a directory named after the page.
+	writeTestFile(t, outputDir, "blog/my-post/hero.jpg", "jpg")
+
+	srv := NewServer(config.Default(), ServeOptions{
+		Port:         1313,
+		Bind:         "localhost",
+		OutputDir:    outputDir,
+		NoLiveReload: true,
+	})
+
+	for _, path := range []string{"/blog/my-post.html", "/blog/my-post", "/blog/my-post/"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", path, nil)
+			rr := httptest.NewRecorder()
+			srv.handleRequest(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("expected status 200, got %d", rr.Code)
+			}
+			if !bytes.Contains(rr.Body.Bytes(), []byte("<h1>Post</h1>")) {
+				t.Error("expected post content in response")
+			}
+		})
+	}
+}
+
+func TestHandleRequest_BasePath(t *testing.T) {
+	outputDir := t.TempDir()
+	writeTestFile(t, outputDir, "index.html", "<html><body><h1>Home</h1></body></html>")
+	writeTestFile(t, outputDir, "blog/my-post/index.html", "<html><body><h1>Post</h1></body></html>")
+
+	cfg := config.Default()
+	cfg.BaseURL = "https://example.com/docs"
+	srv := NewServer(cfg, ServeOptions{
+		Port:         1313,
+		Bind:         "localhost",
+		OutputDir:    outputDir,
+		NoLiveReload: true,
+	})
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+		location string
+	}{
+		{"/docs/", http.StatusOK, "<h1>Home</h1>", ""},
+		{"/docs/blog/my-post/", http.StatusOK, "<h1>Post</h1>", ""},
+		{"/", http.StatusFound, "", "/docs/"},
+		{"/docs", http.StatusFound, "", "/docs/"},
+		{"/blog/my-post/", http.StatusNotFound, "", ""},
+		{"/docsx/blog/my-post/", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			srv.handleRequest(rr, req)
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && !bytes.Contains(rr.Body.Bytes(), []byte(tt.wantBody)) {
+				t.Errorf("body missing %q", tt.wantBody)
+			}
+			if loc := rr.Header().Get("Location"); loc != tt.location {
+				t.Errorf("Location = %q, want %q", loc, tt.location)
+			}
+		})
+	}
+}
+
 func TestHandleRequest_404(t *testing.T) {
 	outputDir := t.TempDir()
 
@@ -552,7 +627,7 @@ func TestWatcher_Debouncing(t *testing.T) {
 	var mu sync.Mutex
 	var lastCall time.Time
 
-	w := NewWatcher([]string{dir}, 100*time.Millisecond, func() {
+	w := NewWatcher([]string{dir}, 100*time.Millisecond, func(_ []string) {
 		mu.Lock()
 		lastCall = time.Now()
 		mu.Unlock()
@@ -597,9 +672,45 @@ func TestWatcher_Debouncing(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestWatcher_ReportsChangedPaths(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+
+	got := make(chan []string, 4)
+	w := NewWatcher([]string{dir}, 100*time.Millisecond, func(changed []string) {
+		got <- changed
+	})
+
+	go func() {
+		if err := w.Start(); err != nil {
+			t.Logf("watcher start error: %v", err)
+		}
+	}()
+	defer w.Stop()
+
+	time.Sleep(50 * time.Millisecond)
+
+	for _, f := range []string{b, a, b} {
+		if err := os.WriteFile(f, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case changed := <-got:
+		if len(changed) != 2 || changed[0] != a || changed[1] != b {
+			t.Errorf("changed = %v, want [%s %s]", changed, a, b)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for onChange")
+	}
+}
+
 func TestWatcher_NonexistentPaths(t *testing.T) {
 	// Watcher should gracefully handle nonexistent paths.
-	w := NewWatcher([]string{"/nonexistent/path/that/does/not/exist"}, 100*time.Millisecond, func() {})
+	w := NewWatcher([]string{"/nonexistent/path/that/does/not/exist"}, 100*time.Millisecond, func(_ []string) {})
 
 	go func() {
 		_ = w.Start()
@@ -610,7 +721,7 @@ func TestWatcher_NonexistentPaths(t *testing.T) {
 }
 
 func TestWatcher_StopIsIdempotent(t *testing.T) {
-	w := NewWatcher([]string{}, 100*time.Millisecond, func() {})
+	w := NewWatcher([]string{}, 100*time.Millisecond, func(_ []string) {})
 
 	go func() {
 		_ = w.Start()
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
@@ -15,22 +16,27 @@ import (
 // rapid successive changes into a single callback invocation.
 type Watcher struct {
 	paths    []string
-	onChange func()
+	onChange func(changed []string)
 	debounce time.Duration
 	watcher  *fsnotify.Watcher
 	done     chan struct{}
 	once     sync.Once
+
+	mu      sync.Mutex
+	pending map[string]struct{} // paths changed since the last callback
 }
 
 // NewWatcher creates a new Watcher that monitors the given paths for changes.
 // The onChange callback is invoked after changes have been debounced for the
-// specified duration.
-func NewWatcher(paths []string, debounce time.Duration, onChange func()) *Watcher {
+// specified duration and receives the sorted list of paths that changed
+// during that window.
+func NewWatcher(paths []string, debounce time.Duration, onChange func(changed []string)) *Watcher {
 	return &Watcher{
 		paths:    paths,
 		onChange: onChange,
 		debounce: debounce,
 		done:     make(chan struct{}),
+		pending:  make(map[string]struct{}),
 	}
 }
 
@@ -82,12 +88,16 @@ func (w *Watcher) Start() error {
 				}
 			}
 
+			w.mu.Lock()
+			w.pending[event.Name] = struct{}{}
+			w.mu.Unlock()
+
 			// Reset debounce timer.
 			if timer != nil {
 				timer.Stop()
 			}
 			timer = time.AfterFunc(w.debounce, func() {
-				w.onChange()
+				w.onChange(w.takePending())
 			})
 
 		case err, ok := <-fsw.Errors:
@@ -112,6 +122,20 @@ func (w *Watcher) Stop() {
 	})
 }
 
+// takePending returns the paths changed since the last call, sorted, and
+// resets the pending set.
+func (w *Watcher) takePending() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := make([]string, 0, len(w.pending))
+	for p := range w.pending {
+		changed = append(changed, p)
+	}
+	clear(w.pending)
+	slices.Sort(changed)
+	return changed
+}
+
 // addRecursive adds a directory and all its subdirectories to the watcher.
 func (w *Watcher) addRecursive(root string) error {
 	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
@@ -0,0 +1,203 @@
+// Package sitediff compares two builds of a site page by page, reporting
+// which pages were added, removed, or changed, with unified diffs of their
+// normalized HTML.
+package sitediff
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tdewolff/parse/v2"
+	"github.com/tdewolff/parse/v2/html"
+)
+
+// Page statuses.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// PageDiff is one page that differs between two builds.
+type PageDiff struct {
+	URL    string `json:"url"`            // e.g. "/blog/post/"
+	File   string `json:"file"`           // output file, e.g. "blog/post/index.html"
+	Status string `json:"status"`         // Added, Removed, or Changed
+	Diff   string `json:"diff,omitempty"` // unified diff of the normalized HTML (Changed only)
+}
+
+// Options controls how pages are compared.
+type Options struct {
+	// Ignore masks text matching any of these patterns in both builds before
+	// comparing, e.g. timestamps that differ on every build.
+	Ignore []*regexp.Regexp
+
+	// Context is the number of unchanged lines shown around each change.
+	Context int
+}
+
+// TimestampPatterns match full timestamps as templates print a time.Time
+// such as .Site.BuildDate: RFC 3339 and Go's default format.
+var TimestampPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`),
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)? [+-]\d{4} [A-Z0-9+-]+( m=[+-]\d+\.\d+)?`),
+}
+
+// ignoredText replaces text masked by Options.Ignore.
+const ignoredText = "[ignored]"
+
+// Compare compares the HTML files of the build in oldDir with those of the
+// build in newDir and returns the pages that differ, sorted by URL.
+func Compare(oldDir, newDir string, opts Options) ([]PageDiff, error) {
+	oldFiles, err := htmlFiles(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := htmlFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []PageDiff
+	for file := range newFiles {
+		if !oldFiles[file] {
+			diffs = append(diffs, PageDiff{URL: fileURL(file), File: file, Status: Added})
+		}
+	}
+	for file := range oldFiles {
+		if !newFiles[file] {
+			diffs = append(diffs, PageDiff{URL: fileURL(file), File: file, Status: Removed})
+			continue
+		}
+		diff, err := comparePage(oldDir, newDir, file, opts)
+		if err != nil {
+			return nil, err
+		}
+		if diff != "" {
+			diffs = append(diffs, PageDiff{URL: fileURL(file), File: file, Status: Changed, Diff: diff})
+		}
+	}
+	slices.SortFunc(diffs, func(a, b PageDiff) int {
+		return strings.Compare(a.URL, b.URL)
+	})
+	return diffs, nil
+}
+
+// comparePage returns the unified diff of the output file in the two
+// builds, or "" if they are the same after normalization.
+func comparePage(oldDir, newDir, file string, opts Options) (string, error) {
+	oldData, err := os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(file)))
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", file, err)
+	}
+	newData, err := os.ReadFile(filepath.Join(newDir, filepath.FromSlash(file)))
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", file, err)
+	}
+	if bytes.Equal(oldData, newData) {
+		return "", nil
+	}
+	for _, re := range opts.Ignore {
+		oldData = re.ReplaceAll(oldData, []byte(ignoredText))
+		newData = re.ReplaceAll(newData, []byte(ignoredText))
+	}
+	context := opts.Context
+	if context <= 0 {
+		context = 3
+	}
+	return Unified("a/"+file, "b/"+file, Normalize(oldData), Normalize(newData), context), nil
+}
+
+// htmlFiles returns the slash-separated paths of the HTML files in dir.
+func htmlFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".html") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return fmt.Errorf("computing relative path for %s: %w", p, err)
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// fileURL returns the URL an output file is served at: "blog/index.html"
+// is "/blog/", "404.html" is "/404.html".
+func fileURL(file string) string {
+	if file == "index.html" {
+		return "/"
+	}
+	if dir, ok := strings.CutSuffix(file, "/index.html"); ok {
+		return "/" + dir + "/"
+	}
+	return "/" + file
+}
+
+// Normalize splits an HTML document into lines for diffing: one line per
+// tag, comment, or run of text, with whitespace outside <pre>, <textarea>,
+// <script>, and <style> collapsed. Formatting-only differences, such as
+// indentation or minification, disappear.
+func Normalize(data []byte) []string {
+	var lines []string
+	var tag strings.Builder
+	var name, verbatim string // current start tag; element whose text is kept as is
+	l := html.NewLexer(parse.NewInputBytes(data))
+	for {
+		tt, raw := l.Next()
+		switch tt {
+		case html.ErrorToken:
+			return lines
+		case html.StartTagToken:
+			name = strings.ToLower(string(l.Text()))
+			tag.Reset()
+			tag.WriteString("<" + name)
+		case html.AttributeToken:
+			tag.WriteString(" " + collapseSpace(string(raw)))
+		case html.StartTagCloseToken, html.StartTagVoidToken:
+			tag.WriteString(strings.TrimSpace(string(raw)))
+			lines = append(lines, tag.String())
+			if verbatim == "" && tt == html.StartTagCloseToken {
+				switch name {
+				case "pre", "textarea", "script", "style":
+					verbatim = name
+				}
+			}
+		case html.EndTagToken:
+			end := strings.ToLower(collapseSpace(string(raw)))
+			lines = append(lines, end)
+			if verbatim != "" && end == "</"+verbatim+">" {
+				verbatim = ""
+			}
+		case html.TextToken:
+			if verbatim != "" {
+				lines = append(lines, strings.Split(strings.Trim(string(raw), "\n"), "\n")...)
+			} else if text := collapseSpace(string(raw)); text != "" {
+				lines = append(lines, text)
+			}
+		default:
+			// Doctype, comments, and inline SVG or MathML.
+			lines = append(lines, collapseSpace(string(raw)))
+		}
+	}
+}
+
+// collapseSpace trims s and replaces each run of whitespace with a space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,111 @@
+package sitediff
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	writeFiles(t, oldDir, map[string]string{
+		"index.html":        "<html><body><h1>Home</h1></body></html>",
+		"blog/a/index.html": "<html>\n  <body>\n    <p>Same</p>\n  </body>\n</html>",
+		"blog/b/index.html": "<p>Old text</p>",
+		"old/index.html":    "<p>gone</p>",
+		"css/style.css":     "body{}",
+		"about/index.html":  "<footer>Built 2024-01-02T03:04:05Z</footer>",
+	})
+	writeFiles(t, newDir, map[string]string{
+		"index.html":        "<html><body><h1>Home</h1></body></html>",
+		"blog/a/index.html": "<html><body><p>Same</p></body></html>", // formatting only
+		"blog/b/index.html": "<p>New text</p>",
+		"new.html":          "<p>new</p>",
+		"css/style.css":     "body{color:red}",
+		"about/index.html":  "<footer>Built 2026-10-16T12:00:00+02:00</footer>",
+	})
+
+	diffs, err := Compare(oldDir, newDir, Options{Ignore: TimestampPatterns})
+	if err != nil {
+		t.Fatalf("Compare() error: %v", err)
+	}
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Status+" "+d.URL)
+	}
+	want := []string{"changed /blog/b/", "added /new.html", "removed /old/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Compare() = %v, want %v", got, want)
+	}
+	if !strings.Contains(diffs[0].Diff, "-Old text\n+New text\n") {
+		t.Errorf("diff of /blog/b/ =\n%s", diffs[0].Diff)
+	}
+
+	// Without ignore patterns the timestamp shows up.
+	diffs, err = Compare(oldDir, newDir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs[0].URL != "/about/" {
+		t.Errorf("first diff = %s, want /about/ with its timestamp", diffs[0].URL)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	doc := "<!DOCTYPE html>\n<html>\n  <body class=\"a\n  b\">\n    <p>Hello,\n      world</p>\n<pre><code>  indented\n    code</code></pre><br/>\n  </body>\n</html>"
+	want := []string{
+		"<!DOCTYPE html>",
+		"<html>",
+		`<body class="a b">`,
+		"<p>",
+		"Hello, world",
+		"</p>",
+		"<pre>",
+		"<code>",
+		"  indented",
+		"    code",
+		"</code>",
+		"</pre>",
+		"<br/>",
+		"</body>",
+		"</html>",
+	}
+	if got := Normalize([]byte(doc)); !slices.Equal(got, want) {
+		t.Errorf("Normalize() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTimestampPatterns(t *testing.T) {
+	for _, s := range []string{
+		"2026-10-16T12:00:00Z",
+		"2026-10-16T12:00:00.123+02:00",
+		"2026-10-16 12:00:00.123456789 +0200 CEST",
+		"2026-10-16 12:00:00 +0000 UTC m=+0.001234567",
+	} {
+		masked := s
+		for _, re := range TimestampPatterns {
+			masked = re.ReplaceAllString(masked, ignoredText)
+		}
+		if masked != ignoredText {
+			t.Errorf("%q masked to %q", s, masked)
+		}
+	}
+	if TimestampPatterns[0].MatchString("2026-10-16") {
+		t.Error("a plain date should not be masked")
+	}
+}
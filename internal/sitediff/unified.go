@@ -0,0 +1,205 @@
+package sitediff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// op is the kind of an edit in a line diff.
+type op int8
+
+const (
+	opEqual op = iota
+	opDelete
+	opInsert
+)
+
+// edit is one line of a diff: kept, removed from a, or added from b.
+type edit struct {
+	op   op
+	line string
+}
+
+// diffLines returns the shortest edit script turning a into b, using the
+// Myers algorithm. Common leading and trailing lines are split off first,
+// since page changes are usually local.
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	return edits
+}
+
+// myers computes the edit script of a and b. trace[d] holds the furthest
+// x reached on each diagonal k in [-d, d] after d edits, at index k+d.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	maxD := n + m
+	v := make([]int, 2*maxD+3)
+	off := maxD + 1
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // down: insert from b
+			} else {
+				x = v[off+k-1] + 1 // right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+		}
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[off-d:off+d+1])
+		trace = append(trace, snapshot)
+		if done(snapshot, d, n, m) {
+			break
+		}
+	}
+	return backtrack(a, b, trace)
+}
+
+// done reports whether step d reached the end of both inputs.
+func done(vd []int, d, n, m int) bool {
+	k := n - m
+	if k < -d || k > d || (k+d)%2 != 0 {
+		return false
+	}
+	return vd[k+d] >= n
+}
+
+// backtrack walks trace from the end of both inputs back to the start,
+// collecting the edits in reverse.
+func backtrack(a, b []string, trace [][]int) []edit {
+	x, y := len(a), len(b)
+	var rev []edit
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, edit{opEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			rev = append(rev, edit{opInsert, b[y]})
+		} else {
+			x--
+			rev = append(rev, edit{opDelete, a[x]})
+		}
+	}
+	for x > 0 {
+		x--
+		rev = append(rev, edit{opEqual, a[x]})
+	}
+
+	edits := make([]edit, len(rev))
+	for i, e := range rev {
+		edits[len(rev)-1-i] = e
+	}
+	return edits
+}
+
+// Unified returns a unified diff of the lines a and b, labelled oldName and
+// newName, with context unchanged lines around each change. It returns ""
+// if a and b are equal.
+func Unified(oldName, newName string, a, b []string, context int) string {
+	edits := diffLines(a, b)
+
+	// Line positions in a and b before each edit.
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if e.op != opInsert {
+			aPos[i+1]++
+		}
+		if e.op != opDelete {
+			bPos[i+1]++
+		}
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(edits); {
+		if edits[i].op == opEqual {
+			i++
+			continue
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+
+		// A hunk runs from context lines before its first change to context
+		// lines after its last, merging changes separated by at most
+		// 2*context unchanged lines.
+		start := max(i-context, 0)
+		end := i + 1
+		for j := i + 1; j < len(edits); j++ {
+			if edits[j].op != opEqual {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+		stop := min(end+context, len(edits))
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aPos[start], aPos[stop]-aPos[start]),
+			hunkRange(bPos[start], bPos[stop]-bPos[start]))
+		for _, e := range edits[start:stop] {
+			switch e.op {
+			case opEqual:
+				sb.WriteByte(' ')
+			case opDelete:
+				sb.WriteByte('-')
+			case opInsert:
+				sb.WriteByte('+')
+			}
+			sb.WriteString(e.line)
+			sb.WriteByte('\n')
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+// hunkRange formats the range of a hunk header: the 1-based first line and
+// the line count, or the line before an empty range.
+func hunkRange(pos, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", pos+1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, count)
+}
@@ -0,0 +1,71 @@
+package sitediff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	a := strings.Split("a b c d e f g h i j", " ")
+	b := strings.Split("a b X d e f g h i j Y", " ")
+
+	got := Unified("old", "new", a, b, 1)
+	want := `--- old
++++ new
+@@ -2,3 +2,3 @@
+ b
+-c
++X
+ d
+@@ -10 +10,2 @@
+ j
++Y
+`
+	if got != want {
+		t.Errorf("Unified() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnified_MergesNearbyChanges(t *testing.T) {
+	a := strings.Split("a b c d e", " ")
+	b := strings.Split("a X c Y e", " ")
+
+	got := Unified("old", "new", a, b, 1)
+	if n := strings.Count(got, "@@ -"); n != 1 {
+		t.Errorf("got %d hunks, want 1:\n%s", n, got)
+	}
+	if !strings.Contains(got, "@@ -1,5 +1,5 @@") {
+		t.Errorf("unexpected hunk header:\n%s", got)
+	}
+}
+
+func TestUnified_Equal(t *testing.T) {
+	lines := []string{"a", "b"}
+	if got := Unified("old", "new", lines, lines, 3); got != "" {
+		t.Errorf("Unified() of equal input = %q, want empty", got)
+	}
+}
+
+func TestDiffLines_Reconstructs(t *testing.T) {
+	tests := [][2]string{
+		{"", "a b c"},
+		{"a b c", ""},
+		{"a b c a b b a", "c b a b a c"},
+		{"x y z", "a b c"},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt[0]), strings.Fields(tt[1])
+		var gotA, gotB []string
+		for _, e := range diffLines(a, b) {
+			if e.op != opInsert {
+				gotA = append(gotA, e.line)
+			}
+			if e.op != opDelete {
+				gotB = append(gotB, e.line)
+			}
+		}
+		if strings.Join(gotA, " ") != tt[0] || strings.Join(gotB, " ") != tt[1] {
+			t.Errorf("diffLines(%q, %q) reconstructs %q, %q", tt[0], tt[1], gotA, gotB)
+		}
+	}
+}
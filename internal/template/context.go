@@ -2,6 +2,8 @@ package template
 
 import (
 	"html/template"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,8 @@ type PageContext struct {
 	Summary         template.HTML
 	Date            time.Time
 	Lastmod         time.Time
+	PublishDate     time.Time
+	ExpiryDate      time.Time
 	Draft           bool
 	Slug            string
 	URL             string
@@ -30,8 +34,13 @@ type PageContext struct {
 	TableOfContents template.HTML
 	PrevPage        *PageContext
 	NextPage        *PageContext
+	Parent          *PageContext   // enclosing section's list page, or the home page
+	Ancestors       []*PageContext // from the home page down to Parent, for breadcrumbs
+	Sections        []*PageContext // list pages of the child sections, by weight then title
 	Section         string
-	Type            string // "single", "list", "taxonomy", "home", etc.
+	Path            string     // content file, e.g. "blog/post.md"; empty for generated pages
+	Type            string     // "single", "list", "taxonomy", "home", etc.
+	Paginator       *Paginator // set on list and taxonomy term pages, and on the home page with paginate
 
 	Site *SiteContext
 }
@@ -48,6 +57,21 @@ type CoverImage struct {
 	Sizes      string // precomputed sizes attribute
 }
 
+// Paginator mirrors content.Pager for templates. Pages holds only the
+// entries that belong on the current page of the list.
+type Paginator struct {
+	Pages      []*PageContext
+	PageNumber int
+	TotalPages int
+	HasPrev    bool
+	HasNext    bool
+	URL        string
+	PrevURL    string
+	NextURL    string
+	First      string
+	Last       string
+}
+
 // SiteContext holds site-wide data accessible as .Site in templates.
 type SiteContext struct {
 	Title       string
@@ -58,10 +82,40 @@ type SiteContext struct {
 	Menu        []MenuItemContext
 	Params      map[string]any
 	Data        map[string]any
-	Pages       []*PageContext
+	Pages       []*PageContext // pages listed site-wide (see build.list)
 	Sections    map[string][]*PageContext
 	Taxonomies  map[string]map[string][]*PageContext
 	BuildDate   time.Time
+
+	// AllPages holds every page, including unlisted and unrendered ones,
+	// for GetPage.
+	AllPages []*PageContext
+}
+
+// GetPage returns the page with the given content path, or nil. The path
+// may omit the leading slash and the .md extension, and a bundle or section
+// is found by its directory: "snippets/hero" matches "snippets/hero.md",
+// "snippets/hero/index.md", and "snippets/hero/_index.md".
+func (s *SiteContext) GetPage(path string) *PageContext {
+	want := pageKey(path)
+	for _, pc := range s.AllPages {
+		if pc.Path != "" && pageKey(pc.Path) == want {
+			return pc
+		}
+	}
+	return nil
+}
+
+// pageKey normalizes a content path for GetPage.
+func pageKey(path string) string {
+	key := strings.TrimSuffix(strings.Trim(path, "/"), ".md")
+	for _, index := range []string{"index", "_index"} {
+		if key == index {
+			return ""
+		}
+		key = strings.TrimSuffix(key, "/"+index)
+	}
+	return key
 }
 
 // AuthorContext mirrors config.AuthorConfig for templates.
@@ -88,3 +142,65 @@ type MenuItemContext struct {
 	URL    string
 	Weight int
 }
+
+// ShortcodeContext is the data passed to a shortcode template,
+// layouts/shortcodes/<name>.html, as ".".
+type ShortcodeContext struct {
+	Name string
+
+	// Params holds the call's arguments: named ones by name, positional
+	// ones by index ("0", "1", ...).
+	Params map[string]string
+
+	// Inner is the content between the opening and closing tags, as
+	// written. In a {{% %}} call it is Markdown, rendered along with the
+	// shortcode's output.
+	Inner template.HTML
+
+	// Page is the page the call is in. Shortcodes render with the page's
+	// Markdown, so Page and the pages of Site carry no rendered content.
+	Page *PageContext
+	Site *SiteContext
+}
+
+// Get returns the named argument, or the positional one for an int index,
+// or "" if the call has no such argument.
+func (s *ShortcodeContext) Get(key any) string {
+	switch k := key.(type) {
+	case int:
+		return s.Params[strconv.Itoa(k)]
+	case string:
+		return s.Params[k]
+	}
+	return ""
+}
+
+// RenderHookContext is the data passed to a render hook template,
+// layouts/_markup/render-<kind>.html, as ".". The fields that do not apply
+// to the kind of element are empty.
+type RenderHookContext struct {
+	// Destination and Title are the URL and title of a link or image.
+	Destination string
+	Title       string
+
+	// Text is the content of a link or heading, or the alt text of an
+	// image, rendered as HTML. PlainText is the same without HTML tags.
+	Text      template.HTML
+	PlainText string
+
+	// Level and Anchor are the level (1 to 6) and ID of a heading.
+	Level  int
+	Anchor string
+
+	// Type is the language of a code block, and Inner its code.
+	Type  string
+	Inner string
+
+	// Attributes holds the {.class #id key=value} attributes of a heading
+	// or code block.
+	Attributes map[string]any
+
+	// Page is the page the element is in, without rendered content as in
+	// ShortcodeContext.
+	Page *PageContext
+}
@@ -8,15 +8,76 @@ import (
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/aellingwood/forge/internal/content"
 )
 
+// baseofName is the base layout that page templates render through.
+const baseofName = "_default/baseof.html"
+
+// shortcodeDir holds the shortcode templates, one per shortcode name.
+const shortcodeDir = "shortcodes/"
+
+// markupDir holds the render hook templates, render-<kind>.html, one per
+// kind of Markdown element.
+const markupDir = "_markup/"
+
 // Engine wraps Go's html/template with layout resolution, custom functions,
 // and theme/user layout overlaying.
 type Engine struct {
 	templates *template.Template
 	funcMap   template.FuncMap
 	sources   map[string]string
+
+	// assets maps asset URLs to fingerprinted URLs for the asset and
+	// fingerprint functions. It is set once, before rendering.
+	assets map[string]string
+
+	// uglyURLs makes URL functions produce .html URLs (build.cleanUrls=false).
+	uglyURLs bool
+
+	// permalinks holds the site's permalinks patterns, which termURL
+	// follows for taxonomies that have one.
+	permalinks map[string]string
+
+	// basePath is the path the site is hosted under (e.g. "/docs"), which
+	// URL functions prefix to root-relative URLs.
+	basePath string
+
+	// buildDate, if set, is the time the now function returns and the seed
+	// of shuffle, so that a build's output depends only on its inputs.
+	buildDate time.Time
+
+	// pageSets holds one precompiled, isolated set per page template.
+	pageSets map[string]*pageSet
+}
+
+// pageSet is the template set for one page template: all partials, baseof,
+// and the page template, which supplies the "main" block. The compiled set
+// is never executed, so it stays cloneable; pages render through a single
+// clone made on first use, which html/template escapes once and can execute
+// concurrently.
+type pageSet struct {
+	compiled *template.Template
+
+	once sync.Once
+	live *template.Template
+	err  error
+}
+
+// instance returns the executable clone of the set, creating it on first use.
+func (ps *pageSet) instance() (*template.Template, error) {
+	ps.once.Do(func() {
+		ps.live, ps.err = ps.compiled.Clone()
+		if ps.err == nil {
+			ps.live.Funcs(template.FuncMap{"partial": setPartial(ps.live)})
+		}
+	})
+	return ps.live, ps.err
 }
 
 // NewEngine creates a template Engine by loading .html files from the theme
@@ -63,6 +124,29 @@ func NewEngine(themePath, userLayoutPath string) (*Engine, error) {
 	e.funcMap["partial"] = func(name string, ctx any) (template.HTML, error) {
 		return e.executePartial(name, ctx)
 	}
+	e.funcMap["relURL"] = func(path string) string {
+		return content.WithBasePath(e.basePath, relURL(path))
+	}
+	assetFn := func(path string) string {
+		return content.WithBasePath(e.basePath, assetURL(e.assets, path))
+	}
+	e.funcMap["asset"] = assetFn
+	e.funcMap["fingerprint"] = assetFn
+	e.funcMap["termURL"] = func(taxonomy, term string) string {
+		return content.WithBasePath(e.basePath, termURL(e.uglyURLs, e.permalinks, taxonomy, term))
+	}
+	e.funcMap["now"] = func() time.Time {
+		if e.buildDate.IsZero() {
+			return now()
+		}
+		return e.buildDate
+	}
+	e.funcMap["shuffle"] = func(items any) any {
+		if e.buildDate.IsZero() {
+			return shuffle(items)
+		}
+		return shuffleSeeded(items, e.buildDate.UnixNano())
+	}
 	// Re-register the func map so the partial function is available.
 	// We need to re-parse because Go templates bind functions at parse time.
 	// Instead, we rebuild from scratch with the partial function in place.
@@ -81,9 +165,82 @@ func NewEngine(themePath, userLayoutPath string) (*Engine, error) {
 	}
 	e.templates = root
 
+	if err := e.compilePageSets(); err != nil {
+		return nil, err
+	}
+
 	return e, nil
 }
 
+// compilePageSets parses an isolated template set for every page template
+// (everything except partials, shortcodes and render hooks) so that
+// ExecutePage never re-parses sources. Without a baseof there is nothing to
+// compile; ExecutePage falls back to Execute.
+func (e *Engine) compilePageSets() error {
+	if _, ok := e.sources[baseofName]; !ok {
+		return nil
+	}
+	e.pageSets = make(map[string]*pageSet, len(e.sources))
+	for name := range e.sources {
+		if strings.HasPrefix(name, "partials/") || strings.HasPrefix(name, shortcodeDir) || strings.HasPrefix(name, markupDir) {
+			continue
+		}
+		set, err := e.parsePageSet(name)
+		if err != nil {
+			return err
+		}
+		e.pageSets[name] = &pageSet{compiled: set}
+	}
+	return nil
+}
+
+// parsePageSet builds the set used to render templateName: all partials,
+// baseof, and templateName itself, which supplies the "main" block.
+func (e *Engine) parsePageSet(templateName string) (*template.Template, error) {
+	set := template.New("").Funcs(e.funcMap)
+
+	for name, src := range e.sources {
+		if strings.HasPrefix(name, "partials/") {
+			if _, err := set.New(name).Parse(src); err != nil {
+				return nil, fmt.Errorf("parsing partial %s: %w", name, err)
+			}
+		}
+	}
+
+	if _, err := set.New(baseofName).Parse(e.sources[baseofName]); err != nil {
+		return nil, fmt.Errorf("parsing baseof: %w", err)
+	}
+
+	if templateName != baseofName {
+		if _, err := set.New(templateName).Parse(e.sources[templateName]); err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", templateName, err)
+		}
+	}
+	return set, nil
+}
+
+// setPartial returns a partial function that resolves partials within set.
+func setPartial(set *template.Template) func(string, any) (template.HTML, error) {
+	return func(name string, pctx any) (template.HTML, error) {
+		tmplName := name
+		if !strings.HasPrefix(name, "partials/") {
+			tmplName = "partials/" + name
+		}
+		t := set.Lookup(tmplName)
+		if t == nil {
+			t = set.Lookup(name)
+		}
+		if t == nil {
+			return "", fmt.Errorf("partial template %q not found", name)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, pctx); err != nil {
+			return "", fmt.Errorf("executing partial %q: %w", name, err)
+		}
+		return template.HTML(buf.String()), nil
+	}
+}
+
 // executePartial executes a partial template and returns the rendered HTML.
 func (e *Engine) executePartial(name string, ctx any) (template.HTML, error) {
 	// Look for the partial template. Try with and without "partials/" prefix.
@@ -147,26 +304,30 @@ func collectTemplateFiles(dir string) (map[string]string, error) {
 
 // Resolve returns the name of the first matching template for the given page
 // type, section, and layout, following the layout resolution order described
-// in the spec. If no matching template is found, an empty string is returned.
+// in the spec. A nested section such as "docs/guides" falls back through its
+// parents: "docs/guides/single.html", then "docs/single.html". If no
+// matching template is found, an empty string is returned.
 func (e *Engine) Resolve(pageType, section, layout string) string {
 	var candidates []string
 
 	switch pageType {
 	case "single":
-		if layout != "" {
-			candidates = append(candidates, section+"/"+layout+".html")
+		for _, dir := range sectionDirs(section) {
+			if layout != "" {
+				candidates = append(candidates, dir+"/"+layout+".html")
+			}
+			candidates = append(candidates, dir+"/single.html")
 		}
-		candidates = append(candidates, section+"/single.html")
 		if layout != "" {
 			candidates = append(candidates, "_default/"+layout+".html")
 		}
 		candidates = append(candidates, "_default/single.html")
 
 	case "list":
-		candidates = append(candidates,
-			section+"/list.html",
-			"_default/list.html",
-		)
+		for _, dir := range sectionDirs(section) {
+			candidates = append(candidates, dir+"/list.html")
+		}
+		candidates = append(candidates, "_default/list.html")
 
 	case "home":
 		candidates = append(candidates,
@@ -200,6 +361,21 @@ func (e *Engine) Resolve(pageType, section, layout string) string {
 	return ""
 }
 
+// sectionDirs returns the layout directories for a section path, deepest
+// first: "docs/guides" yields "docs/guides" and "docs".
+func sectionDirs(section string) []string {
+	var dirs []string
+	for section != "" {
+		dirs = append(dirs, section)
+		i := strings.LastIndex(section, "/")
+		if i < 0 {
+			break
+		}
+		section = section[:i]
+	}
+	return dirs
+}
+
 // Execute renders the named template with the given PageContext and returns
 // the output bytes.
 func (e *Engine) Execute(templateName string, ctx *PageContext) ([]byte, error) {
@@ -216,84 +392,164 @@ func (e *Engine) Execute(templateName string, ctx *PageContext) ([]byte, error)
 }
 
 // ExecutePage renders a page template through the baseof layout using an
-// isolated per-render template set to avoid "main" block definition conflicts
-// between pages. Each call builds a fresh set containing only partials +
-// _default/baseof.html + the specific templateName, then executes baseof.
-// Falls back to Execute if no baseof source is available.
+// isolated template set to avoid "main" block definition conflicts between
+// pages. The set (partials + _default/baseof.html + templateName) is
+// precompiled by NewEngine and cloned once per template on first render, so
+// rendering never re-parses sources. Falls back to Execute if no baseof
+// source is available.
 func (e *Engine) ExecutePage(templateName string, ctx *PageContext) ([]byte, error) {
-	const baseof = "_default/baseof.html"
-
 	// Fall back to Execute if baseof is not available.
-	if _, ok := e.sources[baseof]; !ok {
+	if e.pageSets == nil {
 		return e.Execute(templateName, ctx)
 	}
 
-	// Build a per-render isolated template set. The partial closure captures
-	// localSet by variable reference so it uses the fully-populated set when
-	// actually called during execution.
-	var localSet *template.Template
+	ps, ok := e.pageSets[templateName]
+	if !ok {
+		return nil, fmt.Errorf("template %q not found", templateName)
+	}
+	set, err := ps.instance()
+	if err != nil {
+		return nil, fmt.Errorf("cloning template set %s: %w", templateName, err)
+	}
 
-	localFuncMap := make(template.FuncMap, len(e.funcMap))
-	maps.Copy(localFuncMap, e.funcMap)
-	localFuncMap["partial"] = func(name string, pctx any) (template.HTML, error) {
-		tmplName := name
-		if !strings.HasPrefix(name, "partials/") {
-			tmplName = "partials/" + name
-		}
-		t := localSet.Lookup(tmplName)
-		if t == nil {
-			t = localSet.Lookup(name)
-		}
-		if t == nil {
-			return "", fmt.Errorf("partial template %q not found", name)
-		}
-		var buf bytes.Buffer
-		if err := t.Execute(&buf, pctx); err != nil {
-			return "", fmt.Errorf("executing partial %q: %w", name, err)
-		}
-		return template.HTML(buf.String()), nil
+	// Execute baseof, which calls {{ block "main" . }} resolved by templateName.
+	var buf bytes.Buffer
+	if err := set.Lookup(baseofName).Execute(&buf, ctx); err != nil {
+		return nil, fmt.Errorf("executing template %q via baseof: %w", templateName, err)
 	}
+	return buf.Bytes(), nil
+}
 
-	localSet = template.New("").Funcs(localFuncMap)
+// RenderShortcode renders a shortcode call in page with its template,
+// shortcodes/<name>.html. Its output is HTML, or Markdown for a {{% %}}
+// call.
+func (e *Engine) RenderShortcode(sc *content.Shortcode, page *PageContext, site *SiteContext) (string, error) {
+	name := shortcodeDir + sc.Name + ".html"
+	t := e.templates.Lookup(name)
+	if t == nil {
+		return "", fmt.Errorf("%w: template %s not found", content.ErrUnknownShortcode, name)
+	}
+	var buf bytes.Buffer
+	err := t.Execute(&buf, &ShortcodeContext{
+		Name:   sc.Name,
+		Params: sc.Params,
+		Inner:  template.HTML(sc.Inner),
+		Page:   page,
+		Site:   site,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
 
-	// Add all partials.
-	for name, src := range e.sources {
-		if strings.HasPrefix(name, "partials/") {
-			t := localSet.New(name)
-			if _, err := t.Parse(src); err != nil {
-				return nil, fmt.Errorf("parsing partial %s: %w", name, err)
-			}
+// HasShortcodes reports whether any shortcode template exists.
+func (e *Engine) HasShortcodes() bool {
+	for name := range e.sources {
+		if strings.HasPrefix(name, shortcodeDir) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Add baseof.
-	baseofT := localSet.New(baseof)
-	if _, err := baseofT.Parse(e.sources[baseof]); err != nil {
-		return nil, fmt.Errorf("parsing baseof: %w", err)
+// RenderHook renders a Markdown element of page with its render hook
+// template, _markup/render-<kind>.html.
+func (e *Engine) RenderHook(h *content.RenderHook, page *PageContext) (string, error) {
+	name := markupDir + "render-" + h.Kind + ".html"
+	t := e.templates.Lookup(name)
+	if t == nil {
+		return "", fmt.Errorf("template %s not found", name)
+	}
+	var buf bytes.Buffer
+	err := t.Execute(&buf, &RenderHookContext{
+		Destination: h.Destination,
+		Title:       h.Title,
+		Text:        template.HTML(h.Text),
+		PlainText:   h.PlainText,
+		Level:       h.Level,
+		Anchor:      h.Anchor,
+		Type:        h.Type,
+		Inner:       h.Inner,
+		Attributes:  h.Attributes,
+		Page:        page,
+	})
+	if err != nil {
+		return "", err
 	}
+	return buf.String(), nil
+}
 
-	// Add the specific page template (produces the "main" block definition).
-	if templateName != baseof {
-		src, ok := e.sources[templateName]
-		if !ok {
-			return nil, fmt.Errorf("template %q not found", templateName)
-		}
-		t := localSet.New(templateName)
-		if _, err := t.Parse(src); err != nil {
-			return nil, fmt.Errorf("parsing template %s: %w", templateName, err)
+// RenderHooks returns the kinds of Markdown elements (see
+// content.RenderHookKinds) that have a render hook template.
+func (e *Engine) RenderHooks() []string {
+	var kinds []string
+	for _, kind := range content.RenderHookKinds {
+		if _, ok := e.sources[markupDir+"render-"+kind+".html"]; ok {
+			kinds = append(kinds, kind)
 		}
 	}
+	return kinds
+}
 
-	// Execute baseof, which calls {{ block "main" . }} resolved by templateName.
-	t := localSet.Lookup(baseof)
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, ctx); err != nil {
-		return nil, fmt.Errorf("executing template %q via baseof: %w", templateName, err)
-	}
-	return buf.Bytes(), nil
+// SetAssets sets the asset manifest used by the asset and fingerprint
+// template functions, mapping original asset URLs ("/css/style.css") to
+// fingerprinted ones. It must be called before any template is executed.
+func (e *Engine) SetAssets(manifest map[string]string) {
+	e.assets = manifest
+}
+
+// SetUglyURLs makes URL template functions produce .html URLs, matching
+// pages built with build.cleanUrls set to false. It must be called before any
+// template is executed.
+func (e *Engine) SetUglyURLs(ugly bool) {
+	e.uglyURLs = ugly
+}
+
+// SetPermalinks sets the site's permalinks patterns (config.Permalinks), so
+// that termURL links terms where their pages are written. It must be called
+// before any template is executed.
+func (e *Engine) SetPermalinks(patterns map[string]string) {
+	e.permalinks = patterns
+}
+
+// SetBasePath sets the path the site is hosted under (e.g. "/docs"), which
+// relURL, asset, and termURL prefix to the URLs they return. It must be
+// called before any template is executed.
+func (e *Engine) SetBasePath(basePath string) {
+	e.basePath = basePath
+}
+
+// SetBuildDate fixes the time returned by the now template function and
+// seeds shuffle from it, so rendering the same pages twice produces the same
+// output. It must be called before any template is executed.
+func (e *Engine) SetBuildDate(t time.Time) {
+	e.buildDate = t
 }
 
 // HasTemplate reports whether a template with the given name exists.
 func (e *Engine) HasTemplate(name string) bool {
 	return e.templates.Lookup(name) != nil
 }
+
+// Dependencies returns the sorted names of every template that can take part
+// in rendering templateName: the template itself, _default/baseof.html when
+// present, and all partials (each render set includes every partial).
+func (e *Engine) Dependencies(templateName string) []string {
+	deps := []string{templateName}
+	for name := range e.sources {
+		if name == baseofName || strings.HasPrefix(name, "partials/") {
+			if name != templateName {
+				deps = append(deps, name)
+			}
+		}
+	}
+	slices.Sort(deps)
+	return deps
+}
+
+// Source returns the raw source of the named template, or "" if there is no
+// such template.
+func (e *Engine) Source(name string) string {
+	return e.sources[name]
+}
@@ -1,12 +1,17 @@
 package template
 
 import (
+	"bytes"
+	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/aellingwood/forge/internal/content"
 )
 
 // testdataThemePath returns the path to testdata used as a "theme" directory.
@@ -213,6 +218,13 @@ func TestFuncMap(t *testing.T) {
 		}
 	})
 
+	t.Run("hasPrefix", func(t *testing.T) {
+		fn := fm["hasPrefix"].(func(string, string) bool)
+		if !fn("/images/a.png", "/") || fn("a.png", "/") {
+			t.Error("hasPrefix should report whether s starts with prefix")
+		}
+	})
+
 	t.Run("slugify", func(t *testing.T) {
 		fn := fm["slugify"].(func(string) string)
 
@@ -517,6 +529,26 @@ func TestHasTemplate(t *testing.T) {
 	}
 }
 
+func TestDependencies(t *testing.T) {
+	eng, err := NewEngine(testdataThemePath(t), "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	got := eng.Dependencies("_default/single.html")
+	want := []string{"_default/baseof.html", "_default/single.html", "partials/header.html"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+
+	if eng.Source("partials/header.html") == "" {
+		t.Error("Source should return the partial's source")
+	}
+	if eng.Source("nonexistent.html") != "" {
+		t.Error("Source should be empty for a missing template")
+	}
+}
+
 func TestReadFile(t *testing.T) {
 	// Create a temp file to read.
 	tmp := t.TempDir()
@@ -602,6 +634,48 @@ func TestResolveTaxonomy(t *testing.T) {
 	}
 }
 
+func TestResolveNestedSection(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"_default/single.html":  `single`,
+		"_default/list.html":    `list`,
+		"docs/single.html":      `docs single`,
+		"docs/guides/list.html": `guides list`,
+		"docs/guides/wide.html": `guides wide`,
+		"docs/reference.html":   `docs reference`,
+	} {
+		fullPath := filepath.Join(tmp, "layouts", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng, err := NewEngine(tmp, "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	tests := []struct {
+		pageType, section, layout, want string
+	}{
+		{"single", "docs/guides/install", "", "docs/single.html"},
+		{"single", "docs/guides/install", "wide", "docs/guides/wide.html"},
+		{"single", "docs/guides/install", "reference", "docs/reference.html"},
+		{"list", "docs/guides/install", "", "docs/guides/list.html"},
+		{"list", "docs/guides", "", "docs/guides/list.html"},
+		{"list", "docs", "", "_default/list.html"},
+		{"single", "blog/2024", "", "_default/single.html"},
+	}
+	for _, tt := range tests {
+		if got := eng.Resolve(tt.pageType, tt.section, tt.layout); got != tt.want {
+			t.Errorf("Resolve(%q, %q, %q) = %q, want %q", tt.pageType, tt.section, tt.layout, got, tt.want)
+		}
+	}
+}
+
 func TestExecutePage(t *testing.T) {
 	// Build a theme with baseof + two distinct page templates.
 	themeDir := t.TempDir()
@@ -697,3 +771,302 @@ func TestExecutePage(t *testing.T) {
 		}
 	})
 }
+
+func TestExecutePage_PartialsAndConcurrency(t *testing.T) {
+	themeDir := t.TempDir()
+	for name, content := range map[string]string{
+		"_default/baseof.html": `<html>{{ partial "nav.html" . }}{{ block "main" . }}{{ end }}</html>`,
+		"_default/single.html": `{{ define "main" }}<article>{{ .Title }}{{ partial "partials/byline.html" . }}</article>{{ end }}`,
+		"partials/nav.html":    `<nav>{{ .Site.Title }}</nav>`,
+		"partials/byline.html": `<span>by {{ .Site.Author.Name }}</span>`,
+	} {
+		path := filepath.Join(themeDir, "layouts", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng, err := NewEngine(themeDir, "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+
+	if _, err := eng.ExecutePage("_default/nonexistent.html", &PageContext{}); err == nil {
+		t.Error("expected error for unknown template")
+	}
+
+	site := &SiteContext{Title: "Site", Author: AuthorContext{Name: "Ada"}}
+	errs := make(chan error, 16)
+	for i := range 16 {
+		go func() {
+			title := fmt.Sprintf("Post %d", i)
+			out, err := eng.ExecutePage("_default/single.html", &PageContext{Title: title, Site: site})
+			if err == nil && string(out) != "<html><nav>Site</nav><article>"+title+"<span>by Ada</span></article></html>" {
+				err = fmt.Errorf("unexpected output: %s", out)
+			}
+			errs <- err
+		}()
+	}
+	for range 16 {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// BenchmarkExecutePage renders a page through baseof with a theme of 20
+// partials, comparing the precompiled template sets against parsing a fresh
+// set for every render.
+func TestSetBuildDate(t *testing.T) {
+	themeDir := t.TempDir()
+	layoutDir := filepath.Join(themeDir, "layouts", "_default")
+	if err := os.MkdirAll(layoutDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	single := `{{ now.Format "2006-01-02" }} {{ range shuffle (slice 1 2 3 4 5 6 7 8 9) }}{{ . }}{{ end }}`
+	if err := os.WriteFile(filepath.Join(layoutDir, "single.html"), []byte(single), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	render := func() string {
+		t.Helper()
+		eng, err := NewEngine(themeDir, "")
+		if err != nil {
+			t.Fatalf("NewEngine failed: %v", err)
+		}
+		eng.SetBuildDate(time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC))
+		out, err := eng.Execute("_default/single.html", &PageContext{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return string(out)
+	}
+
+	first := render()
+	if !strings.HasPrefix(first, "2001-02-03 ") {
+		t.Errorf("now = %q, want the build date", first)
+	}
+	for range 5 {
+		if got := render(); got != first {
+			t.Fatalf("render = %q, then %q; want the same output for the same build date", first, got)
+		}
+	}
+}
+
+func TestSiteGetPage(t *testing.T) {
+	hero := &PageContext{Title: "Hero", Path: "snippets/hero.md"}
+	bundle := &PageContext{Title: "Bundle", Path: "blog/trip/index.md"}
+	section := &PageContext{Title: "Blog", Path: "blog/_index.md"}
+	home := &PageContext{Title: "Home", Path: "_index.md"}
+	site := &SiteContext{AllPages: []*PageContext{{Title: "Tags"}, hero, bundle, section, home}}
+
+	tests := map[string]*PageContext{
+		"snippets/hero":     hero,
+		"/snippets/hero.md": hero,
+		"blog/trip":         bundle,
+		"blog/trip/":        bundle,
+		"blog":              section,
+		"/":                 home,
+		"snippets/missing":  nil,
+	}
+	for path, want := range tests {
+		if got := site.GetPage(path); got != want {
+			t.Errorf("GetPage(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func BenchmarkExecutePage(b *testing.B) {
+	themeDir := b.TempDir()
+	layoutDir := filepath.Join(themeDir, "layouts")
+	files := map[string]string{
+		"_default/baseof.html": `<!DOCTYPE html><html><head>{{ partial "head.html" . }}</head>` +
+			`<body>{{ partial "header.html" . }}{{ block "main" . }}{{ end }}{{ partial "footer.html" . }}</body></html>`,
+		"_default/single.html": `{{ define "main" }}<article><h1>{{ .Title }}</h1>{{ .Content }}{{ partial "tags.html" . }}</article>{{ end }}`,
+		"_default/list.html":   `{{ define "main" }}<section>{{ range .Site.Pages }}{{ partial "card.html" . }}{{ end }}</section>{{ end }}`,
+		"partials/head.html":   `<title>{{ .Title }} | {{ .Site.Title }}</title><meta name="description" content="{{ .Description }}">`,
+		"partials/header.html": `<header><a href="/">{{ .Site.Title }}</a>{{ range .Site.Menu }}<a href="{{ .URL }}">{{ .Name }}</a>{{ end }}</header>`,
+		"partials/footer.html": `<footer>&copy; {{ .Site.Author.Name }}</footer>`,
+		"partials/tags.html":   `<ul>{{ range .Tags }}<li><a href="/tags/{{ slugify . }}/">{{ . }}</a></li>{{ end }}</ul>`,
+		"partials/card.html":   `<div class="card"><a href="{{ .URL }}">{{ .Title }}</a></div>`,
+	}
+	for i := range 15 {
+		files[fmt.Sprintf("partials/extra-%d.html", i)] = `{{ if .Params.flag }}<div>{{ truncate 10 .Title }}</div>{{ end }}`
+	}
+	for name, content := range files {
+		path := filepath.Join(layoutDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	eng, err := NewEngine(themeDir, "")
+	if err != nil {
+		b.Fatalf("NewEngine failed: %v", err)
+	}
+	ctx := &PageContext{
+		Title:   "Benchmark Post",
+		Content: template.HTML("<p>Hello, world.</p>"),
+		Tags:    []string{"go", "templates"},
+		Site: &SiteContext{
+			Title: "Bench Site",
+			Menu:  []MenuItemContext{{Name: "Blog", URL: "/blog/"}, {Name: "About", URL: "/about/"}},
+		},
+	}
+
+	b.Run("precompiled", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := eng.ExecutePage("_default/single.html", ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// The previous strategy: parse a fresh set for every render.
+	b.Run("parse-per-render", func(b *testing.B) {
+		for b.Loop() {
+			set, err := eng.parsePageSet("_default/single.html")
+			if err != nil {
+				b.Fatal(err)
+			}
+			set.Funcs(template.FuncMap{"partial": setPartial(set)})
+			var buf bytes.Buffer
+			if err := set.Lookup(baseofName).Execute(&buf, ctx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRenderShortcode(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"_default/single.html":       `{{ .Content }}`,
+		"shortcodes/note.html":       `<aside title="{{ .Get 0 }}" data-page="{{ .Page.Title }}">{{ .Inner }}</aside>`,
+		"shortcodes/named.html":      `{{ .Name }}:{{ .Get "id" }}:{{ .Get "missing" }}`,
+		"shortcodes/broken.html":     `{{ .Nope }}`,
+		"partials/unrelated.html":    `partial`,
+		"shortcodes/sub/nested.html": `nested`,
+	} {
+		fullPath := filepath.Join(tmp, "layouts", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng, err := NewEngine(tmp, "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if !eng.HasShortcodes() {
+		t.Error("HasShortcodes() = false, want true")
+	}
+	page := &PageContext{Title: "Post"}
+
+	tests := []struct {
+		sc   *content.Shortcode
+		want string
+	}{
+		{
+			&content.Shortcode{Name: "note", Params: map[string]string{"0": "Tip"}, Inner: "<b>hi</b>"},
+			`<aside title="Tip" data-page="Post"><b>hi</b></aside>`,
+		},
+		{
+			&content.Shortcode{Name: "named", Params: map[string]string{"id": "x"}},
+			`named:x:`,
+		},
+		{
+			&content.Shortcode{Name: "sub/nested"},
+			`nested`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := eng.RenderShortcode(tt.sc, page, &SiteContext{})
+		if err != nil {
+			t.Errorf("RenderShortcode(%s) error: %v", tt.sc.Name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderShortcode(%s) = %q, want %q", tt.sc.Name, got, tt.want)
+		}
+	}
+
+	if _, err := eng.RenderShortcode(&content.Shortcode{Name: "missing"}, page, &SiteContext{}); err == nil {
+		t.Error("RenderShortcode(missing) should fail")
+	}
+	if _, err := eng.RenderShortcode(&content.Shortcode{Name: "broken"}, page, &SiteContext{}); err == nil {
+		t.Error("RenderShortcode(broken) should fail")
+	}
+
+	// The default theme in testdata has no shortcodes.
+	eng, err = NewEngine(testdataThemePath(t), "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if eng.HasShortcodes() {
+		t.Error("HasShortcodes() = true for a theme without shortcodes")
+	}
+}
+
+func TestRenderHook(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"_default/single.html":        `{{ .Content }}`,
+		"_markup/render-link.html":    `<a href="{{ .Destination }}"{{ with .Title }} title="{{ . }}"{{ end }} rel="noopener">{{ .Text }}</a>`,
+		"_markup/render-heading.html": `<h{{ .Level }} id="{{ .Anchor }}" class="{{ index .Attributes "class" }}" data-page="{{ .Page.Title }}">{{ .Text }}</h{{ .Level }}>`,
+	} {
+		fullPath := filepath.Join(tmp, "layouts", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eng, err := NewEngine(tmp, "")
+	if err != nil {
+		t.Fatalf("NewEngine failed: %v", err)
+	}
+	if got, want := eng.RenderHooks(), []string{"link", "heading"}; !slices.Equal(got, want) {
+		t.Errorf("RenderHooks() = %v, want %v", got, want)
+	}
+	page := &PageContext{Title: "Post"}
+
+	tests := []struct {
+		hook *content.RenderHook
+		want string
+	}{
+		{
+			&content.RenderHook{Kind: "link", Destination: "https://example.com", Text: "<em>x</em>"},
+			`<a href="https://example.com" rel="noopener"><em>x</em></a>`,
+		},
+		{
+			&content.RenderHook{Kind: "heading", Level: 2, Anchor: "intro", Text: "Intro", Attributes: map[string]any{"class": "big"}},
+			`<h2 id="intro" class="big" data-page="Post">Intro</h2>`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := eng.RenderHook(tt.hook, page)
+		if err != nil {
+			t.Errorf("RenderHook(%s) error: %v", tt.hook.Kind, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RenderHook(%s) = %q, want %q", tt.hook.Kind, got, tt.want)
+		}
+	}
+
+	if _, err := eng.RenderHook(&content.RenderHook{Kind: "image"}, page); err == nil {
+		t.Error("RenderHook(image) should fail without a template")
+	}
+}
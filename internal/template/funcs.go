@@ -11,7 +11,12 @@ import (
 	"sort"
 	"strings"
 	"time"
-	"unicode"
+
+	"github.com/aellingwood/forge/internal/content"
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
 )
 
 // FuncMap returns the custom template functions available to all Forge templates.
@@ -23,6 +28,8 @@ func FuncMap() template.FuncMap {
 		"truncate":    truncate,
 		"slugify":     slugify,
 		"safeHTML":    safeHTML,
+		"highlight":   highlight,
+		"hasPrefix":   strings.HasPrefix,
 
 		// Collection functions
 		"first":   first,
@@ -40,6 +47,15 @@ func FuncMap() template.FuncMap {
 		"relURL": relURL,
 		"absURL": absURL,
 
+		// Asset functions — the Engine rebinds these to the build's asset
+		// manifest; without one, paths are returned unfingerprinted.
+		"asset":       func(path string) string { return assetURL(nil, path) },
+		"fingerprint": func(path string) string { return assetURL(nil, path) },
+
+		// termURL links a taxonomy term page; the Engine rebinds it to the
+		// site's URL style and permalinks.
+		"termURL": func(taxonomy, term string) string { return termURL(false, nil, taxonomy, term) },
+
 		// Data functions
 		"readFile": readFile,
 
@@ -120,22 +136,10 @@ func truncate(n int, s string) string {
 }
 
 // slugify converts a string to a URL-safe slug: lowercase, hyphens for
-// spaces/special chars, stripped of non-alphanumeric characters.
+// spaces/special chars, stripped of non-alphanumeric characters. It is the
+// slug taxonomy term URLs use (content.TermSlug).
 func slugify(s string) string {
-	s = strings.ToLower(s)
-	var b strings.Builder
-	prevHyphen := false
-	for _, r := range s {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			b.WriteRune(r)
-			prevHyphen = false
-		} else if !prevHyphen && b.Len() > 0 {
-			b.WriteRune('-')
-			prevHyphen = true
-		}
-	}
-	result := strings.TrimRight(b.String(), "-")
-	return result
+	return content.TermSlug(s)
 }
 
 // safeHTML marks a string as safe HTML so Go templates will not escape it.
@@ -143,6 +147,25 @@ func safeHTML(s string) template.HTML {
 	return template.HTML(s)
 }
 
+// highlight syntax-highlights code as HTML with the Chroma classes fenced
+// code blocks get. lang is a language name, alias, or file name; code in
+// an unknown language is left plain.
+func highlight(code any, lang string) (template.HTML, error) {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, fmt.Sprint(code))
+	if err != nil {
+		return "", fmt.Errorf("highlight: %w", err)
+	}
+	var buf strings.Builder
+	if err := chromahtml.New(chromahtml.WithClasses(true)).Format(&buf, styles.Fallback, iterator); err != nil {
+		return "", fmt.Errorf("highlight: %w", err)
+	}
+	return template.HTML(buf.String()), nil
+}
+
 // --- Collection functions ---
 
 // first returns the first n items from a slice. If the slice has fewer than n
@@ -227,6 +250,12 @@ func sortBy(items []*PageContext, field string) []*PageContext {
 
 // shuffle returns a new slice with the elements in random order.
 func shuffle(items any) any {
+	return shuffleSeeded(items, time.Now().UnixNano())
+}
+
+// shuffleSeeded is shuffle with a fixed seed: the same seed always yields
+// the same order.
+func shuffleSeeded(items any, seed int64) any {
 	v := reflect.ValueOf(items)
 	if v.Kind() != reflect.Slice {
 		return items
@@ -235,7 +264,7 @@ func shuffle(items any) any {
 	result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
 	reflect.Copy(result, v)
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r := rand.New(rand.NewSource(seed))
 	for i := result.Len() - 1; i > 0; i-- {
 		j := r.Intn(i + 1)
 		tmp := result.Index(i).Interface()
@@ -312,6 +341,30 @@ func absURL(baseURL, path string) string {
 	return baseURL + path
 }
 
+// --- Asset functions ---
+
+// assetURL returns the fingerprinted URL that manifest records for path
+// (e.g. "/css/style.css" -> "/css/style.3f9a1c2b7d4e.css"). Paths without an
+// entry are returned as-is, with a leading slash.
+func assetURL(manifest map[string]string, path string) string {
+	path = relURL(path)
+	if hashed, ok := manifest[path]; ok {
+		return hashed
+	}
+	return path
+}
+
+// termURL returns the URL of the page for term in taxonomy, e.g.
+// "/tags/machine-learning/", or "/tags/machine-learning/index.html" with
+// ugly URLs, following the taxonomy's pattern in permalinks if it has one.
+func termURL(ugly bool, permalinks map[string]string, taxonomy, term string) string {
+	url := content.TermPermalink(permalinks, taxonomy, term)
+	if ugly {
+		url = content.UglyURL(url, true)
+	}
+	return url
+}
+
 // --- Data functions ---
 
 // readFile reads and returns the contents of a file. The path is resolved
@@ -1,6 +1,7 @@
 package template
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -70,3 +71,44 @@ func TestJoin(t *testing.T) {
 		})
 	}
 }
+
+func TestAssetURL(t *testing.T) {
+	manifest := map[string]string{"/css/style.css": "/css/style.3f9a1c2b7d4e.css"}
+	tests := []struct {
+		name     string
+		manifest map[string]string
+		path     string
+		want     string
+	}{
+		{"fingerprinted", manifest, "/css/style.css", "/css/style.3f9a1c2b7d4e.css"},
+		{"no leading slash", manifest, "css/style.css", "/css/style.3f9a1c2b7d4e.css"},
+		{"not in manifest", manifest, "/js/app.js", "/js/app.js"},
+		{"nil manifest", nil, "css/style.css", "/css/style.css"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assetURL(tt.manifest, tt.path); got != tt.want {
+				t.Errorf("assetURL(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	got, err := highlight("x := 1", "go")
+	if err != nil {
+		t.Fatalf("highlight() error: %v", err)
+	}
+	if !strings.Contains(string(got), `<pre class="chroma">`) || !strings.Contains(string(got), `<span class="o">:=</span>`) {
+		t.Errorf("highlight(go) = %q, want chroma classes", got)
+	}
+
+	// Unknown languages fall back to plain, escaped text.
+	got, err = highlight("<b>", "no-such-lang")
+	if err != nil {
+		t.Fatalf("highlight() error: %v", err)
+	}
+	if !strings.Contains(string(got), "&lt;b&gt;") {
+		t.Errorf("highlight(unknown) = %q, want escaped text", got)
+	}
+}